@@ -2,20 +2,17 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
-	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
-
-	"github.com/obsidianstack/obsidianstack/agent/internal/compute"
 	"github.com/obsidianstack/obsidianstack/agent/internal/config"
-	"github.com/obsidianstack/obsidianstack/agent/internal/scraper"
-	"github.com/obsidianstack/obsidianstack/agent/internal/security"
+	"github.com/obsidianstack/obsidianstack/agent/internal/runner"
 	"github.com/obsidianstack/obsidianstack/agent/internal/shipper"
+	"github.com/obsidianstack/obsidianstack/pkg/admin"
 )
 
 func main() {
@@ -41,76 +38,62 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	// Build scraper + engine instances from the initial config.
-	// Hot-reload updates logging only; rebuilding scrapers on reload is T-future.
-	type pipeline struct {
-		src    config.Source
-		s      scraper.Scraper
-		engine *compute.Engine
-	}
-	var pipelines []pipeline
-	for _, src := range cfg.Agent.Sources {
-		s, err := scraper.New(src)
-		if err != nil {
-			slog.Error("skipping source — could not build scraper", "source", src.ID, "err", err)
-			continue
-		}
-		pipelines = append(pipelines, pipeline{src: src, s: s, engine: compute.NewEngine()})
-		slog.Info("registered source", "id", src.ID, "type", src.Type, "endpoint", src.Endpoint)
+	// Start the gRPC shipper — runs until ctx is cancelled. With a WAL
+	// configured this replays snapshots left over from the previous run.
+	ship, err := shipper.New(cfg.Agent)
+	if err != nil {
+		slog.Error("failed to start shipper", "err", err)
+		os.Exit(1)
 	}
+	shipDone := make(chan struct{})
+	go func() {
+		ship.Run(ctx)
+		close(shipDone)
+	}()
 
-	if len(pipelines) == 0 {
-		slog.Warn("no sources configured — agent will idle")
-	}
+	// Build pipelines from the initial config, then scrape every
+	// ScrapeInterval: compute strength score, ship.
+	run := runner.New(ship)
+	run.Apply(cfg.Agent)
+	runDone := make(chan struct{})
+	go func() {
+		run.Run(ctx)
+		close(runDone)
+	}()
 
-	// Watch config file for hot-reload (logs only in this phase).
+	// Serve the agent's own metrics and health probes. The agent is ready
+	// once the shipper holds a server connection. admin_port is read once at
+	// startup; changing it needs a restart.
+	reg := admin.NewRegistry(append(runner.Collectors(), ship.Collectors()...)...)
+	go admin.Serve(ctx, cfg.Agent.AdminPort, admin.Handler(reg, admin.Check{
+		Name: "shipper",
+		Fn: func() error {
+			if !ship.Connected() {
+				return errors.New("not connected to server")
+			}
+			return nil
+		},
+	}))
+
+	// Watch config file for hot-reload: reconcile sources and scrape interval,
+	// and redial the server if its endpoint or auth changed.
 	go func() {
 		if err := config.Watch(ctx, *configPath, func(updated *config.Config) {
 			slog.Info("config hot-reloaded", "sources", len(updated.Agent.Sources))
+			run.Apply(updated.Agent)
+			ship.Reconfigure(updated.Agent)
 		}); err != nil {
 			slog.Error("config watcher stopped", "err", err)
 		}
 	}()
 
-	// Start the gRPC shipper — runs until ctx is cancelled.
-	ship := shipper.New(cfg.Agent)
-	go ship.Run(ctx)
-
-	// Scrape loop: poll every ScrapeInterval, compute strength score, ship.
-	go func() {
-		ticker := time.NewTicker(cfg.Agent.ScrapeInterval)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case t := <-ticker.C:
-				for _, p := range pipelines {
-					res, err := p.s.Scrape(ctx)
-					if err != nil {
-						slog.Warn("scrape error", "source", p.src.ID, "err", err)
-						continue
-					}
-
-					// TLS cert check — runs only for HTTPS endpoints.
-					var certs []*pb.CertStatus
-					if cs := security.Check(ctx, p.src); cs != nil {
-						certs = []*pb.CertStatus{cs}
-					}
-
-					if result := p.engine.Process(res, t); result != nil {
-						ship.Ship(result, certs)
-						slog.Debug("shipped snapshot",
-							"source", p.src.ID,
-							"state", result.State,
-							"score", result.StrengthScore,
-						)
-					}
-				}
-			}
-		}
-	}()
-
 	<-ctx.Done()
-	slog.Info("obsidianstack-agent shutting down")
+	slog.Info("obsidianstack-agent shutting down", "queued", ship.Depth())
+	// Scrapes still in flight ship into the buffer; close it only once
+	// they and the sender are done, so none is dropped on "wal: closed".
+	<-runDone
+	<-shipDone
+	if err := ship.Close(); err != nil {
+		slog.Error("closing shipper buffer", "err", err)
+	}
 }
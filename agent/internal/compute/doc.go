@@ -8,5 +8,14 @@
 // baselines and derives per-minute rates from deltas between scrape cycles.
 // Engine.Process accepts an injectable time.Time so tests are deterministic.
 //
-// Health state thresholds: Healthy ≥85, Degraded 60–84, Critical <60, Unknown.
+// latency.go derives P50/P95/P99 from the per-bucket deltas of a scraper's
+// latency histogram (PromQL histogram_quantile interpolation). Synthetic http
+// probes have no histogram; their percentiles come from a rolling window of
+// response times instead.
+//
+// scoring.go holds the per-source Scoring overrides (baseline latency, weights,
+// thresholds) built from config and applied via Engine.SetScoring.
+//
+// Default health state thresholds: Healthy ≥85, Degraded 60–84, Critical <60,
+// Unknown.
 package compute
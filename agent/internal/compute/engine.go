@@ -2,6 +2,8 @@ package compute
 
 import (
 	"log/slog"
+	"math"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -27,8 +29,12 @@ type Result struct {
 	ThroughputPM  float64 // total items/min across all signal types
 	StrengthScore float64
 	UptimePct     float64
+	LatencyP50Ms  float64
+	LatencyP95Ms  float64
+	LatencyP99Ms  float64
+	Thresholds    Thresholds // state thresholds State was derived with
 	Signals       []SignalResult
-	ErrorMessage  string            // non-empty when the scrape failed; forwarded to the server
+	ErrorMessage  string            // non-empty when the scrape or probe failed; forwarded to the server
 	Extra         map[string]float64 // component-specific metrics (e.g. queue_size, exporter_sent_*)
 }
 
@@ -54,6 +60,23 @@ func NewEngine() *Engine {
 	return &Engine{states: make(map[string]*sourceState)}
 }
 
+// SetScoring sets the scoring overrides used for sourceID from the next
+// Process call on. It may be called again at any time, e.g. on config reload.
+func (e *Engine) SetScoring(sourceID string, sc Scoring) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stateFor(sourceID).scoring = sc
+}
+
+// Forget drops all state held for sourceID — counter baselines, uptime and
+// latency history, scoring overrides. The next Process call for it behaves
+// like the very first one.
+func (e *Engine) Forget(sourceID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.states, sourceID)
+}
+
 // Process ingests a ScrapeResult and returns derived health metrics.
 //
 // now is passed explicitly so callers (and tests) control the clock without
@@ -61,13 +84,17 @@ func NewEngine() *Engine {
 //
 // The first call for a source records the baseline counter values and returns
 // a Result with State "unknown" — rates cannot be computed without a delta.
+// A probe that found its target down is "critical" from the first call.
 func (e *Engine) Process(res *scraper.ScrapeResult, now time.Time) *Result {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	st := e.stateFor(res.SourceID)
-	success := res.Err == nil
+	success := res.Err == nil && res.ProbeErr == nil
 	st.recordScrape(success)
+	if res.Err == nil && res.ResponseTime > 0 {
+		st.recordLatency(float64(res.ResponseTime) / float64(time.Millisecond))
+	}
 
 	// Always build a base result so callers always get something back.
 	out := &Result{
@@ -75,9 +102,10 @@ func (e *Engine) Process(res *scraper.ScrapeResult, now time.Time) *Result {
 		SourceType: res.SourceType,
 		Timestamp:  now,
 		UptimePct:  st.uptimePct(),
+		Thresholds: effectiveThresholds(st.scoring.Thresholds),
 	}
 
-	if !success {
+	if res.Err != nil {
 		slog.Warn("compute: scrape failed, marking unknown",
 			"source", res.SourceID, "err", res.Err)
 		out.State = StateUnknown
@@ -86,6 +114,26 @@ func (e *Engine) Process(res *scraper.ScrapeResult, now time.Time) *Result {
 		return out
 	}
 
+	if res.ProbeErr != nil {
+		// The probe answered: the target is down. Report it, with the
+		// probe's gauges and the latency of the responses it did get.
+		slog.Warn("compute: probe failed, marking critical",
+			"source", res.SourceID, "err", res.ProbeErr)
+		out.State = StateCritical
+		out.ErrorMessage = res.ProbeErr.Error()
+		out.LatencyP50Ms = percentile(st.latencies, 50)
+		out.LatencyP95Ms = percentile(st.latencies, 95)
+		out.LatencyP99Ms = percentile(st.latencies, 99)
+		out.Extra = make(map[string]float64, len(res.Extra))
+		for k, v := range res.Extra {
+			if isGauge(k) {
+				out.Extra[k] = v
+			}
+		}
+		st.updateBaseline(res, now)
+		return out
+	}
+
 	if !st.hasBaseline {
 		// First successful scrape — store counters but return unknown,
 		// since we cannot compute any rates yet.
@@ -139,23 +187,40 @@ func (e *Engine) Process(res *scraper.ScrapeResult, now time.Time) *Result {
 	// retry-success counters for a more precise signal.
 	out.RecoveryRate = 100 - out.DropPct
 
+	// Latency percentiles: from the latency histogram's bucket deltas when the
+	// scraper exposes one, otherwise over the rolling window of probe response
+	// times. Both are empty for components without latency data, which leaves
+	// the fields at 0.
+	if res.Latency != nil {
+		buckets := histogramDelta(res.Latency, st.prev.Latency)
+		out.LatencyP50Ms = histogramQuantile(0.50, buckets)
+		out.LatencyP95Ms = histogramQuantile(0.95, buckets)
+		out.LatencyP99Ms = histogramQuantile(0.99, buckets)
+	} else {
+		out.LatencyP50Ms = percentile(st.latencies, 50)
+		out.LatencyP95Ms = percentile(st.latencies, 95)
+		out.LatencyP99Ms = percentile(st.latencies, 99)
+	}
+
 	scoreOut := Compute(Input{
 		DropPct:      out.DropPct,
 		RecoveryRate: out.RecoveryRate,
 		UptimePct:    out.UptimePct,
-		// LatencyP95ms and BaselineLatencyMs default to 0 until T011 adds
-		// latency data; the latency factor then defaults to 1.0 (full credit).
+		LatencyP95ms: out.LatencyP95Ms,
+		// BaselineLatencyMs is 0 unless the source configures one, in which
+		// case the latency factor stays at 1.0 (full credit).
+		BaselineLatencyMs: st.scoring.BaselineLatencyMs,
+		Weights:           st.scoring.Weights,
+		Thresholds:        st.scoring.Thresholds,
 	})
 	out.State = scoreOut.State
 	out.StrengthScore = scoreOut.Score
 
 	// Compute per-minute rates for Extra counter fields; copy gauges as-is.
-	// Convention: fields ending in "_size" or "_capacity" are gauges (current
-	// value). Everything else is a monotonic counter — compute delta/elapsed.
 	if len(res.Extra) > 0 {
 		out.Extra = make(map[string]float64, len(res.Extra)*2)
 		for k, v := range res.Extra {
-			if strings.HasSuffix(k, "_size") || strings.HasSuffix(k, "_capacity") {
+			if isGauge(k) {
 				out.Extra[k] = v
 			} else {
 				var prev float64
@@ -171,12 +236,24 @@ func (e *Engine) Process(res *scraper.ScrapeResult, now time.Time) *Result {
 	return out
 }
 
+// isGauge reports whether an Extra key holds a gauge (current value) rather
+// than a monotonic counter.
+// Convention: fields ending in "_size" or "_capacity", and synthetic probe
+// fields prefixed "probe_", are gauges. Everything else is a counter.
+func isGauge(key string) bool {
+	return strings.HasSuffix(key, "_size") ||
+		strings.HasSuffix(key, "_capacity") ||
+		strings.HasPrefix(key, "probe_")
+}
+
 // sourceState holds per-source counters and uptime history.
 type sourceState struct {
 	prev        *scraper.ScrapeResult
 	prevTime    time.Time
 	hasBaseline bool
-	history     []bool // circular buffer of scrape outcomes, newest last
+	history     []bool    // circular buffer of scrape outcomes, newest last
+	latencies   []float64 // circular buffer of probe response times (ms), newest last
+	scoring     Scoring   // per-source overrides set via SetScoring
 }
 
 func (e *Engine) stateFor(id string) *sourceState {
@@ -203,6 +280,13 @@ func (st *sourceState) recordScrape(success bool) {
 	st.history = append(st.history, success)
 }
 
+func (st *sourceState) recordLatency(ms float64) {
+	if len(st.latencies) >= uptimeWindow {
+		st.latencies = st.latencies[1:]
+	}
+	st.latencies = append(st.latencies, ms)
+}
+
 func (st *sourceState) uptimePct() float64 {
 	if len(st.history) == 0 {
 		return 100 // assume up before first observation
@@ -216,6 +300,14 @@ func (st *sourceState) uptimePct() float64 {
 	return float64(ok) / float64(len(st.history)) * 100
 }
 
+// effectiveThresholds returns th, or DefaultThresholds when th is unset.
+func effectiveThresholds(th Thresholds) Thresholds {
+	if th == (Thresholds{}) {
+		return DefaultThresholds
+	}
+	return th
+}
+
 // deltaOf returns the positive counter delta between current and previous.
 // If current < previous (counter reset after restart), returns 0.
 func deltaOf(current, previous float64) float64 {
@@ -225,3 +317,19 @@ func deltaOf(current, previous float64) float64 {
 	}
 	return d
 }
+
+// percentile returns the nearest-rank p-th percentile (0–100) of samples.
+// Returns 0 for an empty slice. samples is not modified.
+func percentile(samples []float64, p float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(samples))
+	copy(sorted, samples)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
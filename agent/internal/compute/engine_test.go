@@ -367,3 +367,183 @@ func TestDeltaOf(t *testing.T) {
 		}
 	}
 }
+
+// --- Synthetic probe latency ---
+
+func probeResult(id string, rt time.Duration) *scraper.ScrapeResult {
+	r := makeResult(id, "http", map[string]float64{}, map[string]float64{})
+	r.ResponseTime = rt
+	r.Extra["probe_status_code"] = 200
+	r.Extra["probe_response_ms"] = float64(rt / time.Millisecond)
+	return r
+}
+
+func TestEngine_ProbeLatencyPercentiles(t *testing.T) {
+	e := NewEngine()
+	var out *Result
+	for i := 1; i <= 10; i++ {
+		out = e.Process(probeResult("grafana", time.Duration(i*10)*time.Millisecond), tick(i))
+	}
+
+	// Window holds 10, 20, ..., 100 ms.
+	if out.LatencyP50Ms != 50 {
+		t.Errorf("LatencyP50Ms = %v, want 50", out.LatencyP50Ms)
+	}
+	if out.LatencyP95Ms != 100 {
+		t.Errorf("LatencyP95Ms = %v, want 100", out.LatencyP95Ms)
+	}
+	if out.LatencyP99Ms != 100 {
+		t.Errorf("LatencyP99Ms = %v, want 100", out.LatencyP99Ms)
+	}
+	if out.State != StateHealthy {
+		t.Errorf("State = %q, want healthy", out.State)
+	}
+}
+
+func TestEngine_ProbeExtraIsGauge(t *testing.T) {
+	e := NewEngine()
+	e.Process(probeResult("grafana", 40*time.Millisecond), tick(0))
+	out := e.Process(probeResult("grafana", 40*time.Millisecond), tick(1))
+
+	if got := out.Extra["probe_status_code"]; got != 200 {
+		t.Errorf("Extra[probe_status_code] = %v, want 200 (gauge copied as-is)", got)
+	}
+	if _, ok := out.Extra["probe_status_code_pm"]; ok {
+		t.Error("probe_ fields must not be converted to _pm rates")
+	}
+}
+
+func TestEngine_ProbeDown_CountsAgainstUptime(t *testing.T) {
+	e := NewEngine()
+	e.Process(probeResult("grafana", 40*time.Millisecond), tick(0))
+	down := probeResult("grafana", 0)
+	down.ProbeErr = errors.New("unexpected status 503")
+	e.Process(down, tick(1))
+	out := e.Process(probeResult("grafana", 40*time.Millisecond), tick(2))
+
+	want := 2.0 / 3.0 * 100
+	if diff := out.UptimePct - want; diff > 0.01 || diff < -0.01 {
+		t.Errorf("UptimePct = %.2f, want %.2f", out.UptimePct, want)
+	}
+}
+
+func TestEngine_ProbeDown_ReportsCritical(t *testing.T) {
+	e := NewEngine()
+	down := probeResult("grafana", 0) // first scrape: no baseline needed
+	down.ProbeErr = errors.New("unexpected status 503")
+	down.Extra["probe_status_code"] = 503
+	out := e.Process(down, tick(0))
+
+	if out.State != StateCritical || out.ErrorMessage != "unexpected status 503" {
+		t.Errorf("State = %q, ErrorMessage = %q; want critical with the probe error", out.State, out.ErrorMessage)
+	}
+	if got := out.Extra["probe_status_code"]; got != 503 {
+		t.Errorf("Extra[probe_status_code] = %v, want 503", got)
+	}
+	if out.UptimePct != 0 {
+		t.Errorf("UptimePct = %v, want 0", out.UptimePct)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	if got := percentile(nil, 95); got != 0 {
+		t.Errorf("percentile(nil) = %v, want 0", got)
+	}
+	samples := []float64{30, 10, 20}
+	if got := percentile(samples, 50); got != 20 {
+		t.Errorf("percentile(p50) = %v, want 20", got)
+	}
+	if samples[0] != 30 {
+		t.Error("percentile must not reorder its input")
+	}
+}
+
+// --- Histogram latency ---
+
+func TestEngine_HistogramLatencyFromDeltas(t *testing.T) {
+	e := NewEngine()
+
+	first := makeResult("otel-1", "otelcol", map[string]float64{"traces": 1000}, map[string]float64{})
+	// History before the first scrape is all fast (<=10ms) — it must not count.
+	first.Latency = hist(1000, 1000, 1000, 1000)
+	e.Process(first, tick(0))
+
+	second := makeResult("otel-1", "otelcol", map[string]float64{"traces": 2000}, map[string]float64{})
+	// 100 new observations, all in (100, 1000].
+	second.Latency = hist(1000, 1000, 1100, 1100)
+	out := e.Process(second, tick(1))
+
+	if out.LatencyP50Ms != 550 {
+		t.Errorf("LatencyP50Ms = %v, want 550", out.LatencyP50Ms)
+	}
+	if out.LatencyP99Ms <= out.LatencyP95Ms || out.LatencyP95Ms <= out.LatencyP50Ms {
+		t.Errorf("percentiles not ordered: p50=%v p95=%v p99=%v",
+			out.LatencyP50Ms, out.LatencyP95Ms, out.LatencyP99Ms)
+	}
+}
+
+func TestEngine_HistogramLatency_NoNewObservations(t *testing.T) {
+	e := NewEngine()
+	r1 := makeResult("prom", "prometheus", map[string]float64{"metrics": 10}, map[string]float64{})
+	r1.Latency = hist(5, 10, 10, 10)
+	e.Process(r1, tick(0))
+
+	r2 := makeResult("prom", "prometheus", map[string]float64{"metrics": 20}, map[string]float64{})
+	r2.Latency = hist(5, 10, 10, 10)
+	out := e.Process(r2, tick(1))
+
+	if out.LatencyP95Ms != 0 {
+		t.Errorf("LatencyP95Ms with no new observations = %v, want 0", out.LatencyP95Ms)
+	}
+}
+
+func TestEngine_SetScoring_PerSource(t *testing.T) {
+	e := NewEngine()
+	e.SetScoring("jaeger", Scoring{
+		BaselineLatencyMs: 1000,
+		Thresholds:        Thresholds{Healthy: 70, Degraded: 40},
+	})
+
+	for _, id := range []string{"jaeger", "otel"} {
+		r1 := makeResult(id, "jaeger", map[string]float64{"traces": 1000}, map[string]float64{})
+		r1.Latency = hist(0, 0, 0, 0)
+		e.Process(r1, tick(0))
+	}
+
+	process := func(id string) *Result {
+		r := makeResult(id, "jaeger", map[string]float64{"traces": 2000}, map[string]float64{})
+		// 100 observations in (100, 1000] → P95 = 955ms.
+		r.Latency = hist(0, 0, 100, 100)
+		return e.Process(r, tick(1))
+	}
+
+	tuned := process("jaeger")
+	if tuned.StrengthScore >= ThresholdHealthy {
+		t.Errorf("jaeger score = %.2f, want latency penalty below %v", tuned.StrengthScore, ThresholdHealthy)
+	}
+	if tuned.State != StateHealthy {
+		t.Errorf("jaeger State = %q, want healthy under its own thresholds", tuned.State)
+	}
+	if tuned.Thresholds != (Thresholds{Healthy: 70, Degraded: 40}) {
+		t.Errorf("jaeger Thresholds = %+v, want 70/40", tuned.Thresholds)
+	}
+
+	def := process("otel")
+	if !almostEqual(def.StrengthScore, 100, 0.001) {
+		t.Errorf("otel score = %.2f, want 100 without a baseline", def.StrengthScore)
+	}
+	if def.Thresholds != DefaultThresholds {
+		t.Errorf("otel Thresholds = %+v, want defaults", def.Thresholds)
+	}
+}
+
+func TestEngine_Forget_ResetsBaseline(t *testing.T) {
+	e := NewEngine()
+	e.Process(makeResult("otel", "otelcol", map[string]float64{"traces": 100}, nil), tick(0))
+	e.Forget("otel")
+
+	out := e.Process(makeResult("otel", "otelcol", map[string]float64{"traces": 200}, nil), tick(1))
+	if out.State != StateUnknown {
+		t.Errorf("State after Forget = %q, want %q (no baseline)", out.State, StateUnknown)
+	}
+}
@@ -0,0 +1,87 @@
+package compute
+
+import (
+	"math"
+
+	"github.com/obsidianstack/obsidianstack/agent/internal/scraper"
+)
+
+// histogramDelta returns the per-bucket observation counts recorded between
+// prev and cur, keeping the cumulative shape. If cur has fewer observations
+// than prev (counter reset after a restart) cur is returned unchanged, since
+// everything it holds was observed after the reset.
+//
+// A bucket bound missing from prev is treated as 0 there.
+func histogramDelta(cur, prev *scraper.Histogram) []scraper.Bucket {
+	if cur == nil {
+		return nil
+	}
+	if prev == nil || cur.Count < prev.Count {
+		return cur.Buckets
+	}
+	prevByBound := make(map[float64]float64, len(prev.Buckets))
+	for _, b := range prev.Buckets {
+		prevByBound[b.UpperMs] = b.Count
+	}
+	out := make([]scraper.Bucket, len(cur.Buckets))
+	for i, b := range cur.Buckets {
+		out[i] = scraper.Bucket{UpperMs: b.UpperMs, Count: deltaOf(b.Count, prevByBound[b.UpperMs])}
+	}
+	return out
+}
+
+// histogramQuantile estimates the q-quantile (0–1) from cumulative buckets
+// sorted by ascending bound, interpolating linearly inside the bucket that
+// holds the target rank — the same method as PromQL's histogram_quantile.
+//
+// If the rank falls in the +Inf bucket, the highest finite bound is returned.
+// Returns 0 when the buckets hold no observations. A count lower than the one
+// below it (a bound missing from some scrape) is raised to it first, as PromQL
+// does, so the estimate stays within the buckets.
+func histogramQuantile(q float64, buckets []scraper.Bucket) float64 {
+	if len(buckets) == 0 {
+		return 0
+	}
+	buckets = monotonic(buckets)
+	total := buckets[len(buckets)-1].Count
+	if total <= 0 {
+		return 0
+	}
+	rank := q * total
+
+	var lowerBound, lowerCount float64
+	for i, b := range buckets {
+		if b.Count < rank {
+			lowerBound, lowerCount = b.UpperMs, b.Count
+			continue
+		}
+		if math.IsInf(b.UpperMs, +1) {
+			if i == 0 {
+				return 0
+			}
+			return buckets[i-1].UpperMs
+		}
+		inBucket := b.Count - lowerCount
+		if inBucket <= 0 {
+			return b.UpperMs
+		}
+		return lowerBound + (b.UpperMs-lowerBound)*(rank-lowerCount)/inBucket
+	}
+	return buckets[len(buckets)-1].UpperMs
+}
+
+// monotonic returns buckets with every count raised to at least the count of
+// the bucket below it, copying only if a change is needed.
+func monotonic(buckets []scraper.Bucket) []scraper.Bucket {
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i].Count >= buckets[i-1].Count {
+			continue
+		}
+		out := append([]scraper.Bucket(nil), buckets...)
+		for j := i; j < len(out); j++ {
+			out[j].Count = max(out[j].Count, out[j-1].Count)
+		}
+		return out
+	}
+	return buckets
+}
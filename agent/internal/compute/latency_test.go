@@ -0,0 +1,87 @@
+package compute
+
+import (
+	"math"
+	"testing"
+
+	"github.com/obsidianstack/obsidianstack/agent/internal/scraper"
+)
+
+var inf = math.Inf(+1)
+
+func hist(counts ...float64) *scraper.Histogram {
+	// Bounds: 10ms, 100ms, 1000ms, +Inf.
+	bounds := []float64{10, 100, 1000, inf}
+	h := &scraper.Histogram{}
+	for i, c := range counts {
+		h.Buckets = append(h.Buckets, scraper.Bucket{UpperMs: bounds[i], Count: c})
+	}
+	h.Count = counts[len(counts)-1]
+	return h
+}
+
+func TestHistogramQuantile_Interpolates(t *testing.T) {
+	// 50 obs <=10ms, 40 in (10,100], 10 in (100,1000].
+	b := hist(50, 90, 100, 100).Buckets
+
+	if got := histogramQuantile(0.5, b); got != 10 {
+		t.Errorf("p50 = %v, want 10", got)
+	}
+	// rank 95 → 5 of 10 into (100,1000] → 100 + 900*0.5 = 550
+	if got := histogramQuantile(0.95, b); got != 550 {
+		t.Errorf("p95 = %v, want 550", got)
+	}
+	// rank 25 → halfway into [0,10]
+	if got := histogramQuantile(0.25, b); got != 5 {
+		t.Errorf("p25 = %v, want 5", got)
+	}
+}
+
+func TestHistogramQuantile_NonMonotonic(t *testing.T) {
+	// 100ms undercounts: it is raised to the 8 at 10ms.
+	b := hist(8, 0, 18, 18).Buckets
+	if got := histogramQuantile(0.4, b); got < 0 || got > 10 {
+		t.Errorf("p40 = %v, want within (0, 10]", got)
+	}
+	if b[1].Count != 0 {
+		t.Error("histogramQuantile must not modify its input")
+	}
+}
+
+func TestHistogramQuantile_InfBucket(t *testing.T) {
+	// All observations above the highest finite bound.
+	b := hist(0, 0, 0, 10).Buckets
+	if got := histogramQuantile(0.99, b); got != 1000 {
+		t.Errorf("p99 in +Inf bucket = %v, want highest finite bound 1000", got)
+	}
+}
+
+func TestHistogramQuantile_Empty(t *testing.T) {
+	if got := histogramQuantile(0.95, nil); got != 0 {
+		t.Errorf("quantile(nil) = %v, want 0", got)
+	}
+	if got := histogramQuantile(0.95, hist(0, 0, 0, 0).Buckets); got != 0 {
+		t.Errorf("quantile(no observations) = %v, want 0", got)
+	}
+}
+
+func TestHistogramDelta(t *testing.T) {
+	prev := hist(100, 100, 100, 100)
+	cur := hist(100, 150, 200, 200) // 50 new in (10,100], 50 in (100,1000]
+	d := histogramDelta(cur, prev)
+	want := []float64{0, 50, 100, 100}
+	for i, b := range d {
+		if b.Count != want[i] {
+			t.Errorf("delta bucket %v = %v, want %v", b.UpperMs, b.Count, want[i])
+		}
+	}
+}
+
+func TestHistogramDelta_CounterReset(t *testing.T) {
+	prev := hist(1000, 2000, 3000, 3000)
+	cur := hist(5, 8, 10, 10)
+	d := histogramDelta(cur, prev)
+	if d[3].Count != 10 {
+		t.Errorf("after reset total = %v, want 10 (current counts used as-is)", d[3].Count)
+	}
+}
@@ -1,13 +1,16 @@
 package compute
 
-// Weight constants for the strength score formula.
+// Weights are the per-factor weights of the strength score formula.
 // They must sum to 1.0.
-const (
-	weightDrop     = 0.40
-	weightLatency  = 0.30
-	weightRecovery = 0.20
-	weightUptime   = 0.10
-)
+type Weights struct {
+	Drop     float64
+	Latency  float64
+	Recovery float64
+	Uptime   float64
+}
+
+// DefaultWeights is used when a source does not configure its own weights.
+var DefaultWeights = Weights{Drop: 0.40, Latency: 0.30, Recovery: 0.20, Uptime: 0.10}
 
 // State constants returned by the score calculator.
 const (
@@ -17,12 +20,22 @@ const (
 	StateUnknown  = "unknown"
 )
 
-// Thresholds that map a score to a health state.
+// Default thresholds that map a score to a health state.
 const (
 	ThresholdHealthy  = 85.0
 	ThresholdDegraded = 60.0
 )
 
+// Thresholds map a score to a health state: score ≥ Healthy is "healthy",
+// score ≥ Degraded is "degraded", anything lower is "critical".
+type Thresholds struct {
+	Healthy  float64
+	Degraded float64
+}
+
+// DefaultThresholds is used when a source does not configure its own thresholds.
+var DefaultThresholds = Thresholds{Healthy: ThresholdHealthy, Degraded: ThresholdDegraded}
+
 // Input holds the normalised values fed into the strength score formula.
 // All percentage fields are in the range 0–100.
 type Input struct {
@@ -47,6 +60,13 @@ type Input struct {
 	// UptimePct is the percentage of recent scrape cycles that returned
 	// valid data. 100 = always reachable, 0 = never reachable.
 	UptimePct float64
+
+	// Weights overrides the factor weights. The zero value means DefaultWeights.
+	Weights Weights
+
+	// Thresholds overrides the state thresholds. The zero value means
+	// DefaultThresholds.
+	Thresholds Thresholds
 }
 
 // Output is the result of the strength score calculation.
@@ -64,11 +84,14 @@ type Output struct {
 	LatencyFactor  float64
 	RecoveryFactor float64
 	UptimeFactor   float64
+
+	// Thresholds are the state thresholds State was derived with.
+	Thresholds Thresholds
 }
 
 // Compute calculates the pipeline strength score from the given inputs.
 //
-// Formula (per ARCHITECTURE.md):
+// Formula (per ARCHITECTURE.md), shown with DefaultWeights:
 //
 //	score = (
 //	    (1 - drop_pct/100)      * 0.40  +
@@ -79,9 +102,15 @@ type Output struct {
 //
 // If UptimePct is 0 and there is no signal data, the state is "unknown".
 func Compute(in Input) Output {
+	w := in.Weights
+	if w == (Weights{}) {
+		w = DefaultWeights
+	}
+	th := effectiveThresholds(in.Thresholds)
+
 	// No data at all → unknown.
 	if in.UptimePct == 0 && in.DropPct == 0 && in.RecoveryRate == 0 {
-		return Output{State: StateUnknown}
+		return Output{State: StateUnknown, Thresholds: th}
 	}
 
 	dropFactor := 1 - clamp01(in.DropPct/100)
@@ -94,27 +123,28 @@ func Compute(in Input) Output {
 	recoveryFactor := clamp01(in.RecoveryRate / 100)
 	uptimeFactor := clamp01(in.UptimePct / 100)
 
-	score := (dropFactor*weightDrop +
-		latencyFactor*weightLatency +
-		recoveryFactor*weightRecovery +
-		uptimeFactor*weightUptime) * 100
+	score := (dropFactor*w.Drop +
+		latencyFactor*w.Latency +
+		recoveryFactor*w.Recovery +
+		uptimeFactor*w.Uptime) * 100
 
 	return Output{
 		Score:          score,
-		State:          stateFromScore(score),
+		State:          stateFromScore(score, th),
 		DropFactor:     dropFactor,
 		LatencyFactor:  latencyFactor,
 		RecoveryFactor: recoveryFactor,
 		UptimeFactor:   uptimeFactor,
+		Thresholds:     th,
 	}
 }
 
 // stateFromScore maps a numeric score to a named health state.
-func stateFromScore(score float64) string {
+func stateFromScore(score float64, th Thresholds) string {
 	switch {
-	case score >= ThresholdHealthy:
+	case score >= th.Healthy:
 		return StateHealthy
-	case score >= ThresholdDegraded:
+	case score >= th.Degraded:
 		return StateDegraded
 	default:
 		return StateCritical
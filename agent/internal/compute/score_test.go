@@ -182,16 +182,38 @@ func TestCompute_FactorsSumToScore(t *testing.T) {
 		BaselineLatencyMs: 200,
 	}
 	out := Compute(in)
-	reconstructed := (out.DropFactor*weightDrop +
-		out.LatencyFactor*weightLatency +
-		out.RecoveryFactor*weightRecovery +
-		out.UptimeFactor*weightUptime) * 100
+	reconstructed := (out.DropFactor*DefaultWeights.Drop +
+		out.LatencyFactor*DefaultWeights.Latency +
+		out.RecoveryFactor*DefaultWeights.Recovery +
+		out.UptimeFactor*DefaultWeights.Uptime) * 100
 
 	if !almostEqual(out.Score, reconstructed, 0.0001) {
 		t.Errorf("Score %.6f != reconstructed %.6f from factors", out.Score, reconstructed)
 	}
 }
 
+func TestCompute_CustomWeightsAndThresholds(t *testing.T) {
+	// Drop-only weighting: a 50% drop rate halves the score regardless of
+	// the other factors.
+	in := Input{
+		DropPct:      50,
+		RecoveryRate: 50,
+		UptimePct:    100,
+		Weights:      Weights{Drop: 1},
+		Thresholds:   Thresholds{Healthy: 50, Degraded: 20},
+	}
+	out := Compute(in)
+	if !almostEqual(out.Score, 50, 0.0001) {
+		t.Errorf("Score = %.4f, want 50", out.Score)
+	}
+	if out.State != StateHealthy {
+		t.Errorf("State = %q, want healthy at the custom threshold", out.State)
+	}
+	if out.Thresholds != in.Thresholds {
+		t.Errorf("Thresholds = %+v, want %+v", out.Thresholds, in.Thresholds)
+	}
+}
+
 // --- clamp01 ---
 
 func TestClamp01(t *testing.T) {
@@ -220,7 +242,7 @@ func TestStateFromScore(t *testing.T) {
 		{0, StateCritical},
 	}
 	for _, tc := range tests {
-		got := stateFromScore(tc.score)
+		got := stateFromScore(tc.score, DefaultThresholds)
 		if got != tc.want {
 			t.Errorf("stateFromScore(%.2f) = %q, want %q", tc.score, got, tc.want)
 		}
@@ -0,0 +1,34 @@
+package compute
+
+import (
+	"time"
+
+	"github.com/obsidianstack/obsidianstack/agent/internal/config"
+)
+
+// Scoring holds the per-source overrides applied when deriving the strength
+// score. The zero value scores with the built-in defaults and no latency
+// penalty.
+type Scoring struct {
+	BaselineLatencyMs float64
+	Weights           Weights
+	Thresholds        Thresholds
+}
+
+// NewScoring converts a source's validated scoring config. Unset fields stay
+// zero so Compute falls back to DefaultWeights / DefaultThresholds.
+func NewScoring(c config.ScoringConfig) Scoring {
+	return Scoring{
+		BaselineLatencyMs: float64(c.BaselineLatency) / float64(time.Millisecond),
+		Weights: Weights{
+			Drop:     c.Weights.Drop,
+			Latency:  c.Weights.Latency,
+			Recovery: c.Weights.Recovery,
+			Uptime:   c.Weights.Uptime,
+		},
+		Thresholds: Thresholds{
+			Healthy:  c.Thresholds.Healthy,
+			Degraded: c.Thresholds.Degraded,
+		},
+	}
+}
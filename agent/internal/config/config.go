@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"math"
+	"net/http"
 	"os"
+	"regexp"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -10,11 +13,18 @@ import (
 
 // Default values applied when fields are absent from the config file.
 const (
-	DefaultScrapeInterval = 30 * time.Second
-	DefaultShipInterval   = 15 * time.Second
-	DefaultBufferSize     = 1000
-	DefaultGRPCPort       = 50051
-	DefaultHTTPPort       = 8080
+	DefaultScrapeInterval    = 30 * time.Second
+	DefaultScrapeTimeout     = 10 * time.Second
+	DefaultScrapeConcurrency = 4
+	DefaultShipInterval      = 15 * time.Second
+	DefaultBufferSize        = 1000
+	DefaultBatchSize         = 100
+	DefaultWALMaxBytes       = 256 << 20 // 256 MiB
+	DefaultWALFsync          = "interval"
+	DefaultWALFsyncInterval  = time.Second
+	DefaultGRPCPort          = 50051
+	DefaultHTTPPort          = 8080
+	DefaultAdminPort         = 8082
 )
 
 // Config is the top-level configuration for both agent and server.
@@ -29,22 +39,89 @@ type AgentConfig struct {
 	// ServerEndpoint is the gRPC address of obsidianstack-server (host:port).
 	ServerEndpoint string `yaml:"server_endpoint"`
 
-	// ScrapeInterval controls how often each source is polled.
+	// ScrapeInterval controls how often each source is polled, unless the
+	// source sets its own.
 	ScrapeInterval time.Duration `yaml:"scrape_interval"`
 
+	// ScrapeTimeout bounds one scrape of a source (metrics fetch plus TLS
+	// check), unless the source sets its own. When unset it is
+	// DefaultScrapeTimeout, capped at the source's scrape interval.
+	ScrapeTimeout time.Duration `yaml:"scrape_timeout"`
+
+	// ScrapeConcurrency is the maximum number of sources scraped at once.
+	ScrapeConcurrency int `yaml:"scrape_concurrency"`
+
 	// ShipInterval controls how often buffered snapshots are sent to the server.
+	// A batch is sent once it holds BatchSize snapshots or ShipInterval after
+	// the previous one, whichever comes first.
 	ShipInterval time.Duration `yaml:"ship_interval"`
 
+	// BatchSize is the maximum number of snapshots sent in one SendSnapshots
+	// call. 1 disables batching: each snapshot is sent on its own with
+	// SendSnapshot as soon as it is produced.
+	BatchSize int `yaml:"batch_size"`
+
 	// BufferSize is the maximum number of snapshots held in memory when
-	// the server is unreachable.
+	// the server is unreachable. Unused when WAL is enabled.
 	BufferSize int `yaml:"buffer_size"`
 
+	// WAL optionally buffers snapshots on disk instead of in memory, so they
+	// survive agent restarts and long server outages.
+	WAL WALConfig `yaml:"wal"`
+
 	// Sources is the list of pipeline components to monitor.
 	Sources []Source `yaml:"sources"`
 
 	// ServerAuth configures how the agent authenticates to obsidianstack-server.
 	// Supports the same modes as source auth: mtls | apikey | none.
 	ServerAuth AuthConfig `yaml:"server_auth"`
+
+	// AdminPort is the port serving the agent's own /metrics, /healthz and
+	// /readyz endpoints (default 8082). 0 disables the admin listener.
+	AdminPort int `yaml:"admin_port"`
+}
+
+// WALConfig configures the shipper's on-disk write-ahead buffer.
+type WALConfig struct {
+	// Dir is the directory holding the segment files. Empty disables the
+	// WAL and the in-memory buffer of BufferSize snapshots is used instead.
+	Dir string `yaml:"dir"`
+
+	// MaxBytes caps the total size of the segment files. When it is exceeded
+	// the oldest segment is deleted and its unsent snapshots count as dropped.
+	MaxBytes int64 `yaml:"max_bytes"`
+
+	// MaxAge discards snapshots older than this instead of sending them.
+	// Zero keeps snapshots until they are sent or evicted by MaxBytes.
+	MaxAge time.Duration `yaml:"max_age"`
+
+	// Fsync is the durability policy: always (fsync every write), interval
+	// (every FsyncInterval while there are unsynced writes) or never (leave
+	// it to the OS).
+	Fsync string `yaml:"fsync"`
+
+	// FsyncInterval is the fsync period for Fsync == "interval".
+	FsyncInterval time.Duration `yaml:"fsync_interval"`
+}
+
+// Enabled reports whether the on-disk buffer is configured.
+func (w WALConfig) Enabled() bool { return w.Dir != "" }
+
+// ScheduleFor returns the effective scrape interval and timeout of src,
+// applying the agent-wide settings and defaults for fields src leaves unset.
+func (a AgentConfig) ScheduleFor(src Source) (interval, timeout time.Duration) {
+	interval = src.ScrapeInterval
+	if interval == 0 {
+		interval = a.ScrapeInterval
+	}
+	timeout = src.ScrapeTimeout
+	if timeout == 0 {
+		timeout = a.ScrapeTimeout
+	}
+	if timeout == 0 {
+		timeout = min(DefaultScrapeTimeout, interval)
+	}
+	return interval, timeout
 }
 
 // Source describes one monitored pipeline component.
@@ -58,11 +135,89 @@ type Source struct {
 	// Endpoint is the full URL of the component's metrics or health endpoint.
 	Endpoint string `yaml:"endpoint"`
 
+	// ScrapeInterval overrides agent.scrape_interval for this source.
+	ScrapeInterval time.Duration `yaml:"scrape_interval"`
+
+	// ScrapeTimeout overrides agent.scrape_timeout for this source.
+	ScrapeTimeout time.Duration `yaml:"scrape_timeout"`
+
 	// Auth configures how the agent authenticates to this source.
 	Auth AuthConfig `yaml:"auth"`
 
 	// TLS holds optional TLS dial options.
 	TLS TLSConfig `yaml:"tls"`
+
+	// Probe configures the synthetic health check. Only used when Type == "http".
+	Probe ProbeConfig `yaml:"probe"`
+
+	// Scoring overrides how the strength score and health state are derived
+	// for this source. Unset fields keep the built-in defaults.
+	Scoring ScoringConfig `yaml:"scoring"`
+}
+
+// ScoringConfig holds per-source overrides for the strength score formula.
+type ScoringConfig struct {
+	// BaselineLatency is the acceptable P95 latency. When set, the latency
+	// factor drops linearly from full credit at 0 to none at the baseline.
+	// Zero disables the latency penalty.
+	BaselineLatency time.Duration `yaml:"baseline_latency"`
+
+	// Weights overrides the factor weights. When any weight is set, all four
+	// are used as given and must sum to 1.
+	Weights ScoringWeights `yaml:"weights"`
+
+	// Thresholds overrides the score cut-offs for the health states.
+	// When set, both values are required.
+	Thresholds ScoringThresholds `yaml:"thresholds"`
+}
+
+// ScoringWeights are the relative weights of the four score factors.
+type ScoringWeights struct {
+	Drop     float64 `yaml:"drop"`
+	Latency  float64 `yaml:"latency"`
+	Recovery float64 `yaml:"recovery"`
+	Uptime   float64 `yaml:"uptime"`
+}
+
+// ScoringThresholds are the minimum scores for the healthy and degraded states.
+type ScoringThresholds struct {
+	Healthy  float64 `yaml:"healthy"`
+	Degraded float64 `yaml:"degraded"`
+}
+
+// ProbeConfig holds the request and success criteria for an http source.
+type ProbeConfig struct {
+	// Method is the HTTP method to send: GET | HEAD | POST. Defaults to GET.
+	Method string `yaml:"method"`
+
+	// ExpectedStatus lists the response codes that count as up.
+	// Empty means any 2xx status.
+	ExpectedStatus []int `yaml:"expected_status"`
+
+	// BodyMatch is an optional regular expression the response body must match
+	// for the probe to count as up.
+	BodyMatch string `yaml:"body_match"`
+}
+
+// EffectiveMethod returns the configured probe method, or GET if unset.
+func (p ProbeConfig) EffectiveMethod() string {
+	if p.Method != "" {
+		return p.Method
+	}
+	return http.MethodGet
+}
+
+// StatusOK reports whether code satisfies the configured expected statuses.
+func (p ProbeConfig) StatusOK(code int) bool {
+	if len(p.ExpectedStatus) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, want := range p.ExpectedStatus {
+		if code == want {
+			return true
+		}
+	}
+	return false
 }
 
 // AuthConfig specifies the authentication mode for a source.
@@ -70,11 +225,16 @@ type AuthConfig struct {
 	// Mode is one of: mtls | apikey | bearer | basic | none.
 	Mode string `yaml:"mode"`
 
-	// mTLS fields — used when Mode == "mtls".
+	// mTLS fields — used when Mode == "mtls". For server_auth in apikey mode,
+	// CAFile alone verifies the server certificate.
 	CertFile string `yaml:"cert_file"`
 	KeyFile  string `yaml:"key_file"`
 	CAFile   string `yaml:"ca_file"`
 
+	// Plaintext dials obsidianstack-server without TLS in apikey mode
+	// (server_auth only). For local development: the key is sent in the clear.
+	Plaintext bool `yaml:"plaintext"`
+
 	// API key fields — used when Mode == "apikey".
 	// Header is the HTTP header name to send the key in.
 	Header string `yaml:"header"`
@@ -233,9 +393,17 @@ func Load(path string) (*Config, error) {
 func defaults() *Config {
 	return &Config{
 		Agent: AgentConfig{
-			ScrapeInterval: DefaultScrapeInterval,
-			ShipInterval:   DefaultShipInterval,
-			BufferSize:     DefaultBufferSize,
+			ScrapeInterval:    DefaultScrapeInterval,
+			ScrapeConcurrency: DefaultScrapeConcurrency,
+			ShipInterval:      DefaultShipInterval,
+			BufferSize:        DefaultBufferSize,
+			BatchSize:         DefaultBatchSize,
+			AdminPort:         DefaultAdminPort,
+			WAL: WALConfig{
+				MaxBytes:      DefaultWALMaxBytes,
+				Fsync:         DefaultWALFsync,
+				FsyncInterval: DefaultWALFsyncInterval,
+			},
 		},
 		Server: ServerConfig{
 			GRPCPort: DefaultGRPCPort,
@@ -252,12 +420,29 @@ func validate(cfg *Config) error {
 	if cfg.Agent.ScrapeInterval <= 0 {
 		return fmt.Errorf("agent.scrape_interval must be positive")
 	}
+	if cfg.Agent.ScrapeTimeout < 0 {
+		return fmt.Errorf("agent.scrape_timeout must not be negative")
+	}
+	if cfg.Agent.ScrapeConcurrency <= 0 {
+		return fmt.Errorf("agent.scrape_concurrency must be positive")
+	}
 	if cfg.Agent.ShipInterval <= 0 {
 		return fmt.Errorf("agent.ship_interval must be positive")
 	}
 	if cfg.Agent.BufferSize <= 0 {
 		return fmt.Errorf("agent.buffer_size must be positive")
 	}
+	if cfg.Agent.BatchSize <= 0 {
+		return fmt.Errorf("agent.batch_size must be positive")
+	}
+	if cfg.Agent.AdminPort < 0 || cfg.Agent.AdminPort > 65535 {
+		return fmt.Errorf("agent.admin_port %d is out of range [0, 65535]", cfg.Agent.AdminPort)
+	}
+	if cfg.Agent.WAL.Enabled() {
+		if err := validateWAL(cfg.Agent.WAL); err != nil {
+			return fmt.Errorf("agent.wal: %w", err)
+		}
+	}
 	for i, src := range cfg.Agent.Sources {
 		if src.ID == "" {
 			return fmt.Errorf("sources[%d]: id is required", i)
@@ -270,11 +455,99 @@ func validate(cfg *Config) error {
 		default:
 			return fmt.Errorf("sources[%d] %q: unknown type %q", i, src.ID, src.Type)
 		}
+		if src.ScrapeInterval < 0 || src.ScrapeTimeout < 0 {
+			return fmt.Errorf("sources[%d] %q: scrape_interval and scrape_timeout must not be negative", i, src.ID)
+		}
+		if interval, timeout := cfg.Agent.ScheduleFor(src); timeout > interval {
+			return fmt.Errorf("sources[%d] %q: scrape_timeout %v exceeds scrape_interval %v", i, src.ID, timeout, interval)
+		}
 		switch src.Auth.Mode {
 		case "mtls", "apikey", "bearer", "basic", "none", "":
 		default:
 			return fmt.Errorf("sources[%d] %q: unknown auth mode %q", i, src.ID, src.Auth.Mode)
 		}
+		if src.Type == "http" {
+			if err := validateProbe(src.Probe); err != nil {
+				return fmt.Errorf("sources[%d] %q: probe: %w", i, src.ID, err)
+			}
+		}
+		if err := validateScoring(src.Scoring); err != nil {
+			return fmt.Errorf("sources[%d] %q: scoring: %w", i, src.ID, err)
+		}
+	}
+	return nil
+}
+
+// weightSumTolerance absorbs float rounding in hand-written weights like
+// 0.1 + 0.2 + 0.3 + 0.4.
+const weightSumTolerance = 1e-6
+
+// validateScoring checks the per-source scoring overrides.
+func validateScoring(sc ScoringConfig) error {
+	if sc.BaselineLatency < 0 {
+		return fmt.Errorf("baseline_latency must not be negative")
+	}
+	if w := sc.Weights; w != (ScoringWeights{}) {
+		if w.Drop < 0 || w.Latency < 0 || w.Recovery < 0 || w.Uptime < 0 {
+			return fmt.Errorf("weights must not be negative")
+		}
+		if sum := w.Drop + w.Latency + w.Recovery + w.Uptime; math.Abs(sum-1) > weightSumTolerance {
+			return fmt.Errorf("weights must sum to 1, got %g", sum)
+		}
+	}
+	if th := sc.Thresholds; th != (ScoringThresholds{}) {
+		if th.Healthy <= 0 || th.Degraded <= 0 {
+			return fmt.Errorf("thresholds.healthy and thresholds.degraded are both required")
+		}
+		if th.Healthy > 100 {
+			return fmt.Errorf("thresholds.healthy %g is above 100", th.Healthy)
+		}
+		if th.Degraded >= th.Healthy {
+			return fmt.Errorf("thresholds.degraded (%g) must be below thresholds.healthy (%g)", th.Degraded, th.Healthy)
+		}
+	}
+	return nil
+}
+
+// validateWAL checks the on-disk buffer settings.
+func validateWAL(w WALConfig) error {
+	if w.MaxBytes <= 0 {
+		return fmt.Errorf("max_bytes must be positive")
+	}
+	if w.MaxAge < 0 {
+		return fmt.Errorf("max_age must not be negative")
+	}
+	switch w.Fsync {
+	case "always", "never":
+	case "interval":
+		if w.FsyncInterval <= 0 {
+			return fmt.Errorf("fsync_interval must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown fsync policy %q: want always|interval|never", w.Fsync)
+	}
+	return nil
+}
+
+// validateProbe checks the synthetic probe settings of an http source.
+func validateProbe(p ProbeConfig) error {
+	switch p.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodPost:
+	default:
+		return fmt.Errorf("unknown method %q: want GET|HEAD|POST", p.Method)
+	}
+	for _, code := range p.ExpectedStatus {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("expected_status %d is out of range [100, 599]", code)
+		}
+	}
+	if p.BodyMatch != "" {
+		if p.Method == http.MethodHead {
+			return fmt.Errorf("body_match cannot be used with method HEAD")
+		}
+		if _, err := regexp.Compile(p.BodyMatch); err != nil {
+			return fmt.Errorf("body_match: %w", err)
+		}
 	}
 	return nil
 }
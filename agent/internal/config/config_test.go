@@ -64,11 +64,33 @@ agent:
 	if cfg.Agent.BufferSize != DefaultBufferSize {
 		t.Errorf("default buffer_size: got %d, want %d", cfg.Agent.BufferSize, DefaultBufferSize)
 	}
+	if cfg.Agent.BatchSize != DefaultBatchSize {
+		t.Errorf("default batch_size: got %d, want %d", cfg.Agent.BatchSize, DefaultBatchSize)
+	}
+	if cfg.Agent.ScrapeConcurrency != DefaultScrapeConcurrency {
+		t.Errorf("default scrape_concurrency: got %d, want %d", cfg.Agent.ScrapeConcurrency, DefaultScrapeConcurrency)
+	}
+	if cfg.Agent.AdminPort != DefaultAdminPort {
+		t.Errorf("default admin_port: got %d, want %d", cfg.Agent.AdminPort, DefaultAdminPort)
+	}
 	if cfg.Server.GRPCPort != DefaultGRPCPort {
 		t.Errorf("default grpc_port: got %d, want %d", cfg.Server.GRPCPort, DefaultGRPCPort)
 	}
 }
 
+func TestLoad_AdminPort(t *testing.T) {
+	base := `
+agent:
+  server_endpoint: "localhost:50051"
+  admin_port: `
+	if cfg := loadFromString(t, base+"0\n"); cfg.Agent.AdminPort != 0 {
+		t.Errorf("admin_port 0: got %d, want 0 (disabled)", cfg.Agent.AdminPort)
+	}
+	if _, err := loadStringErr(t, base+"70000\n"); err == nil {
+		t.Error("expected error for admin_port out of range, got nil")
+	}
+}
+
 func TestLoad_MissingServerEndpoint(t *testing.T) {
 	yaml := `
 agent:
@@ -196,3 +218,232 @@ func loadStringErr(t *testing.T, content string) (*Config, error) {
 	}
 	return Load(path)
 }
+
+func TestLoad_HTTPProbe(t *testing.T) {
+	yaml := `
+agent:
+  server_endpoint: "localhost:50051"
+  sources:
+    - id: ext-grafana
+      type: http
+      endpoint: "https://grafana.example.com/api/health"
+      probe:
+        method: GET
+        expected_status: [200, 204]
+        body_match: '"database":\s*"ok"'
+`
+	cfg := loadFromString(t, yaml)
+	p := cfg.Agent.Sources[0].Probe
+	if p.EffectiveMethod() != "GET" {
+		t.Errorf("method: got %q, want GET", p.EffectiveMethod())
+	}
+	if !p.StatusOK(204) || p.StatusOK(201) {
+		t.Errorf("StatusOK with expected_status [200, 204] gave wrong result")
+	}
+	if p.BodyMatch == "" {
+		t.Error("body_match was not parsed")
+	}
+}
+
+func TestProbeConfig_Defaults(t *testing.T) {
+	var p ProbeConfig
+	if p.EffectiveMethod() != "GET" {
+		t.Errorf("default method: got %q, want GET", p.EffectiveMethod())
+	}
+	if !p.StatusOK(200) || !p.StatusOK(299) || p.StatusOK(301) || p.StatusOK(500) {
+		t.Error("default StatusOK should accept exactly 2xx")
+	}
+}
+
+func TestLoad_HTTPProbe_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"bad method": `method: DELETE`,
+		"bad status": `expected_status: [42]`,
+		"bad regex":  `body_match: "([a-z"`,
+		"head+body":  "method: HEAD\n        body_match: ok",
+	}
+	for name, probe := range tests {
+		t.Run(name, func(t *testing.T) {
+			yaml := `
+agent:
+  server_endpoint: "localhost:50051"
+  sources:
+    - id: ext
+      type: http
+      endpoint: "https://example.com/health"
+      probe:
+        ` + probe + `
+`
+			if _, err := loadStringErr(t, yaml); err == nil {
+				t.Fatal("expected probe validation error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoad_Scoring(t *testing.T) {
+	yaml := `
+agent:
+  server_endpoint: "localhost:50051"
+  sources:
+    - id: jaeger-prod
+      type: jaeger
+      endpoint: "http://jaeger:14269/metrics"
+      scoring:
+        baseline_latency: 2s
+        weights:
+          drop: 0.1
+          latency: 0.2
+          recovery: 0.3
+          uptime: 0.4
+        thresholds:
+          healthy: 70
+          degraded: 40
+`
+	sc := loadFromString(t, yaml).Agent.Sources[0].Scoring
+	if sc.BaselineLatency != 2*time.Second {
+		t.Errorf("baseline_latency: got %v, want 2s", sc.BaselineLatency)
+	}
+	if sc.Weights != (ScoringWeights{Drop: 0.1, Latency: 0.2, Recovery: 0.3, Uptime: 0.4}) {
+		t.Errorf("weights: got %+v", sc.Weights)
+	}
+	if sc.Thresholds != (ScoringThresholds{Healthy: 70, Degraded: 40}) {
+		t.Errorf("thresholds: got %+v", sc.Thresholds)
+	}
+}
+
+func TestLoad_Scoring_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"weights sum":        "weights: {drop: 0.5, latency: 0.5, recovery: 0.5, uptime: 0}",
+		"negative weight":    "weights: {drop: 1.2, latency: -0.2}",
+		"negative baseline":  "baseline_latency: -1s",
+		"degraded only":      "thresholds: {degraded: 50}",
+		"degraded > healthy": "thresholds: {healthy: 50, degraded: 70}",
+		"healthy > 100":      "thresholds: {healthy: 120, degraded: 70}",
+	}
+	for name, scoring := range tests {
+		t.Run(name, func(t *testing.T) {
+			yaml := `
+agent:
+  server_endpoint: "localhost:50051"
+  sources:
+    - id: otel
+      type: otelcol
+      endpoint: "http://otel:8888/metrics"
+      scoring:
+        ` + scoring + `
+`
+			if _, err := loadStringErr(t, yaml); err == nil {
+				t.Fatal("expected scoring validation error, got nil")
+			}
+		})
+	}
+}
+
+func TestAgentConfig_ScheduleFor(t *testing.T) {
+	agent := AgentConfig{ScrapeInterval: 30 * time.Second}
+	tests := []struct {
+		name                      string
+		agentTimeout              time.Duration
+		src                       Source
+		wantInterval, wantTimeout time.Duration
+	}{
+		{"defaults", 0, Source{}, 30 * time.Second, DefaultScrapeTimeout},
+		{"timeout capped at short interval", 0, Source{ScrapeInterval: 5 * time.Second}, 5 * time.Second, 5 * time.Second},
+		{"agent timeout", 20 * time.Second, Source{}, 30 * time.Second, 20 * time.Second},
+		{"source overrides", 20 * time.Second, Source{ScrapeInterval: time.Minute, ScrapeTimeout: 45 * time.Second}, time.Minute, 45 * time.Second},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a := agent
+			a.ScrapeTimeout = tc.agentTimeout
+			interval, timeout := a.ScheduleFor(tc.src)
+			if interval != tc.wantInterval || timeout != tc.wantTimeout {
+				t.Errorf("ScheduleFor = %v/%v, want %v/%v", interval, timeout, tc.wantInterval, tc.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestLoad_Schedule_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"timeout > interval": "scrape_interval: 5s\n      scrape_timeout: 10s",
+		"negative interval":  "scrape_interval: -5s",
+	}
+	for name, sched := range tests {
+		t.Run(name, func(t *testing.T) {
+			yaml := `
+agent:
+  server_endpoint: "localhost:50051"
+  sources:
+    - id: otel
+      type: otelcol
+      endpoint: "http://otel:8888/metrics"
+      ` + sched + `
+`
+			if _, err := loadStringErr(t, yaml); err == nil {
+				t.Fatal("expected schedule validation error, got nil")
+			}
+		})
+	}
+
+	yaml := `
+agent:
+  server_endpoint: "localhost:50051"
+  scrape_concurrency: 0
+`
+	if _, err := loadStringErr(t, yaml); err == nil {
+		t.Error("expected error for scrape_concurrency 0, got nil")
+	}
+}
+
+func TestLoad_WAL(t *testing.T) {
+	cfg := loadFromString(t, `
+agent:
+  server_endpoint: "localhost:50051"
+  wal:
+    dir: /var/lib/obsidianstack/wal
+    max_age: 24h
+`)
+	w := cfg.Agent.WAL
+	if !w.Enabled() {
+		t.Fatal("WAL not enabled with dir set")
+	}
+	if w.MaxAge != 24*time.Hour {
+		t.Errorf("MaxAge = %v, want 24h", w.MaxAge)
+	}
+	if w.MaxBytes != DefaultWALMaxBytes || w.Fsync != DefaultWALFsync || w.FsyncInterval != DefaultWALFsyncInterval {
+		t.Errorf("defaults = %d/%q/%v, want %d/%q/%v", w.MaxBytes, w.Fsync, w.FsyncInterval,
+			DefaultWALMaxBytes, DefaultWALFsync, DefaultWALFsyncInterval)
+	}
+
+	cfg = loadFromString(t, `
+agent:
+  server_endpoint: "localhost:50051"
+`)
+	if cfg.Agent.WAL.Enabled() {
+		t.Error("WAL enabled without dir")
+	}
+}
+
+func TestLoad_WAL_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown fsync":     "fsync: sometimes",
+		"negative max_age":  "max_age: -1h",
+		"negative maxbytes": "max_bytes: -1",
+	}
+	for name, field := range tests {
+		t.Run(name, func(t *testing.T) {
+			yaml := `
+agent:
+  server_endpoint: "localhost:50051"
+  wal:
+    dir: /tmp/wal
+    ` + field + `
+`
+			if _, err := loadStringErr(t, yaml); err == nil {
+				t.Fatal("expected wal validation error, got nil")
+			}
+		})
+	}
+}
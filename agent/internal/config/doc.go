@@ -2,16 +2,25 @@
 //
 // Top-level types:
 //   - Config{Agent, Server} — full config tree parsed from YAML
-//   - AgentConfig — server_endpoint, scrape_interval, ship_interval, buffer_size,
-//     sources [], server_auth
-//   - Source — id, type (otelcol|prometheus|loki|jaeger|http), endpoint, auth, tls
+//   - AgentConfig — server_endpoint, scrape_interval, scrape_timeout,
+//     scrape_concurrency, ship_interval, batch_size, buffer_size, wal, sources [],
+//     server_auth;
+//     ScheduleFor(src) resolves a source's effective interval and timeout
+//   - Source — id, type (otelcol|prometheus|loki|fluentbit|jaeger|http), endpoint,
+//     scrape_interval, scrape_timeout, auth, tls, probe, scoring
+//   - WALConfig — dir, max_bytes, max_age, fsync (always|interval|never),
+//     fsync_interval for the shipper's optional on-disk buffer
+//   - ProbeConfig — method, expected_status, body_match for synthetic http checks
+//   - ScoringConfig — per-source baseline_latency, weights (must sum to 1) and
+//     state thresholds for the strength score
 //   - AuthConfig — mode (mtls|apikey|bearer|none), cert/key/ca files, header,
 //     key_env, token_env; Key() and Token() resolve from environment variables
 //   - ServerConfig, ServerAuthConfig, AlertsConfig, StorageConfig — server-side
 //     settings parsed but used by the server binary, not the agent
 //
-// Load(path) reads the YAML file, applies defaults (30s scrape, 15s ship,
-// 1000 buffer, ports 50051/8080), then validates required fields and enums.
+// Load(path) reads the YAML file, applies defaults (30s scrape, 4 concurrent
+// scrapes, 15s ship, batches of 100, 1000 buffer, ports 50051/8080), then
+// validates required fields and enums.
 //
 // Watch(ctx, path, onChange) uses fsnotify to detect file changes and calls
 // onChange with the newly parsed Config. It handles the rename→create pattern
@@ -0,0 +1,23 @@
+// Package runner drives the agent's scrape loop and applies hot-reloaded
+// configuration to it.
+//
+// Runner keeps one pipeline (source config + scraper) per source ID and a
+// single compute.Engine whose per-source state is keyed by the same IDs.
+// Runner.Apply reconciles the pipelines with a new AgentConfig: added sources
+// are started, removed ones stopped, and sources whose auth/TLS/probe settings
+// changed get a fresh scraper. Engine state is kept for every source whose
+// type and endpoint are unchanged, so counter baselines and uptime history
+// survive a reload.
+//
+// Runner.Run gives every pipeline its own goroutine and ticker, so one slow
+// endpoint cannot delay the others. Each source scrapes at its own
+// scrape_interval (agent default otherwise), starting at a random offset
+// within it to spread load, and each scrape is bounded by scrape_timeout.
+// A worker pool of agent.scrape_concurrency slots caps concurrent scrapes.
+// A scrape that finishes more than one interval after it was due is logged
+// as an overrun. Every compute.Result goes to the Shipper.
+//
+// Scrape durations, errors and overruns are recorded per source in the
+// Prometheus metrics returned by Collectors (metrics.go); a removed source's
+// series are deleted.
+package runner
@@ -0,0 +1,39 @@
+package runner
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	scrapeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "obsidian_agent_scrape_duration_seconds",
+		Help:    "Time taken by one scrape of a source, including its certificate check.",
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"source_id", "source_type"})
+
+	scrapeErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "obsidian_agent_scrape_errors_total",
+		Help: "Scrapes of a source that failed.",
+	}, []string{"source_id", "source_type"})
+
+	scrapeOverruns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "obsidian_agent_scrape_overruns_total",
+		Help: "Scrapes that finished more than one interval after they were due.",
+	}, []string{"source_id", "source_type"})
+
+	sourcesGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "obsidian_agent_sources",
+		Help: "Number of sources being scraped.",
+	})
+)
+
+// Collectors returns the runner's internal metrics for registration.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{scrapeDuration, scrapeErrors, scrapeOverruns, sourcesGauge}
+}
+
+// forgetMetrics drops the per-source series of a removed source.
+func forgetMetrics(id string) {
+	l := prometheus.Labels{"source_id": id}
+	scrapeDuration.DeletePartialMatch(l)
+	scrapeErrors.DeletePartialMatch(l)
+	scrapeOverruns.DeletePartialMatch(l)
+}
@@ -0,0 +1,324 @@
+package runner
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"reflect"
+	"sync"
+	"time"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+
+	"github.com/obsidianstack/obsidianstack/agent/internal/compute"
+	"github.com/obsidianstack/obsidianstack/agent/internal/config"
+	"github.com/obsidianstack/obsidianstack/agent/internal/scraper"
+	"github.com/obsidianstack/obsidianstack/agent/internal/security"
+)
+
+// Shipper receives every derived Result. *shipper.Shipper implements it.
+type Shipper interface {
+	Ship(res *compute.Result, certs []*pb.CertStatus)
+}
+
+// Runner owns the set of running pipelines. Each pipeline scrapes on its own
+// schedule in its own goroutine; a shared worker pool bounds how many scrapes
+// run at once. Apply reconciles the pipelines against a (re)loaded config
+// while Run keeps them going.
+//
+// All exported methods are safe for concurrent use.
+type Runner struct {
+	mu        sync.Mutex
+	ctx       context.Context // set by Run; pipelines start once it is set
+	stopping  bool            // set once Run's ctx is done; no new loops start
+	pipelines map[string]*pipeline
+	order     []string      // source IDs in config order
+	slots     chan struct{} // worker pool: one token per running scrape
+	wg        sync.WaitGroup
+
+	engine *compute.Engine
+	ship   Shipper
+
+	// Injectable for tests.
+	newScraper func(config.Source) (scraper.Scraper, error)
+	checkCert  func(context.Context, config.Source) *pb.CertStatus
+	jitter     func(interval time.Duration) time.Duration
+}
+
+// pipeline is one configured source, the scraper built for it and its
+// scrape loop. A pipeline is never modified after it is started; Apply
+// replaces it instead.
+type pipeline struct {
+	// src has ScrapeInterval and ScrapeTimeout resolved against the agent
+	// defaults, so they are always set.
+	src  config.Source
+	s    scraper.Scraper
+	stop context.CancelFunc // cancels the scrape loop; nil until started
+
+	overruns int // scrapes that took longer than the interval; loop goroutine only
+}
+
+// New returns a Runner with no pipelines that ships results to ship.
+// Call Apply with the initial config before Run.
+func New(ship Shipper) *Runner {
+	return &Runner{
+		pipelines:  make(map[string]*pipeline),
+		slots:      make(chan struct{}, config.DefaultScrapeConcurrency),
+		engine:     compute.NewEngine(),
+		ship:       ship,
+		newScraper: scraper.New,
+		checkCert:  security.Check,
+		jitter:     randomJitter,
+	}
+}
+
+// randomJitter returns a random offset in [0, interval).
+func randomJitter(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(interval))) //nolint:gosec // not crypto
+}
+
+// Apply reconciles the running pipelines with cfg:
+//
+//   - added sources get a scraper and start scraping
+//   - removed sources are stopped and their compute state is dropped
+//   - sources whose type or endpoint changed are rebuilt from scratch, since
+//     their old counter baselines belong to a different process
+//   - sources whose auth, TLS, probe or timeout settings changed get a new
+//     scraper (and HTTP client) but keep their compute state
+//   - sources whose scrape interval changed are restarted on the new schedule
+//     with the same scraper and compute state
+//   - unchanged sources keep running untouched
+//
+// Scoring overrides are re-applied for every source. A source whose new
+// scraper cannot be built keeps running with its previous settings.
+func (r *Runner) Apply(cfg config.AgentConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cfg.ScrapeConcurrency > 0 && cfg.ScrapeConcurrency != cap(r.slots) {
+		// Scrapes already holding a token release it into the old pool.
+		r.slots = make(chan struct{}, cfg.ScrapeConcurrency)
+	}
+
+	seen := make(map[string]bool, len(cfg.Sources))
+	order := make([]string, 0, len(cfg.Sources))
+	for _, src := range cfg.Sources {
+		src.ScrapeInterval, src.ScrapeTimeout = cfg.ScheduleFor(src)
+		seen[src.ID] = true
+		order = append(order, src.ID)
+
+		old, exists := r.pipelines[src.ID]
+		switch {
+		case !exists:
+			s, err := r.newScraper(src)
+			if err != nil {
+				slog.Error("skipping source — could not build scraper", "source", src.ID, "err", err)
+				continue
+			}
+			r.replace(src.ID, &pipeline{src: src, s: s})
+			slog.Info("registered source", "id", src.ID, "type", src.Type,
+				"endpoint", src.Endpoint, "interval", src.ScrapeInterval)
+
+		case old.src.Type != src.Type || old.src.Endpoint != src.Endpoint:
+			s, err := r.newScraper(src)
+			if err != nil {
+				slog.Error("could not rebuild scraper — keeping previous settings", "source", src.ID, "err", err)
+				continue
+			}
+			r.replace(src.ID, &pipeline{src: src, s: s})
+			r.engine.Forget(src.ID)
+			forgetMetrics(src.ID)
+			slog.Info("replaced source", "id", src.ID, "type", src.Type, "endpoint", src.Endpoint)
+
+		case !sameClient(old.src, src):
+			s, err := r.newScraper(src)
+			if err != nil {
+				slog.Error("could not rebuild scraper — keeping previous settings", "source", src.ID, "err", err)
+				continue
+			}
+			r.replace(src.ID, &pipeline{src: src, s: s})
+			slog.Info("rebuilt source client", "id", src.ID)
+
+		case old.src.ScrapeInterval != src.ScrapeInterval:
+			r.replace(src.ID, &pipeline{src: src, s: old.s})
+			slog.Info("rescheduled source", "id", src.ID, "interval", src.ScrapeInterval)
+		}
+		r.engine.SetScoring(src.ID, compute.NewScoring(src.Scoring))
+	}
+
+	for id, p := range r.pipelines {
+		if !seen[id] {
+			if p.stop != nil {
+				p.stop()
+			}
+			delete(r.pipelines, id)
+			r.engine.Forget(id)
+			forgetMetrics(id)
+			slog.Info("removed source", "id", id)
+		}
+	}
+	r.order = order
+	sourcesGauge.Set(float64(len(r.pipelines)))
+
+	if len(r.pipelines) == 0 {
+		slog.Warn("no sources configured — agent will idle")
+	}
+}
+
+// replace stops the pipeline running for id, if any, and starts p in its
+// place. Must be called with r.mu held.
+func (r *Runner) replace(id string, p *pipeline) {
+	if old, ok := r.pipelines[id]; ok && old.stop != nil {
+		old.stop()
+	}
+	r.pipelines[id] = p
+	r.start(p)
+}
+
+// start launches p's scrape loop if Run has been called and is not shutting
+// down. Must be called with r.mu held.
+func (r *Runner) start(p *pipeline) {
+	if r.ctx == nil || r.stopping {
+		return
+	}
+	ctx, cancel := context.WithCancel(r.ctx)
+	p.stop = cancel
+	r.wg.Add(1)
+	go r.loop(ctx, p)
+}
+
+// sameClient reports whether a and b would build identical scrapers.
+func sameClient(a, b config.Source) bool {
+	return a.Auth == b.Auth && a.TLS == b.TLS &&
+		a.ScrapeTimeout == b.ScrapeTimeout &&
+		reflect.DeepEqual(a.Probe, b.Probe)
+}
+
+// Run starts every pipeline's scrape loop and blocks until ctx is cancelled
+// and all loops have returned, including any scrape in flight, so nothing is
+// shipped after Run returns. Pipelines added by Apply while Run is active
+// start immediately.
+func (r *Runner) Run(ctx context.Context) {
+	r.mu.Lock()
+	r.ctx = ctx
+	for _, id := range r.order {
+		if p, ok := r.pipelines[id]; ok {
+			r.start(p)
+		}
+	}
+	r.mu.Unlock()
+
+	<-ctx.Done()
+	r.mu.Lock()
+	r.stopping = true // no loop may be added once Wait begins
+	r.mu.Unlock()
+	r.wg.Wait()
+}
+
+// loop scrapes p once per interval until ctx is cancelled. The first scrape
+// waits a random fraction of the interval, so sources that share an interval
+// are spread across it instead of all firing at once.
+func (r *Runner) loop(ctx context.Context, p *pipeline) {
+	defer r.wg.Done()
+
+	interval := p.src.ScrapeInterval
+	delay := time.NewTimer(r.jitter(interval))
+	select {
+	case <-ctx.Done():
+		delay.Stop()
+		return
+	case <-delay.C:
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	scheduled := time.Now()
+	for {
+		r.scrape(ctx, p, scheduled)
+		select {
+		case <-ctx.Done():
+			return
+		case scheduled = <-ticker.C:
+		}
+	}
+}
+
+// scrape runs one scrape of p — waiting for a worker slot, then fetching,
+// checking certificates and computing within the source's scrape timeout —
+// and ships the result. scheduled is when the scrape was due; a scrape that
+// finishes more than one interval after it is logged as an overrun.
+func (r *Runner) scrape(ctx context.Context, p *pipeline, scheduled time.Time) {
+	slots := r.pool()
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	defer func() { <-slots }()
+
+	start := time.Now()
+	scrapeCtx, cancel := context.WithTimeout(ctx, p.src.ScrapeTimeout)
+	defer cancel()
+
+	res, err := p.s.Scrape(scrapeCtx)
+	if ctx.Err() != nil {
+		return // pipeline stopped or agent shutting down mid-scrape
+	}
+	if err != nil {
+		scrapeErrors.WithLabelValues(p.src.ID, p.src.Type).Inc()
+		slog.Warn("scrape error", "source", p.src.ID, "err", err)
+		return
+	}
+	if res.Err != nil {
+		scrapeErrors.WithLabelValues(p.src.ID, p.src.Type).Inc()
+	}
+
+	// TLS cert check — runs only for HTTPS endpoints.
+	var certs []*pb.CertStatus
+	if cs := r.checkCert(scrapeCtx, p.src); cs != nil {
+		certs = []*pb.CertStatus{cs}
+	}
+	scrapeDuration.WithLabelValues(p.src.ID, p.src.Type).Observe(time.Since(start).Seconds())
+
+	if result := r.process(p, res, start); result != nil {
+		r.ship.Ship(result, certs)
+		slog.Debug("shipped snapshot",
+			"source", p.src.ID,
+			"state", result.State,
+			"score", result.StrengthScore,
+		)
+	}
+
+	if took := time.Since(scheduled); took > p.src.ScrapeInterval {
+		p.overruns++
+		scrapeOverruns.WithLabelValues(p.src.ID, p.src.Type).Inc()
+		slog.Warn("scrape overran its interval",
+			"source", p.src.ID,
+			"took", took,
+			"interval", p.src.ScrapeInterval,
+			"overruns", p.overruns,
+		)
+	}
+}
+
+// pool returns the current worker pool.
+func (r *Runner) pool() chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.slots
+}
+
+// process feeds res to the engine if p is still the live pipeline for its
+// source. A source removed or replaced by Apply while it was being scraped
+// returns nil, so its result cannot recreate dropped engine state.
+func (r *Runner) process(p *pipeline, res *scraper.ScrapeResult, now time.Time) *compute.Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pipelines[p.src.ID] != p {
+		return nil
+	}
+	return r.engine.Process(res, now)
+}
@@ -0,0 +1,415 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+
+	"github.com/obsidianstack/obsidianstack/agent/internal/compute"
+	"github.com/obsidianstack/obsidianstack/agent/internal/config"
+	"github.com/obsidianstack/obsidianstack/agent/internal/scraper"
+)
+
+// remote simulates the scraped processes: a traces counter per endpoint that
+// grows on every scrape, so a rebuilt scraper for the same endpoint sees the
+// same process.
+type remote struct {
+	mu     sync.Mutex
+	totals map[string]float64
+	calls  map[string]int
+}
+
+func (rm *remote) scrape(endpoint string) float64 {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.totals[endpoint] += 1000
+	rm.calls[endpoint]++
+	return rm.totals[endpoint]
+}
+
+func (rm *remote) callsTo(endpoint string) int {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	return rm.calls[endpoint]
+}
+
+type fakeScraper struct {
+	src    config.Source
+	remote *remote
+	block  chan struct{} // when non-nil, Scrape waits for it or ctx
+}
+
+func (f *fakeScraper) Scrape(ctx context.Context) (*scraper.ScrapeResult, error) {
+	if f.block != nil {
+		select {
+		case <-f.block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return &scraper.ScrapeResult{
+		SourceID:   f.src.ID,
+		SourceType: f.src.Type,
+		Received:   map[string]float64{"traces": f.remote.scrape(f.src.Endpoint)},
+		Dropped:    map[string]float64{},
+		Extra:      map[string]float64{},
+	}, nil
+}
+
+// recordingShipper keeps the last Result shipped per source.
+type recordingShipper struct {
+	mu   sync.Mutex
+	last map[string]*compute.Result
+}
+
+func (s *recordingShipper) Ship(res *compute.Result, _ []*pb.CertStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last[res.SourceID] = res
+}
+
+func (s *recordingShipper) state(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.last[id]; ok {
+		return r.State
+	}
+	return ""
+}
+
+// newTestRunner returns a Runner with fake scrapers, no start-up jitter and
+// a count of scraper builds per source ID. Sources with endpoint "bad" fail
+// to build; sources with an endpoint starting "slow" block until the
+// returned channel is closed.
+func newTestRunner() (*Runner, *recordingShipper, map[string]int) {
+	r, ship, builds, _, _ := newTestRunnerWithRemote()
+	return r, ship, builds
+}
+
+func newTestRunnerWithRemote() (*Runner, *recordingShipper, map[string]int, *remote, chan struct{}) {
+	ship := &recordingShipper{last: make(map[string]*compute.Result)}
+	builds := make(map[string]int)
+	rm := &remote{totals: make(map[string]float64), calls: make(map[string]int)}
+	release := make(chan struct{})
+	r := New(ship)
+	r.newScraper = func(src config.Source) (scraper.Scraper, error) {
+		if src.Endpoint == "bad" {
+			return nil, errors.New("bad endpoint")
+		}
+		builds[src.ID]++
+		f := &fakeScraper{src: src, remote: rm}
+		if strings.HasPrefix(src.Endpoint, "slow") {
+			f.block = release
+		}
+		return f, nil
+	}
+	r.checkCert = func(context.Context, config.Source) *pb.CertStatus { return nil }
+	r.jitter = func(time.Duration) time.Duration { return 0 }
+	return r, ship, builds, rm, release
+}
+
+func agentCfg(sources ...config.Source) config.AgentConfig {
+	return config.AgentConfig{ScrapeInterval: 30 * time.Second, ScrapeConcurrency: 4, Sources: sources}
+}
+
+// current returns the running pipelines in config order.
+func (r *Runner) current() []*pipeline {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]*pipeline, 0, len(r.order))
+	for _, id := range r.order {
+		if p, ok := r.pipelines[id]; ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// scrapeAll runs one scrape of every pipeline, in config order.
+func scrapeAll(r *Runner) {
+	for _, p := range r.current() {
+		r.scrape(context.Background(), p, time.Now())
+	}
+}
+
+// waitFor polls cond until it returns true or the deadline passes.
+func waitFor(t *testing.T, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
+
+func src(id, endpoint string) config.Source {
+	return config.Source{ID: id, Type: "otelcol", Endpoint: endpoint}
+}
+
+func ids(r *Runner) []string {
+	var out []string
+	for _, p := range r.current() {
+		out = append(out, p.src.ID)
+	}
+	return out
+}
+
+func TestApply_AddsAndRemovesSources(t *testing.T) {
+	r, _, _ := newTestRunner()
+	r.Apply(agentCfg(src("a", "http://a"), src("b", "http://b")))
+	if got := ids(r); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("pipelines = %v, want [a b]", got)
+	}
+
+	r.Apply(agentCfg(src("b", "http://b"), src("c", "http://c")))
+	if got := ids(r); len(got) != 2 || got[0] != "b" || got[1] != "c" {
+		t.Errorf("pipelines after reload = %v, want [b c]", got)
+	}
+}
+
+func TestApply_UnchangedSourceKeepsState(t *testing.T) {
+	r, ship, builds := newTestRunner()
+	cfg := agentCfg(src("a", "http://a"))
+	r.Apply(cfg)
+	scrapeAll(r)
+
+	cfg.Sources = append(cfg.Sources, src("b", "http://b"))
+	r.Apply(cfg)
+	scrapeAll(r)
+
+	if builds["a"] != 1 {
+		t.Errorf("scraper for a built %d times, want 1", builds["a"])
+	}
+	if got := ship.state("a"); got != compute.StateHealthy {
+		t.Errorf("a State after reload = %q, want healthy (baseline kept)", got)
+	}
+	if got := ship.state("b"); got != compute.StateUnknown {
+		t.Errorf("new source b State = %q, want unknown", got)
+	}
+}
+
+func TestApply_AuthChangeRebuildsClientKeepsState(t *testing.T) {
+	r, ship, builds := newTestRunner()
+	s := src("a", "http://a")
+	r.Apply(agentCfg(s))
+	scrapeAll(r)
+
+	s.Auth = config.AuthConfig{Mode: "bearer", TokenEnv: "TOKEN"}
+	r.Apply(agentCfg(s))
+	scrapeAll(r)
+
+	if builds["a"] != 2 {
+		t.Errorf("scraper for a built %d times, want 2", builds["a"])
+	}
+	if got := ship.state("a"); got != compute.StateHealthy {
+		t.Errorf("a State after auth change = %q, want healthy (baseline kept)", got)
+	}
+}
+
+func TestApply_EndpointChangeResetsState(t *testing.T) {
+	r, ship, _ := newTestRunner()
+	r.Apply(agentCfg(src("a", "http://a")))
+	scrapeAll(r)
+
+	r.Apply(agentCfg(src("a", "http://a2")))
+	scrapeAll(r)
+
+	if got := ship.state("a"); got != compute.StateUnknown {
+		t.Errorf("a State after endpoint change = %q, want unknown (new baseline)", got)
+	}
+}
+
+func TestApply_BuildFailureKeepsPrevious(t *testing.T) {
+	r, _, _ := newTestRunner()
+	r.Apply(agentCfg(src("a", "http://a")))
+	r.Apply(agentCfg(src("a", "bad")))
+
+	got := r.current()
+	if len(got) != 1 || got[0].src.Endpoint != "http://a" {
+		t.Errorf("pipelines = %+v, want a on its previous endpoint", got)
+	}
+}
+
+func TestApply_IntervalChangeReusesScraper(t *testing.T) {
+	r, _, builds := newTestRunner()
+	s := src("a", "http://a")
+	r.Apply(agentCfg(s))
+	before := r.current()[0]
+
+	s.ScrapeInterval = 10 * time.Second
+	r.Apply(agentCfg(s))
+	after := r.current()[0]
+
+	if after == before {
+		t.Fatal("pipeline was not restarted on interval change")
+	}
+	if after.src.ScrapeInterval != 10*time.Second {
+		t.Errorf("interval = %v, want 10s", after.src.ScrapeInterval)
+	}
+	if after.s != before.s || builds["a"] != 1 {
+		t.Errorf("scraper rebuilt on interval change (builds = %d)", builds["a"])
+	}
+}
+
+func TestApply_ResolvesSchedule(t *testing.T) {
+	r, _, _ := newTestRunner()
+	fast := src("fast", "http://fast")
+	fast.ScrapeInterval = 5 * time.Second
+	r.Apply(agentCfg(fast, src("default", "http://default")))
+
+	got := r.current()
+	if got[0].src.ScrapeInterval != 5*time.Second || got[0].src.ScrapeTimeout != 5*time.Second {
+		t.Errorf("fast schedule = %v/%v, want 5s/5s (timeout capped at interval)",
+			got[0].src.ScrapeInterval, got[0].src.ScrapeTimeout)
+	}
+	if got[1].src.ScrapeInterval != 30*time.Second || got[1].src.ScrapeTimeout != config.DefaultScrapeTimeout {
+		t.Errorf("default schedule = %v/%v, want 30s/%v",
+			got[1].src.ScrapeInterval, got[1].src.ScrapeTimeout, config.DefaultScrapeTimeout)
+	}
+}
+
+func TestRun_SlowSourceDoesNotDelayOthers(t *testing.T) {
+	r, _, _, rm, release := newTestRunnerWithRemote()
+	defer close(release)
+
+	slow, fast := src("slow", "slow://a"), src("fast", "http://fast")
+	slow.ScrapeTimeout = 10 * time.Millisecond
+	slow.ScrapeInterval, fast.ScrapeInterval = 10*time.Millisecond, 10*time.Millisecond
+	r.Apply(agentCfg(slow, fast))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+
+	if !waitFor(t, func() bool { return rm.callsTo("http://fast") >= 5 }) {
+		t.Errorf("fast source scraped %d times while the slow one hung, want >= 5", rm.callsTo("http://fast"))
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+}
+
+// TestRun_NoScrapesAfterReturn checks that once Run has returned — and the
+// agent may close the shipper's buffer — a source added by a late reload
+// does not start scraping.
+func TestRun_NoScrapesAfterReturn(t *testing.T) {
+	r, _, _, rm, _ := newTestRunnerWithRemote()
+	r.Apply(agentCfg(src("a", "http://a")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+	waitFor(t, func() bool { return rm.callsTo("http://a") >= 1 })
+	cancel()
+	<-done
+
+	r.Apply(agentCfg(src("a", "http://a"), src("b", "http://b")))
+	for _, p := range r.current() {
+		if p.src.ID == "b" && p.stop != nil {
+			t.Error("scrape loop started for a source added after Run returned")
+		}
+	}
+}
+
+func TestRun_WorkerPoolBoundsConcurrency(t *testing.T) {
+	r, _, _, _, release := newTestRunnerWithRemote()
+	cfg := agentCfg(src("a", "slow://a"), src("b", "slow://b"), src("c", "slow://c"))
+	cfg.ScrapeConcurrency = 2
+	r.Apply(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+
+	if !waitFor(t, func() bool { return len(r.pool()) == 2 }) {
+		t.Fatalf("in-flight scrapes = %d, want 2", len(r.pool()))
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := len(r.pool()); n != 2 {
+		t.Errorf("in-flight scrapes = %d, want at most 2", n)
+	}
+	close(release)
+}
+
+func TestScrape_OverrunCounted(t *testing.T) {
+	r, ship, _ := newTestRunner()
+	r.Apply(agentCfg(src("a", "http://a")))
+	p := r.current()[0]
+
+	// Due two intervals ago: finishing now is an overrun.
+	r.scrape(context.Background(), p, time.Now().Add(-2*p.src.ScrapeInterval))
+	if p.overruns != 1 {
+		t.Errorf("overruns = %d, want 1", p.overruns)
+	}
+	if ship.state("a") == "" {
+		t.Error("overrunning scrape was not shipped")
+	}
+
+	r.scrape(context.Background(), p, time.Now())
+	if p.overruns != 1 {
+		t.Errorf("overruns after on-time scrape = %d, want 1", p.overruns)
+	}
+}
+
+func TestScrape_RecordsMetrics(t *testing.T) {
+	const name = "obsidian_agent_scrape_duration_seconds"
+	// Other tests share the package-level metrics; compare against them.
+	before := testutil.CollectAndCount(scrapeDuration, name)
+
+	r, _, _ := newTestRunner()
+	r.Apply(agentCfg(src("metrics-a", "http://metrics-a")))
+	scrapeAll(r)
+	scrapeAll(r)
+
+	if n := testutil.CollectAndCount(scrapeDuration, name); n != before+1 {
+		t.Errorf("scrape_duration series: got %d, want %d", n, before+1)
+	}
+	if got := testutil.ToFloat64(sourcesGauge); got != 1 {
+		t.Errorf("sources: got %v, want 1", got)
+	}
+
+	// Removing the source drops its series.
+	r.Apply(agentCfg())
+	if n := testutil.CollectAndCount(scrapeDuration, name); n != before {
+		t.Errorf("scrape_duration series after removal: got %d, want %d", n, before)
+	}
+}
+
+func TestScrape_CountsErrors(t *testing.T) {
+	r, _, _ := newTestRunner()
+	r.Apply(agentCfg(src("metrics-err", "http://metrics-err")))
+	p := r.current()[0]
+	p.s = errScraper{}
+	r.scrape(context.Background(), p, time.Now())
+
+	if got := testutil.ToFloat64(scrapeErrors.WithLabelValues("metrics-err", "otelcol")); got != 1 {
+		t.Errorf("scrape_errors_total: got %v, want 1", got)
+	}
+}
+
+// errScraper always fails.
+type errScraper struct{}
+
+func (errScraper) Scrape(context.Context) (*scraper.ScrapeResult, error) {
+	return nil, errors.New("connection refused")
+}
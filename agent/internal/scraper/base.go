@@ -6,8 +6,10 @@ import (
 	"crypto/x509"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
+	"sort"
 	"time"
 
 	dto "github.com/prometheus/client_model/go"
@@ -38,9 +40,42 @@ type ScrapeResult struct {
 	// Examples: "queue_capacity", "queue_pending", "ring_tokens".
 	Extra map[string]float64
 
+	// ResponseTime is the round-trip time of a synthetic probe (type http).
+	// Zero for scrapers that read a metrics endpoint.
+	ResponseTime time.Duration
+
+	// Latency is the component's export/write latency histogram with raw
+	// cumulative counts. Like Received/Dropped these are totals; the compute
+	// engine derives quantiles from the bucket deltas between two scrapes.
+	// Nil when the component exposes no latency histogram.
+	Latency *Histogram
+
 	// Err is non-nil if the scrape itself failed (connectivity, auth, parse).
 	// The compute engine treats a non-nil Err as an Unknown health state.
 	Err error
+
+	// ProbeErr is non-nil when a synthetic probe (type http) ran and found
+	// its target down: no response in time, an unexpected status, or a body
+	// that did not match. Unlike Err this is a measurement, reported with the
+	// probe_* fields; the compute engine marks the source Critical.
+	ProbeErr error
+}
+
+// Histogram is a cumulative bucket histogram merged across all series of one
+// metric family. Bucket bounds are normalised to milliseconds.
+type Histogram struct {
+	// Buckets are sorted by ascending UpperMs; the last bucket is +Inf.
+	Buckets []Bucket
+	// Count is the total number of observations.
+	Count float64
+	// SumMs is the sum of all observed values in milliseconds.
+	SumMs float64
+}
+
+// Bucket is one cumulative histogram bucket.
+type Bucket struct {
+	UpperMs float64 // inclusive upper bound in milliseconds; +Inf for the last bucket
+	Count   float64 // cumulative count of observations <= UpperMs
 }
 
 // Scraper is the common interface implemented by every pipeline component scraper.
@@ -64,6 +99,14 @@ func New(src config.Source) (Scraper, error) {
 		return &lokiScraper{src: src, client: client}, nil
 	case "fluentbit":
 		return &fluentbitScraper{src: src, client: client}, nil
+	case "jaeger":
+		return &jaegerScraper{src: src, client: client}, nil
+	case "http":
+		s, err := newHTTPScraper(src, client)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
 	default:
 		return nil, fmt.Errorf("scraper: unsupported type %q", src.Type)
 	}
@@ -90,7 +133,8 @@ func (t *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 	return t.base.RoundTrip(req)
 }
 
-// buildHTTPClient constructs an http.Client for the source's auth and TLS settings.
+// buildHTTPClient constructs an http.Client for the source's auth and TLS
+// settings, timing out after src.ScrapeTimeout (defaultScrapeTimeout if unset).
 func buildHTTPClient(src config.Source) (*http.Client, error) {
 	tlsCfg := &tls.Config{
 		InsecureSkipVerify: src.TLS.InsecureSkipVerify, //nolint:gosec // user-configured
@@ -120,9 +164,15 @@ func buildHTTPClient(src config.Source) (*http.Client, error) {
 		base: &http.Transport{TLSClientConfig: tlsCfg},
 		src:  src,
 	}
+	// The runner also bounds each scrape with a context deadline; the client
+	// timeout only matters for callers that scrape without one.
+	timeout := src.ScrapeTimeout
+	if timeout <= 0 {
+		timeout = defaultScrapeTimeout
+	}
 	return &http.Client{
 		Transport: transport,
-		Timeout:   defaultScrapeTimeout,
+		Timeout:   timeout,
 	}, nil
 }
 
@@ -179,6 +229,117 @@ func sumFamily(mf *dto.MetricFamily) float64 {
 	return total
 }
 
+// sumFamilyWithLabel is like sumFamily but only includes series whose label
+// name has the given value. Returns 0 if mf is nil or no series match.
+func sumFamilyWithLabel(mf *dto.MetricFamily, name, value string) float64 {
+	return sumFamily(filterFamily(mf, func(m *dto.Metric) bool {
+		return labelValue(m, name) == value
+	}))
+}
+
+// filterFamily returns a copy of mf containing only the series for which keep
+// returns true. Returns nil if mf is nil.
+func filterFamily(mf *dto.MetricFamily, keep func(*dto.Metric) bool) *dto.MetricFamily {
+	if mf == nil {
+		return nil
+	}
+	filtered := &dto.MetricFamily{Name: mf.Name, Type: mf.Type}
+	for _, m := range mf.GetMetric() {
+		if keep(m) {
+			filtered.Metric = append(filtered.Metric, m)
+		}
+	}
+	return filtered
+}
+
+// labelValue returns the value of the named label on m, or "" if absent.
+func labelValue(m *dto.Metric, name string) string {
+	for _, lp := range m.GetLabel() {
+		if lp.GetName() == name {
+			return lp.GetValue()
+		}
+	}
+	return ""
+}
+
+// histogramFamily merges every histogram series in mf into one Histogram.
+// Series may use different bucket bounds: the merged histogram has the union
+// of them, and a series without a bucket at some bound contributes its count
+// at its highest bound below it, so the merged cumulative counts never
+// decrease. scaleMs converts the family's unit to milliseconds (1000 for
+// _seconds metrics). Returns nil if mf is nil or holds no histogram series.
+func histogramFamily(mf *dto.MetricFamily, scaleMs float64) *Histogram {
+	if mf == nil {
+		return nil
+	}
+	h := &Histogram{}
+	var series [][]Bucket
+	bounds := make(map[float64]bool)
+	for _, m := range mf.GetMetric() {
+		ph := m.GetHistogram()
+		if ph == nil {
+			continue
+		}
+		h.Count += float64(ph.GetSampleCount())
+		h.SumMs += ph.GetSampleSum() * scaleMs
+		var bs []Bucket
+		hasInf := false
+		for _, b := range ph.GetBucket() {
+			bound := b.GetUpperBound()
+			if math.IsInf(bound, +1) {
+				hasInf = true
+			} else {
+				bound *= scaleMs
+			}
+			bs = append(bs, Bucket{UpperMs: bound, Count: float64(b.GetCumulativeCount())})
+			bounds[bound] = true
+		}
+		if !hasInf {
+			// Exposition formats may omit +Inf; its count is the sample count.
+			bs = append(bs, Bucket{UpperMs: math.Inf(+1), Count: float64(ph.GetSampleCount())})
+			bounds[math.Inf(+1)] = true
+		}
+		sort.Slice(bs, func(i, j int) bool { return bs[i].UpperMs < bs[j].UpperMs })
+		series = append(series, bs)
+	}
+	if series == nil {
+		return nil
+	}
+	for bound := range bounds {
+		h.Buckets = append(h.Buckets, Bucket{UpperMs: bound})
+	}
+	sort.Slice(h.Buckets, func(i, j int) bool { return h.Buckets[i].UpperMs < h.Buckets[j].UpperMs })
+	for _, bs := range series {
+		// Walk the union and the series' own buckets together, carrying the
+		// count of its highest bucket at or below each bound.
+		var j int
+		var count float64
+		for i := range h.Buckets {
+			for j < len(bs) && bs[j].UpperMs <= h.Buckets[i].UpperMs {
+				count = max(count, bs[j].Count)
+				j++
+			}
+			h.Buckets[i].Count += count
+		}
+	}
+	return h
+}
+
+// histogramSumCount adds up the _sum and _count of every histogram series in mf.
+// Both are monotonic counters. Returns (0, 0) if mf is nil or not a histogram.
+func histogramSumCount(mf *dto.MetricFamily) (sum, count float64) {
+	if mf == nil {
+		return 0, 0
+	}
+	for _, m := range mf.GetMetric() {
+		if h := m.GetHistogram(); h != nil {
+			sum += h.GetSampleSum()
+			count += float64(h.GetSampleCount())
+		}
+	}
+	return sum, count
+}
+
 // newResult initialises an empty ScrapeResult with all maps allocated.
 func newResult(sourceID, sourceType string) *ScrapeResult {
 	return &ScrapeResult{
@@ -5,7 +5,11 @@
 // scores from these results.
 //
 // Implemented scrapers: OTel Collector (otel.go), Prometheus (prometheus.go),
-// Loki (loki.go). Factory: New(config.Source) returns the correct Scraper.
+// Loki (loki.go), Fluent Bit (fluentbit.go), Jaeger collector (jaeger.go).
+// The http type (http.go) is a synthetic prober rather than a metrics scraper:
+// it records status code, response time and body match for an arbitrary URL,
+// and reports a target that is down in ScrapeResult.ProbeErr, not Err.
+// Factory: New(config.Source) returns the correct Scraper.
 //
 // Authentication (mTLS, API key, bearer token) is handled by the shared
 // authRoundTripper in base.go; individual scrapers receive a pre-configured
@@ -0,0 +1,98 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/obsidianstack/obsidianstack/agent/internal/config"
+)
+
+// maxProbeBody caps how much of a probe response is read for body matching.
+const maxProbeBody = 1 << 20 // 1 MiB
+
+type httpScraper struct {
+	src       config.Source
+	client    *http.Client
+	bodyMatch *regexp.Regexp // nil when no body_match is configured
+}
+
+func newHTTPScraper(src config.Source, client *http.Client) (*httpScraper, error) {
+	s := &httpScraper{src: src, client: client}
+	if src.Probe.BodyMatch != "" {
+		re, err := regexp.Compile(src.Probe.BodyMatch)
+		if err != nil {
+			return nil, fmt.Errorf("scraper %q: compile body_match: %w", src.ID, err)
+		}
+		s.bodyMatch = re
+	}
+	return s, nil
+}
+
+// Scrape sends one synthetic probe request to the source endpoint and records
+// the outcome. It has no counters — Received/Dropped stay empty.
+//
+// The probe is up when the status code is in probe.expected_status (any 2xx by
+// default) and, if probe.body_match is set, the body matches it. A probe that
+// is down — including one that got no response at all — sets res.ProbeErr
+// rather than res.Err: the probe worked and its result is the target being
+// down, so the compute engine reports it critical with the probe_* fields and
+// counts it against uptime. Only a request that cannot be built sets res.Err.
+//
+// ResponseTime is set whenever a response arrives; the compute engine turns the
+// rolling window of response times into latency percentiles.
+//
+// Extra fields (gauges, prefixed "probe_" so the engine does not rate them):
+//
+//	probe_status_code (0 = no response), probe_response_ms, probe_body_match
+//	(1 = matched)
+func (s *httpScraper) Scrape(ctx context.Context) (*ScrapeResult, error) {
+	res := newResult(s.src.ID, "http")
+
+	req, err := http.NewRequestWithContext(ctx, s.src.Probe.EffectiveMethod(), s.src.Endpoint, nil)
+	if err != nil {
+		res.Err = fmt.Errorf("http probe %q: build request: %w", s.src.ID, err)
+		return res, nil
+	}
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		res.ProbeErr = fmt.Errorf("http probe %q: %w", s.src.ID, err)
+		res.Extra["probe_status_code"] = 0
+		res.Extra["probe_response_ms"] = float64(time.Since(start)) / float64(time.Millisecond)
+		res.Extra["probe_body_match"] = 0
+		slog.Warn("scraper: http probe failed", "source", s.src.ID, "err", err)
+		return res, nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+	res.ResponseTime = time.Since(start)
+	res.Extra["probe_status_code"] = float64(resp.StatusCode)
+	res.Extra["probe_response_ms"] = float64(res.ResponseTime) / float64(time.Millisecond)
+	if err != nil {
+		res.ProbeErr = fmt.Errorf("http probe %q: read body: %w", s.src.ID, err)
+		res.Extra["probe_body_match"] = 0
+		return res, nil
+	}
+
+	matched := s.bodyMatch == nil || s.bodyMatch.Match(body)
+	if matched {
+		res.Extra["probe_body_match"] = 1
+	} else {
+		res.Extra["probe_body_match"] = 0
+	}
+
+	switch {
+	case !s.src.Probe.StatusOK(resp.StatusCode):
+		res.ProbeErr = fmt.Errorf("http probe %q: unexpected status %d", s.src.ID, resp.StatusCode)
+	case !matched:
+		res.ProbeErr = fmt.Errorf("http probe %q: body did not match %q", s.src.ID, s.src.Probe.BodyMatch)
+	}
+	return res, nil
+}
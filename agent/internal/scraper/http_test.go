@@ -0,0 +1,130 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/obsidianstack/obsidianstack/agent/internal/config"
+)
+
+func newProbe(t *testing.T, status int, body string, probe config.ProbeConfig) *httpScraper {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	s, err := newHTTPScraper(config.Source{ID: "probe-test", Type: "http", Endpoint: srv.URL, Probe: probe}, srv.Client())
+	if err != nil {
+		t.Fatalf("newHTTPScraper: %v", err)
+	}
+	return s
+}
+
+func TestHTTPScraper_Up(t *testing.T) {
+	s := newProbe(t, http.StatusOK, `{"database": "ok"}`, config.ProbeConfig{BodyMatch: `"database":\s*"ok"`})
+	res, err := s.Scrape(context.Background())
+	if err != nil {
+		t.Fatalf("Scrape() error = %v", err)
+	}
+	if res.Err != nil || res.ProbeErr != nil {
+		t.Fatalf("res.Err = %v, res.ProbeErr = %v", res.Err, res.ProbeErr)
+	}
+	if res.SourceType != "http" {
+		t.Errorf("SourceType = %q, want http", res.SourceType)
+	}
+	if got := res.Extra["probe_status_code"]; got != 200 {
+		t.Errorf("Extra[probe_status_code] = %v, want 200", got)
+	}
+	if got := res.Extra["probe_body_match"]; got != 1 {
+		t.Errorf("Extra[probe_body_match] = %v, want 1", got)
+	}
+	if res.ResponseTime <= 0 {
+		t.Errorf("ResponseTime = %v, want > 0", res.ResponseTime)
+	}
+	if len(res.Received) != 0 || len(res.Dropped) != 0 {
+		t.Errorf("probe should not report counters, got Received=%v Dropped=%v", res.Received, res.Dropped)
+	}
+}
+
+func TestHTTPScraper_UnexpectedStatus(t *testing.T) {
+	s := newProbe(t, http.StatusServiceUnavailable, "", config.ProbeConfig{})
+	res, _ := s.Scrape(context.Background())
+	if res.Err != nil || res.ProbeErr == nil {
+		t.Fatalf("503 response: Err = %v, ProbeErr = %v; want only ProbeErr", res.Err, res.ProbeErr)
+	}
+	if got := res.Extra["probe_status_code"]; got != 503 {
+		t.Errorf("Extra[probe_status_code] = %v, want 503", got)
+	}
+}
+
+func TestHTTPScraper_ExpectedStatusOverride(t *testing.T) {
+	s := newProbe(t, http.StatusUnauthorized, "", config.ProbeConfig{ExpectedStatus: []int{401}})
+	res, _ := s.Scrape(context.Background())
+	if res.Err != nil || res.ProbeErr != nil {
+		t.Fatalf("401 listed in expected_status should be up, got: %v, %v", res.Err, res.ProbeErr)
+	}
+}
+
+func TestHTTPScraper_BodyMismatch(t *testing.T) {
+	s := newProbe(t, http.StatusOK, `{"database": "failing"}`, config.ProbeConfig{BodyMatch: `"database":\s*"ok"`})
+	res, _ := s.Scrape(context.Background())
+	if res.Err != nil || res.ProbeErr == nil {
+		t.Fatalf("body mismatch: Err = %v, ProbeErr = %v; want only ProbeErr", res.Err, res.ProbeErr)
+	}
+	if got := res.Extra["probe_body_match"]; got != 0 {
+		t.Errorf("Extra[probe_body_match] = %v, want 0", got)
+	}
+}
+
+func TestHTTPScraper_ConnectFailure(t *testing.T) {
+	s, err := newHTTPScraper(config.Source{ID: "probe-down", Endpoint: "http://127.0.0.1:1"}, &http.Client{})
+	if err != nil {
+		t.Fatalf("newHTTPScraper: %v", err)
+	}
+	res, err := s.Scrape(context.Background())
+	if err != nil {
+		t.Fatalf("Scrape() should not return err, got: %v", err)
+	}
+	if res.Err != nil || res.ProbeErr == nil {
+		t.Fatalf("unreachable endpoint: Err = %v, ProbeErr = %v; want only ProbeErr", res.Err, res.ProbeErr)
+	}
+	if got, ok := res.Extra["probe_status_code"]; !ok || got != 0 {
+		t.Errorf("Extra[probe_status_code] = %v (set %v), want 0", got, ok)
+	}
+}
+
+func TestHTTPScraper_SendsAuthAndMethod(t *testing.T) {
+	var gotMethod, gotKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotKey = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	t.Setenv("TEST_GRAFANA_KEY", "Bearer glc_123")
+	src := config.Source{
+		ID:       "ext-grafana",
+		Type:     "http",
+		Endpoint: srv.URL,
+		Auth:     config.AuthConfig{Mode: "apikey", Header: "Authorization", KeyEnv: "TEST_GRAFANA_KEY"},
+		Probe:    config.ProbeConfig{Method: http.MethodHead},
+	}
+	s, err := New(src)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	res, _ := s.Scrape(context.Background())
+	if res.Err != nil {
+		t.Fatalf("res.Err = %v", res.Err)
+	}
+	if gotMethod != http.MethodHead {
+		t.Errorf("method = %q, want HEAD", gotMethod)
+	}
+	if gotKey != "Bearer glc_123" {
+		t.Errorf("Authorization header = %q, want %q", gotKey, "Bearer glc_123")
+	}
+}
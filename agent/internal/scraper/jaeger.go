@@ -0,0 +1,82 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/obsidianstack/obsidianstack/agent/internal/config"
+)
+
+// Jaeger collector internal metric names we track. These are served on the
+// collector's admin port (default :14269/metrics).
+const (
+	// Spans received by the collector across all transports and formats.
+	jaegerSpansReceived = "jaeger_collector_spans_received_total"
+
+	// Spans rejected by the span processor (invalid, or the queue refused them).
+	jaegerSpansRejected = "jaeger_collector_spans_rejected_total"
+
+	// Spans dropped by the bounded in-memory queue when it was full.
+	jaegerSpansDropped = "jaeger_collector_spans_dropped_total"
+
+	// Spans written to storage, labelled result="ok" | "err".
+	jaegerSpansSaved = "jaeger_collector_spans_saved_by_svc_total"
+
+	// Current number of spans waiting in the queue.
+	jaegerQueueLength = "jaeger_collector_queue_length"
+
+	// Maximum number of spans the queue can hold.
+	jaegerQueueCapacity = "jaeger_collector_queue_capacity"
+
+	// Storage write latency histogram (seconds).
+	jaegerSaveLatency = "jaeger_collector_save_latency"
+)
+
+type jaegerScraper struct {
+	src    config.Source
+	client *http.Client
+}
+
+// Scrape fetches the Jaeger collector's Prometheus metrics endpoint and
+// returns span ingestion health data.
+//
+// All signal data is reported under the "traces" signal type.
+// Dropped = queue drops + processor rejections. The queue gauges are stored
+// as Extra["queue_size"] / Extra["queue_capacity"] so the compute engine
+// passes them through as gauges. The save latency histogram is carried in
+// res.Latency; its _sum/_count counters also go to Extra so the server can
+// show mean latency.
+func (s *jaegerScraper) Scrape(ctx context.Context) (*ScrapeResult, error) {
+	res := newResult(s.src.ID, "jaeger")
+
+	mfs, err := fetchMetrics(ctx, s.client, s.src.Endpoint)
+	if err != nil {
+		res.Err = fmt.Errorf("jaeger scrape %q: %w", s.src.ID, err)
+		slog.Warn("scraper: jaeger fetch failed", "source", s.src.ID, "err", err)
+		return res, nil
+	}
+
+	received := sumFamily(mfs[jaegerSpansReceived])
+	rejected := sumFamily(mfs[jaegerSpansRejected])
+	dropped := sumFamily(mfs[jaegerSpansDropped])
+
+	res.Received["traces"] = received
+	res.Dropped["traces"] = dropped + rejected
+
+	res.Extra["spans_received"] = received
+	res.Extra["spans_rejected"] = rejected
+	res.Extra["spans_dropped"] = dropped
+	res.Extra["spans_saved_ok"] = sumFamilyWithLabel(mfs[jaegerSpansSaved], "result", "ok")
+	res.Extra["spans_saved_err"] = sumFamilyWithLabel(mfs[jaegerSpansSaved], "result", "err")
+	res.Extra["queue_size"] = sumFamily(mfs[jaegerQueueLength])
+	res.Extra["queue_capacity"] = sumFamily(mfs[jaegerQueueCapacity])
+
+	latSum, latCount := histogramSumCount(mfs[jaegerSaveLatency])
+	res.Extra["save_latency_seconds_sum"] = latSum
+	res.Extra["save_latency_count"] = latCount
+	res.Latency = histogramFamily(mfs[jaegerSaveLatency], 1000)
+
+	return res, nil
+}
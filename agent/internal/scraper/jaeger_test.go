@@ -0,0 +1,121 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/obsidianstack/obsidianstack/agent/internal/config"
+)
+
+// jaegerMetrics is a realistic subset of a Jaeger collector's admin /metrics output.
+const jaegerMetrics = `
+# HELP jaeger_collector_spans_received_total received spans
+# TYPE jaeger_collector_spans_received_total counter
+jaeger_collector_spans_received_total{debug="false",format="proto",svc="checkout",transport="grpc"} 9000
+jaeger_collector_spans_received_total{debug="false",format="jaeger",svc="cart",transport="http"} 1000
+
+# HELP jaeger_collector_spans_rejected_total rejected spans
+# TYPE jaeger_collector_spans_rejected_total counter
+jaeger_collector_spans_rejected_total{debug="false",format="proto",svc="checkout",transport="grpc"} 40
+
+# HELP jaeger_collector_spans_dropped_total spans dropped by the queue
+# TYPE jaeger_collector_spans_dropped_total counter
+jaeger_collector_spans_dropped_total{host="collector-0"} 60
+
+# HELP jaeger_collector_spans_saved_by_svc_total spans saved by service
+# TYPE jaeger_collector_spans_saved_by_svc_total counter
+jaeger_collector_spans_saved_by_svc_total{debug="false",result="ok",svc="checkout"} 9800
+jaeger_collector_spans_saved_by_svc_total{debug="false",result="err",svc="checkout"} 12
+
+# HELP jaeger_collector_queue_length current number of spans in the queue
+# TYPE jaeger_collector_queue_length gauge
+jaeger_collector_queue_length 750
+
+# HELP jaeger_collector_queue_capacity queue capacity
+# TYPE jaeger_collector_queue_capacity gauge
+jaeger_collector_queue_capacity 2000
+
+# HELP jaeger_collector_save_latency latency of saving spans to storage
+# TYPE jaeger_collector_save_latency histogram
+jaeger_collector_save_latency_bucket{host="collector-0",le="0.005"} 400
+jaeger_collector_save_latency_bucket{host="collector-0",le="0.05"} 900
+jaeger_collector_save_latency_bucket{host="collector-0",le="+Inf"} 1000
+jaeger_collector_save_latency_sum{host="collector-0"} 12.5
+jaeger_collector_save_latency_count{host="collector-0"} 1000
+`
+
+func TestJaegerScraper_Scrape(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = w.Write([]byte(jaegerMetrics))
+	}))
+	defer srv.Close()
+
+	s := &jaegerScraper{
+		src:    config.Source{ID: "jaeger-test", Type: "jaeger", Endpoint: srv.URL},
+		client: srv.Client(),
+	}
+
+	res, err := s.Scrape(context.Background())
+	if err != nil {
+		t.Fatalf("Scrape() error = %v", err)
+	}
+	if res.Err != nil {
+		t.Fatalf("res.Err = %v", res.Err)
+	}
+	if res.SourceType != "jaeger" {
+		t.Errorf("SourceType = %q, want jaeger", res.SourceType)
+	}
+
+	// Received = 9000 + 1000 spans
+	if got := res.Received["traces"]; got != 10000 {
+		t.Errorf("Received[traces] = %v, want 10000", got)
+	}
+	// Dropped = 60 queue drops + 40 rejected
+	if got := res.Dropped["traces"]; got != 100 {
+		t.Errorf("Dropped[traces] = %v, want 100", got)
+	}
+
+	cases := map[string]float64{
+		"spans_rejected":           40,
+		"spans_dropped":            60,
+		"spans_saved_ok":           9800,
+		"spans_saved_err":          12,
+		"queue_size":               750,
+		"queue_capacity":           2000,
+		"save_latency_seconds_sum": 12.5,
+		"save_latency_count":       1000,
+	}
+	for key, want := range cases {
+		if got := res.Extra[key]; got != want {
+			t.Errorf("Extra[%s] = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestJaegerScraper_ConnectFailure(t *testing.T) {
+	s := &jaegerScraper{
+		src:    config.Source{ID: "jaeger-down", Endpoint: "http://127.0.0.1:1"},
+		client: &http.Client{},
+	}
+	res, err := s.Scrape(context.Background())
+	if err != nil {
+		t.Fatalf("Scrape() should not return err, got: %v", err)
+	}
+	if res.Err == nil {
+		t.Fatal("res.Err should be set when endpoint is unreachable")
+	}
+}
+
+func TestNew_Jaeger(t *testing.T) {
+	src := config.Source{ID: "j", Type: "jaeger", Endpoint: "http://localhost:14269/metrics"}
+	s, err := New(src)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, ok := s.(*jaegerScraper); !ok {
+		t.Errorf("New() returned %T, want *jaegerScraper", s)
+	}
+}
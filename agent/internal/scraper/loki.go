@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
 
 	"github.com/obsidianstack/obsidianstack/agent/internal/config"
 )
@@ -34,6 +37,10 @@ const (
 
 	// Ingester ingestion rate — samples/sec being appended.
 	lokiIngestionRate = "loki_ingester_ingestion_rate_bytes"
+
+	// HTTP/gRPC request duration histogram (seconds), labelled by route.
+	// Only push routes (loki_api_v1_push, api_prom_push) are used for latency.
+	lokiRequestDuration = "loki_request_duration_seconds"
 )
 
 type lokiScraper struct {
@@ -47,6 +54,7 @@ type lokiScraper struct {
 // All signal data is reported under the "logs" signal type.
 // The ring health metrics (cortex_ring_*) are only present in microservice
 // mode; in monolithic mode they will be 0 in Extra, which is not an error.
+// The push request duration histogram is carried in res.Latency.
 func (s *lokiScraper) Scrape(ctx context.Context) (*ScrapeResult, error) {
 	res := newResult(s.src.ID, "loki")
 
@@ -74,5 +82,10 @@ func (s *lokiScraper) Scrape(ctx context.Context) (*ScrapeResult, error) {
 	res.Extra["ring_replication"] = sumFamily(mfs[lokiRingReplication])
 	res.Extra["ingestion_rate_bytes"] = sumFamily(mfs[lokiIngestionRate])
 
+	pushOnly := filterFamily(mfs[lokiRequestDuration], func(m *dto.Metric) bool {
+		return strings.Contains(labelValue(m, "route"), "push")
+	})
+	res.Latency = histogramFamily(pushOnly, 1000)
+
 	return res, nil
 }
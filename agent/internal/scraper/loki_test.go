@@ -147,3 +147,30 @@ func TestSumFamily_Nil(t *testing.T) {
 		t.Errorf("sumFamily(nil) = %v, want 0", got)
 	}
 }
+
+func TestLokiScraper_PushLatencyOnly(t *testing.T) {
+	body := `
+# TYPE loki_request_duration_seconds histogram
+loki_request_duration_seconds_bucket{route="loki_api_v1_push",le="0.05"} 90
+loki_request_duration_seconds_bucket{route="loki_api_v1_push",le="+Inf"} 100
+loki_request_duration_seconds_sum{route="loki_api_v1_push"} 3
+loki_request_duration_seconds_count{route="loki_api_v1_push"} 100
+loki_request_duration_seconds_bucket{route="loki_api_v1_query_range",le="0.05"} 0
+loki_request_duration_seconds_bucket{route="loki_api_v1_query_range",le="+Inf"} 500
+loki_request_duration_seconds_sum{route="loki_api_v1_query_range"} 900
+loki_request_duration_seconds_count{route="loki_api_v1_query_range"} 500
+`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	s := &lokiScraper{src: config.Source{ID: "loki-lat", Endpoint: srv.URL}, client: srv.Client()}
+	res, _ := s.Scrape(context.Background())
+	if res.Latency == nil {
+		t.Fatal("res.Latency is nil, want push-route histogram")
+	}
+	if res.Latency.Count != 100 {
+		t.Errorf("Latency.Count = %v, want 100 (query routes excluded)", res.Latency.Count)
+	}
+}
@@ -17,6 +17,9 @@ const (
 	otelExporterSent     = "otelcol_exporter_sent"
 	otelExporterFailed   = "otelcol_exporter_send_failed"
 	otelProcessorDropped = "otelcol_processor_dropped"
+
+	// Exporter send latency histogram (milliseconds), one series per exporter.
+	otelExporterSendLatency = "otelcol_exporter_send_latency"
 )
 
 // otelSuffixes maps the OTel metric suffix to the canonical signal type.
@@ -37,6 +40,9 @@ type otelScraper struct {
 // Dropped items include exporter send failures and processor drops.
 // Receiver refusals are tracked in Extra["receiver_refused_*"] for diagnostics
 // but excluded from the drop count (they never entered the pipeline).
+//
+// The exporter send latency histogram, merged across exporters, is carried in
+// res.Latency for the compute engine's P50/P95/P99.
 func (s *otelScraper) Scrape(ctx context.Context) (*ScrapeResult, error) {
 	res := newResult(s.src.ID, "otelcol")
 
@@ -73,5 +79,7 @@ func (s *otelScraper) Scrape(ctx context.Context) (*ScrapeResult, error) {
 	res.Extra["exporter_queue_size"] = sumFamily(mfs["otelcol_exporter_queue_size"])
 	res.Extra["exporter_queue_capacity"] = sumFamily(mfs["otelcol_exporter_queue_capacity"])
 
+	res.Latency = histogramFamily(mfs[otelExporterSendLatency], 1)
+
 	return res, nil
 }
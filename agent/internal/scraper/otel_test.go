@@ -228,7 +228,7 @@ func TestOTelScraper_BearerAuth(t *testing.T) {
 }
 
 func TestNew_UnsupportedType(t *testing.T) {
-	src := config.Source{ID: "x", Type: "jaeger", Endpoint: "http://localhost:14269"}
+	src := config.Source{ID: "x", Type: "kafka", Endpoint: "http://localhost:9308"}
 	_, err := New(src)
 	if err == nil {
 		t.Fatal("New() with unsupported type should return error")
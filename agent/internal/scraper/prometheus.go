@@ -31,6 +31,9 @@ const (
 
 	// WAL storage errors — unrecoverable write errors to local WAL.
 	promWALErrors = "prometheus_tsdb_wal_storage_errors_total"
+
+	// Remote write batch send duration histogram (seconds).
+	promSentBatchDuration = "prometheus_remote_storage_sent_batch_duration_seconds"
 )
 
 type promScraper struct {
@@ -42,7 +45,8 @@ type promScraper struct {
 // and remote-write health data.
 //
 // All signal data is reported under the "metrics" signal type since Prometheus
-// only handles metric samples.
+// only handles metric samples. The remote write batch duration histogram is
+// carried in res.Latency.
 func (s *promScraper) Scrape(ctx context.Context) (*ScrapeResult, error) {
 	res := newResult(s.src.ID, "prometheus")
 
@@ -68,5 +72,7 @@ func (s *promScraper) Scrape(ctx context.Context) (*ScrapeResult, error) {
 	res.Extra["shards_active"] = sumFamily(mfs[promShardsActive])
 	res.Extra["wal_errors"] = sumFamily(mfs[promWALErrors])
 
+	res.Latency = histogramFamily(mfs[promSentBatchDuration], 1000)
+
 	return res, nil
 }
@@ -2,6 +2,7 @@ package scraper
 
 import (
 	"context"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -141,3 +142,97 @@ func TestPromScraper_ConnectFailure(t *testing.T) {
 		t.Fatal("res.Err should be set when endpoint is unreachable")
 	}
 }
+
+func TestPromScraper_SentBatchDurationHistogram(t *testing.T) {
+	body := `
+# TYPE prometheus_remote_storage_sent_batch_duration_seconds histogram
+prometheus_remote_storage_sent_batch_duration_seconds_bucket{remote_name="a",le="0.01"} 10
+prometheus_remote_storage_sent_batch_duration_seconds_bucket{remote_name="a",le="0.1"} 30
+prometheus_remote_storage_sent_batch_duration_seconds_bucket{remote_name="a",le="+Inf"} 40
+prometheus_remote_storage_sent_batch_duration_seconds_sum{remote_name="a"} 2
+prometheus_remote_storage_sent_batch_duration_seconds_count{remote_name="a"} 40
+prometheus_remote_storage_sent_batch_duration_seconds_bucket{remote_name="b",le="0.01"} 5
+prometheus_remote_storage_sent_batch_duration_seconds_bucket{remote_name="b",le="0.1"} 5
+prometheus_remote_storage_sent_batch_duration_seconds_bucket{remote_name="b",le="+Inf"} 10
+prometheus_remote_storage_sent_batch_duration_seconds_sum{remote_name="b"} 3
+prometheus_remote_storage_sent_batch_duration_seconds_count{remote_name="b"} 10
+`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	s := &promScraper{src: config.Source{ID: "prom-hist", Endpoint: srv.URL}, client: srv.Client()}
+	res, _ := s.Scrape(context.Background())
+	if res.Err != nil {
+		t.Fatalf("res.Err = %v", res.Err)
+	}
+	h := res.Latency
+	if h == nil {
+		t.Fatal("res.Latency is nil, want merged histogram")
+	}
+	if h.Count != 50 || h.SumMs != 5000 {
+		t.Errorf("Count/SumMs = %v/%v, want 50/5000", h.Count, h.SumMs)
+	}
+	// Buckets merged across both remotes and converted to milliseconds.
+	want := []Bucket{{UpperMs: 10, Count: 15}, {UpperMs: 100, Count: 35}, {UpperMs: math.Inf(+1), Count: 50}}
+	if len(h.Buckets) != len(want) {
+		t.Fatalf("buckets = %v, want %v", h.Buckets, want)
+	}
+	for i, b := range want {
+		if h.Buckets[i] != b {
+			t.Errorf("bucket[%d] = %v, want %v", i, h.Buckets[i], b)
+		}
+	}
+}
+
+// TestPromScraper_HistogramDifferentBounds merges series whose bucket bounds
+// differ: each series counts at every bound of the union, so the merged
+// counts never decrease.
+func TestPromScraper_HistogramDifferentBounds(t *testing.T) {
+	body := `
+# TYPE prometheus_remote_storage_sent_batch_duration_seconds histogram
+prometheus_remote_storage_sent_batch_duration_seconds_bucket{remote_name="a",le="0.1"} 0
+prometheus_remote_storage_sent_batch_duration_seconds_bucket{remote_name="a",le="+Inf"} 10
+prometheus_remote_storage_sent_batch_duration_seconds_sum{remote_name="a"} 5
+prometheus_remote_storage_sent_batch_duration_seconds_count{remote_name="a"} 10
+prometheus_remote_storage_sent_batch_duration_seconds_bucket{remote_name="b",le="0.05"} 8
+prometheus_remote_storage_sent_batch_duration_seconds_bucket{remote_name="b",le="+Inf"} 8
+prometheus_remote_storage_sent_batch_duration_seconds_sum{remote_name="b"} 0.2
+prometheus_remote_storage_sent_batch_duration_seconds_count{remote_name="b"} 8
+`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	s := &promScraper{src: config.Source{ID: "prom-hist", Endpoint: srv.URL}, client: srv.Client()}
+	res, _ := s.Scrape(context.Background())
+	if res.Latency == nil {
+		t.Fatal("res.Latency is nil, want merged histogram")
+	}
+	// b's 8 samples at or below 50ms also lie at or below 100ms.
+	want := []Bucket{{UpperMs: 50, Count: 8}, {UpperMs: 100, Count: 8}, {UpperMs: math.Inf(+1), Count: 18}}
+	got := res.Latency.Buckets
+	if len(got) != len(want) {
+		t.Fatalf("buckets = %v, want %v", got, want)
+	}
+	for i, b := range want {
+		if got[i] != b {
+			t.Errorf("bucket[%d] = %v, want %v", i, got[i], b)
+		}
+	}
+}
+
+func TestPromScraper_NoHistogram(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(promMetrics))
+	}))
+	defer srv.Close()
+
+	s := &promScraper{src: config.Source{ID: "prom", Endpoint: srv.URL}, client: srv.Client()}
+	res, _ := s.Scrape(context.Background())
+	if res.Latency != nil {
+		t.Errorf("res.Latency = %v, want nil when no histogram is exposed", res.Latency)
+	}
+}
@@ -13,18 +13,23 @@ import (
 // checker (one per HTTPS endpoint); it may be nil for plain-HTTP sources.
 func toProto(r *compute.Result, certs []*pb.CertStatus) *pb.PipelineSnapshot {
 	snap := &pb.PipelineSnapshot{
-		SourceId:         r.SourceID,
-		SourceType:       r.SourceType,
-		TimestampUnix:    r.Timestamp.Unix(),
-		State:            r.State,
-		DropPct:          r.DropPct,
-		RecoveryRate:     r.RecoveryRate,
-		ThroughputPerMin: r.ThroughputPM,
-		StrengthScore:    r.StrengthScore,
-		UptimePct:        r.UptimePct,
-		ErrorMessage:     r.ErrorMessage,
-		Extra:            r.Extra,
-		Certs:            certs,
+		SourceId:          r.SourceID,
+		SourceType:        r.SourceType,
+		TimestampUnix:     r.Timestamp.Unix(),
+		State:             r.State,
+		DropPct:           r.DropPct,
+		RecoveryRate:      r.RecoveryRate,
+		ThroughputPerMin:  r.ThroughputPM,
+		StrengthScore:     r.StrengthScore,
+		UptimePct:         r.UptimePct,
+		LatencyP50Ms:      r.LatencyP50Ms,
+		LatencyP95Ms:      r.LatencyP95Ms,
+		LatencyP99Ms:      r.LatencyP99Ms,
+		ErrorMessage:      r.ErrorMessage,
+		Extra:             r.Extra,
+		Certs:             certs,
+		ThresholdHealthy:  r.Thresholds.Healthy,
+		ThresholdDegraded: r.Thresholds.Degraded,
 	}
 
 	if r.State == compute.StateUnknown && r.DropPct == 0 {
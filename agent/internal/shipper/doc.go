@@ -1,17 +1,47 @@
 // Package shipper sends PipelineSnapshot protobuf messages to obsidianstack-server
-// via gRPC (SnapshotService.SendSnapshot unary RPC).
+// via gRPC (SnapshotService.SendSnapshots batches, or one SendSnapshot unary
+// RPC per snapshot).
 //
-// Shipper.Ship() is non-blocking: results are converted to proto and placed in
-// an in-memory channel (default capacity 1000). When the buffer is full the
-// oldest entry is evicted so the latest health data is always preserved.
+// Shipper.Ship() is non-blocking: results are converted to proto and queued.
+// By default the queue is in memory (buffer_size entries, default 1000); when
+// the buffer is full the oldest entry is evicted so the latest health data is
+// always preserved.
 //
-// Shipper.Run() drains the buffer in a loop, reconnecting with truncated
+// With agent.wal.dir set the queue is a write-ahead log on disk instead
+// (wal.go): length-prefixed, CRC-checked records in append-only segment files
+// plus a cursor file holding the oldest unacknowledged index. Snapshots
+// survive agent restarts and are replayed on start. The log is bounded by
+// wal.max_bytes (oldest segment evicted) and wal.max_age (expired snapshots
+// skipped), both logged as drops, and fsynced per wal.fsync; with "interval"
+// a timer also syncs the last writes before the queue goes idle. A torn
+// record left by a crash is truncated on open.
+//
+// Shipper.Run() drains the queue in a loop, reconnecting with truncated
 // exponential backoff (1s→60s, ±25% jitter) on connection or send errors.
-// Permanent gRPC errors (Unauthenticated, PermissionDenied, InvalidArgument)
-// discard the snapshot immediately rather than retrying.
+// A snapshot is removed only once the server answered for it, so a transient
+// failure retries it on the next connection. Permanent gRPC errors
+// (Unauthenticated, PermissionDenied, InvalidArgument) discard the snapshot
+// immediately rather than retrying.
+//
+// With batch_size > 1 (default 100) snapshots are sent in batches: a full
+// batch immediately, a partial one ship_interval after the previous send, so a
+// backlog drains in len/batch_size calls. The server acknowledges each
+// snapshot of a batch; a rejected snapshot is logged and dropped, one without
+// a result stays queued. Servers without SendSnapshots answer Unimplemented
+// and the connection falls back to SendSnapshot, so old servers keep working.
+//
+// Depth() and Dropped() report the queue
+// length and the number of snapshots discarded unsent; Connected() reports
+// whether Run holds a server connection. Collectors() exposes these, plus
+// reconnect counts and per-RPC latency (metrics.go), as Prometheus metrics.
+//
+// Shipper.Reconfigure() applies a hot-reloaded config: a changed server
+// endpoint or server_auth makes Run redial immediately, keeping the buffer.
 //
-// Auth: mTLS via credentials.NewTLS(), API key via gRPC metadata header,
-// or insecure (plaintext) for local development.
+// Auth: mTLS via credentials.NewTLS(), API key via gRPC metadata header over
+// TLS (server verified against server_auth.ca_file or the system roots;
+// server_auth.plaintext opts out), or insecure (plaintext) for local
+// development.
 //
 // The dialFn field is injectable for testing (bufconn / net.Listen).
 package shipper
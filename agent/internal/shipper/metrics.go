@@ -0,0 +1,53 @@
+package shipper
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	reconnects = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "obsidian_agent_shipper_reconnects_total",
+		Help: "Times the shipper lost or dropped its server connection and redialled.",
+	})
+
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "obsidian_agent_shipper_rpc_duration_seconds",
+		Help:    "Latency of snapshot delivery RPCs, by method and gRPC status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "code"})
+)
+
+// observeRPC records the latency of one RPC started at start.
+func observeRPC(method string, start time.Time, err error) {
+	rpcDuration.WithLabelValues(method, status.Code(err).String()).Observe(time.Since(start).Seconds())
+}
+
+// Collectors returns the shipper's internal metrics for registration: the
+// package-wide RPC metrics plus buffer depth, dropped snapshots and
+// connection state of s.
+func (s *Shipper) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		reconnects,
+		rpcDuration,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "obsidian_agent_shipper_buffer_depth",
+			Help: "Snapshots waiting to be sent.",
+		}, func() float64 { return float64(s.Depth()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "obsidian_agent_shipper_dropped_total",
+			Help: "Snapshots discarded unsent: evicted from a full buffer or expired by wal.max_age.",
+		}, func() float64 { return float64(s.Dropped()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "obsidian_agent_shipper_connected",
+			Help: "1 while the shipper holds a server connection, else 0.",
+		}, func() float64 {
+			if s.Connected() {
+				return 1
+			}
+			return 0
+		}),
+	}
+}
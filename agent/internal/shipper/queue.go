@@ -0,0 +1,123 @@
+package shipper
+
+import (
+	"log/slog"
+	"sync"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+)
+
+// queue holds snapshots waiting to be sent to the server, oldest first.
+//
+// Entries are read with peek and only leave the queue once ack confirms they
+// were delivered (or permanently rejected), so a failed send or an agent
+// restart never loses the entry being sent. Every entry carries a monotonic
+// index; ack(next) removes all entries with a lower index, which stays
+// correct even if push evicted some of them in the meantime.
+//
+// Implementations must be safe for concurrent use.
+type queue interface {
+	// push appends snap. A full queue evicts its oldest entries first.
+	push(snap *pb.PipelineSnapshot) error
+
+	// peek returns up to n entries from the head without removing them.
+	peek(n int) []entry
+
+	// ack removes every entry with an index below next.
+	ack(next uint64)
+
+	// ready is signalled after each push, so a consumer that found the
+	// queue empty can wait for new entries.
+	ready() <-chan struct{}
+
+	// depth returns the number of entries waiting to be sent.
+	depth() int
+
+	// dropped returns how many entries were discarded without being sent
+	// (evicted on overflow, or expired) since the queue was opened.
+	dropped() uint64
+
+	close() error
+}
+
+// entry is one queued snapshot and its position in the queue.
+type entry struct {
+	idx  uint64
+	snap *pb.PipelineSnapshot
+}
+
+// memQueue is the default in-memory queue, bounded by entry count. Its
+// contents are lost when the agent stops.
+type memQueue struct {
+	mu     sync.Mutex
+	items  []entry
+	next   uint64 // index of the next pushed entry
+	max    int
+	drops  uint64
+	notify chan struct{}
+}
+
+func newMemQueue(max int) *memQueue {
+	return &memQueue{max: max, notify: make(chan struct{}, 1)}
+}
+
+func (q *memQueue) push(snap *pb.PipelineSnapshot) error {
+	q.mu.Lock()
+	if len(q.items) >= q.max {
+		// Buffer full — drop the oldest snapshot, keep the newest.
+		evicted := q.items[0]
+		q.items = q.items[1:]
+		q.drops++
+		slog.Warn("shipper: buffer full, evicted oldest snapshot",
+			"source", evicted.snap.SourceId, "buffer_cap", q.max)
+	}
+	q.items = append(q.items, entry{idx: q.next, snap: snap})
+	q.next++
+	q.mu.Unlock()
+
+	signal(q.notify)
+	return nil
+}
+
+func (q *memQueue) peek(n int) []entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n = min(n, len(q.items))
+	out := make([]entry, n)
+	copy(out, q.items[:n])
+	return out
+}
+
+func (q *memQueue) ack(next uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := 0
+	for i < len(q.items) && q.items[i].idx < next {
+		i++
+	}
+	q.items = q.items[i:]
+}
+
+func (q *memQueue) ready() <-chan struct{} { return q.notify }
+
+func (q *memQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+func (q *memQueue) dropped() uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.drops
+}
+
+func (q *memQueue) close() error { return nil }
+
+// signal does a non-blocking send on a 1-buffered notification channel.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/rand"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
@@ -30,47 +33,104 @@ const (
 )
 
 // Shipper buffers compute.Results and ships them to obsidianstack-server via gRPC.
-// Ship() is non-blocking; when the buffer is full the oldest snapshot is evicted.
-// Run() must be called in a goroutine to drain the buffer and handle reconnection.
+// Ship() is non-blocking; snapshots wait in a queue — in memory, or on disk
+// when the WAL is enabled — until the server acknowledges them.
+// Run() must be called in a goroutine to drain the queue and handle reconnection.
 type Shipper struct {
-	cfg    config.AgentConfig
-	buf    chan *pb.PipelineSnapshot
-	dialFn dialFunc // injectable for tests
+	mu        sync.Mutex
+	cfg       config.AgentConfig
+	q         queue
+	reconnect chan struct{} // signalled by Reconfigure to drop the current connection
+	dialFn    dialFunc      // injectable for tests
+	connected atomic.Bool   // true while Run holds a connection
 }
 
+// errReconfigured is returned by drain when Reconfigure asked for a redial.
+var errReconfigured = errors.New("server connection settings changed")
+
 // dialFunc is the function signature used to open a gRPC connection.
 // Abstracted so tests can inject an in-memory bufconn dialer.
 type dialFunc func(ctx context.Context, endpoint string, cfg config.AgentConfig) (*grpc.ClientConn, error)
 
-// New creates a Shipper using the given agent config.
-func New(cfg config.AgentConfig) *Shipper {
+// New creates a Shipper using the given agent config. When cfg.WAL is
+// enabled it opens the on-disk buffer, replaying snapshots left from a
+// previous run; otherwise snapshots are buffered in memory.
+func New(cfg config.AgentConfig) (*Shipper, error) {
+	var q queue = newMemQueue(cfg.BufferSize)
+	if cfg.WAL.Enabled() {
+		w, err := openWAL(cfg.WAL)
+		if err != nil {
+			return nil, fmt.Errorf("shipper: open wal %q: %w", cfg.WAL.Dir, err)
+		}
+		q = w
+	}
 	return &Shipper{
-		cfg:    cfg,
-		buf:    make(chan *pb.PipelineSnapshot, cfg.BufferSize),
-		dialFn: defaultDial,
+		cfg:       cfg,
+		q:         q,
+		reconnect: make(chan struct{}, 1),
+		dialFn:    defaultDial,
+	}, nil
+}
+
+// Reconfigure applies a hot-reloaded agent config. When the server endpoint
+// or server auth changed, Run drops its current connection and redials with
+// the new settings; buffered snapshots are kept and sent on the new
+// connection. Changes to buffer_size or wal only take effect after a restart.
+func (s *Shipper) Reconfigure(cfg config.AgentConfig) {
+	s.mu.Lock()
+	changed := cfg.ServerEndpoint != s.cfg.ServerEndpoint || cfg.ServerAuth != s.cfg.ServerAuth
+	if cfg.WAL != s.cfg.WAL {
+		slog.Warn("shipper: wal change requires a restart")
+	} else if !cfg.WAL.Enabled() && cfg.BufferSize != s.cfg.BufferSize {
+		slog.Warn("shipper: buffer_size change requires a restart",
+			"current", s.cfg.BufferSize, "configured", cfg.BufferSize)
+	}
+	// The queue is fixed for the life of the Shipper; keep the settings in use.
+	cfg.WAL, cfg.BufferSize = s.cfg.WAL, s.cfg.BufferSize
+	s.cfg = cfg
+	s.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	slog.Info("shipper: server settings changed, reconnecting", "endpoint", cfg.ServerEndpoint)
+	select {
+	case s.reconnect <- struct{}{}:
+	default: // a redial is already pending
 	}
 }
 
+// config returns the current agent config.
+func (s *Shipper) config() config.AgentConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg
+}
+
 // Ship converts a compute.Result to a proto snapshot and enqueues it.
 // certs contains any TLS certificate status records for this source (may be nil).
-// If the buffer is full the oldest entry is evicted to make room.
+// If the queue is full the oldest entries are evicted to make room.
 func (s *Shipper) Ship(res *compute.Result, certs []*pb.CertStatus) {
-	snap := toProto(res, certs)
-	select {
-	case s.buf <- snap:
-	default:
-		// Buffer full — drop the oldest snapshot, keep the newest.
-		select {
-		case <-s.buf:
-			slog.Warn("shipper: buffer full, evicted oldest snapshot",
-				"source", res.SourceID, "buffer_cap", cap(s.buf))
-		default:
-		}
-		s.buf <- snap
+	if err := s.q.push(toProto(res, certs)); err != nil {
+		slog.Error("shipper: could not buffer snapshot, dropping it",
+			"source", res.SourceID, "err", err)
 	}
 }
 
-// Run drains the buffer, sending snapshots to the server.
+// Depth returns the number of snapshots waiting to be sent.
+func (s *Shipper) Depth() int { return s.q.depth() }
+
+// Dropped returns the number of snapshots discarded unsent — evicted from a
+// full buffer or expired by wal.max_age — since the Shipper was created.
+func (s *Shipper) Dropped() uint64 { return s.q.dropped() }
+
+// Connected reports whether Run currently holds a server connection.
+func (s *Shipper) Connected() bool { return s.connected.Load() }
+
+// Close flushes and closes the buffer. Call it after Run has returned.
+func (s *Shipper) Close() error { return s.q.close() }
+
+// Run drains the queue, sending snapshots to the server.
 // It reconnects with exponential backoff when the connection is lost.
 // Run blocks until ctx is cancelled.
 func (s *Shipper) Run(ctx context.Context) {
@@ -81,97 +141,227 @@ func (s *Shipper) Run(ctx context.Context) {
 			return
 		}
 
-		conn, err := s.dialFn(ctx, s.cfg.ServerEndpoint, s.cfg)
+		cfg := s.config()
+		conn, err := s.dialFn(ctx, cfg.ServerEndpoint, cfg)
 		if err != nil {
 			wait := bo.next()
 			slog.Error("shipper: dial failed, will retry",
-				"endpoint", s.cfg.ServerEndpoint,
+				"endpoint", cfg.ServerEndpoint,
 				"err", err,
 				"retry_in", wait)
 			select {
 			case <-ctx.Done():
 				return
+			case <-s.reconnect:
+				bo.reset()
+				continue
 			case <-time.After(wait):
 				continue
 			}
 		}
 
-		slog.Info("shipper: connected", "endpoint", s.cfg.ServerEndpoint)
+		slog.Info("shipper: connected", "endpoint", cfg.ServerEndpoint, "queued", s.q.depth())
 		bo.reset()
+		s.connected.Store(true)
 
-		err = s.drain(ctx, conn)
+		err = s.drain(ctx, conn, cfg)
 		conn.Close()
+		s.connected.Store(false)
 
 		if ctx.Err() != nil {
 			return
 		}
+		reconnects.Inc()
+		if errors.Is(err, errReconfigured) {
+			continue // redial immediately with the new settings
+		}
 
 		wait := bo.next()
 		slog.Warn("shipper: connection lost, will reconnect",
-			"endpoint", s.cfg.ServerEndpoint,
+			"endpoint", cfg.ServerEndpoint,
 			"err", err,
 			"retry_in", wait)
 		select {
 		case <-ctx.Done():
 			return
+		case <-s.reconnect:
+			bo.reset()
 		case <-time.After(wait):
 		}
 	}
 }
 
-// drain reads from the buffer and sends snapshots until the connection fails
-// or ctx is cancelled.
-func (s *Shipper) drain(ctx context.Context, conn *grpc.ClientConn) error {
+// drain sends queued snapshots, oldest first, until the connection fails,
+// Reconfigure asks for a redial, or ctx is cancelled. cfg is the config the
+// connection was dialled with. A snapshot leaves the queue only once the
+// server answered for it; after a transient failure it is sent again on the
+// next connection.
+//
+// With batch_size > 1 snapshots go out in SendSnapshots batches: a full batch
+// is sent at once, a partial one ship_interval after the previous send. A
+// server without SendSnapshots gets one SendSnapshot call per snapshot.
+func (s *Shipper) drain(ctx context.Context, conn *grpc.ClientConn, cfg config.AgentConfig) error {
 	client := pb.NewSnapshotServiceClient(conn)
+	batchRPC := true
+	var lastSend time.Time
 
 	for {
+		// A pending redial wins over buffered snapshots, so nothing shipped
+		// after Reconfigure goes to the old server.
 		select {
-		case <-ctx.Done():
-			return nil
+		case <-s.reconnect:
+			return errReconfigured
+		default:
+		}
 
-		case snap := <-s.buf:
-			sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		// Batching settings are hot-reloadable; read them on every round.
+		live := s.config()
+		batched := batchRPC && live.BatchSize > 1
 
-			// Inject API key header if configured.
-			if s.cfg.ServerAuth.Mode == "apikey" && s.cfg.ServerAuth.KeyEnv != "" {
-				sendCtx = metadata.AppendToOutgoingContext(
-					sendCtx,
-					s.cfg.ServerAuth.Header, s.cfg.ServerAuth.Key(),
-				)
+		head := s.q.peek(max(live.BatchSize, 1))
+		var due *time.Timer
+		if batched && len(head) > 0 && len(head) < live.BatchSize {
+			if d := live.ShipInterval - time.Since(lastSend); d > 0 {
+				due = time.NewTimer(d)
 			}
-
-			resp, err := client.SendSnapshot(sendCtx, snap)
-			cancel()
-
-			if err != nil {
-				// Put the snapshot back at the front if there's room.
-				select {
-				case s.buf <- snap:
-				default:
-					// Buffer full — snapshot lost; this is acceptable since the
-					// server will receive the next cycle's data on reconnect.
-				}
-
-				// Transient errors (unavailable, deadline exceeded) → reconnect.
-				// Permanent errors (unauthenticated, invalid arg) → log and discard.
-				if isPermanentError(err) {
-					slog.Error("shipper: permanent send error, discarding snapshot",
-						"source", snap.SourceId, "err", err)
-					continue
-				}
-				return fmt.Errorf("send: %w", err)
+		}
+		if len(head) == 0 || due != nil {
+			// Empty queue or a partial batch: wait for more snapshots or
+			// for the batch to become due.
+			var dueC <-chan time.Time
+			if due != nil {
+				dueC = due.C
 			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-s.reconnect:
+				return errReconfigured
+			case <-s.q.ready():
+			case <-dueC:
+			}
+			if due != nil {
+				due.Stop()
+			}
+			continue
+		}
 
-			if !resp.Ok {
-				slog.Warn("shipper: server rejected snapshot",
-					"source", snap.SourceId, "message", resp.Message)
-			} else {
-				slog.Debug("shipper: snapshot delivered", "source", snap.SourceId)
+		lastSend = time.Now()
+		if !batched {
+			if err := s.sendOne(ctx, client, cfg, head[0]); err != nil {
+				return err
 			}
+			continue
+		}
+		err := s.sendBatch(ctx, client, cfg, head)
+		if status.Code(err) == codes.Unimplemented {
+			slog.Info("shipper: server does not support batched delivery, sending snapshots one by one",
+				"endpoint", cfg.ServerEndpoint)
+			batchRPC = false
+			continue
+		}
+		if err != nil {
+			return err
 		}
 	}
 }
 
+// callContext returns the context for one RPC: bounded by sendTimeout and
+// carrying the API key header if configured.
+func callContext(ctx context.Context, cfg config.AgentConfig) (context.Context, context.CancelFunc) {
+	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+	if cfg.ServerAuth.Mode == "apikey" && cfg.ServerAuth.KeyEnv != "" {
+		sendCtx = metadata.AppendToOutgoingContext(
+			sendCtx,
+			cfg.ServerAuth.Header, cfg.ServerAuth.Key(),
+		)
+	}
+	return sendCtx, cancel
+}
+
+// sendOne delivers e with the unary SendSnapshot RPC and acknowledges it in
+// the queue unless the error is transient.
+func (s *Shipper) sendOne(ctx context.Context, client pb.SnapshotServiceClient, cfg config.AgentConfig, e entry) error {
+	sendCtx, cancel := callContext(ctx, cfg)
+	start := time.Now()
+	resp, err := client.SendSnapshot(sendCtx, e.snap)
+	observeRPC("SendSnapshot", start, err)
+	cancel()
+
+	if err != nil {
+		// Transient errors (unavailable, deadline exceeded) → reconnect,
+		// leaving the snapshot at the head of the queue.
+		// Permanent errors (unauthenticated, invalid arg) → log and discard.
+		if isPermanentError(err) {
+			slog.Error("shipper: permanent send error, discarding snapshot",
+				"source", e.snap.SourceId, "err", err)
+			s.q.ack(e.idx + 1)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("send: %w", err)
+	}
+	s.q.ack(e.idx + 1)
+
+	if !resp.Ok {
+		slog.Warn("shipper: server rejected snapshot",
+			"source", e.snap.SourceId, "message", resp.Message)
+	} else {
+		slog.Debug("shipper: snapshot delivered", "source", e.snap.SourceId)
+	}
+	return nil
+}
+
+// sendBatch delivers batch with SendSnapshots and acknowledges every snapshot
+// the server answered for. Snapshots without a result stay queued and the
+// connection is dropped, so they are retried after a reconnect. An
+// Unimplemented error is returned unwrapped so drain can fall back.
+func (s *Shipper) sendBatch(ctx context.Context, client pb.SnapshotServiceClient, cfg config.AgentConfig, batch []entry) error {
+	snaps := make([]*pb.PipelineSnapshot, len(batch))
+	for i, e := range batch {
+		snaps[i] = e.snap
+	}
+
+	sendCtx, cancel := callContext(ctx, cfg)
+	start := time.Now()
+	resp, err := client.SendSnapshots(sendCtx, &pb.SnapshotBatch{Snapshots: snaps})
+	observeRPC("SendSnapshots", start, err)
+	cancel()
+
+	if err != nil {
+		switch {
+		case status.Code(err) == codes.Unimplemented:
+			return err
+		case isPermanentError(err):
+			slog.Error("shipper: permanent send error, discarding batch",
+				"snapshots", len(batch), "err", err)
+			s.q.ack(batch[len(batch)-1].idx + 1)
+			return nil
+		case ctx.Err() != nil:
+			return nil
+		}
+		return fmt.Errorf("send batch: %w", err)
+	}
+
+	n := min(len(resp.Results), len(batch))
+	for i, r := range resp.Results[:n] {
+		if !r.Ok {
+			slog.Warn("shipper: server rejected snapshot",
+				"source", snaps[i].SourceId, "message", r.Message)
+		}
+	}
+	if n > 0 {
+		s.q.ack(batch[n-1].idx + 1)
+	}
+	if n < len(batch) {
+		return fmt.Errorf("send batch: server acknowledged %d of %d snapshots", n, len(batch))
+	}
+	slog.Debug("shipper: batch delivered", "snapshots", n)
+	return nil
+}
+
 // isPermanentError returns true for gRPC errors that indicate the snapshot
 // itself is invalid and should not be retried.
 func isPermanentError(err error) bool {
@@ -196,31 +386,41 @@ func defaultDial(ctx context.Context, endpoint string, cfg config.AgentConfig) (
 func dialOptions(cfg config.AgentConfig) ([]grpc.DialOption, error) {
 	switch cfg.ServerAuth.Mode {
 	case "mtls":
-		creds, err := buildMTLSCreds(cfg.ServerAuth)
+		creds, err := buildTLSCreds(cfg.ServerAuth, true)
 		if err != nil {
 			return nil, fmt.Errorf("shipper: build mtls creds: %w", err)
 		}
 		return []grpc.DialOption{grpc.WithTransportCredentials(creds)}, nil
 
 	case "apikey":
-		// API key is injected per-call in drain(); use plain TLS transport.
-		// In production you'd also want server-side TLS here.
-		return []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, nil
+		// API key is injected per-call in drain(); the transport is TLS so
+		// the key is not sent in the clear, unless plaintext is set.
+		if cfg.ServerAuth.Plaintext {
+			return []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, nil
+		}
+		creds, err := buildTLSCreds(cfg.ServerAuth, false)
+		if err != nil {
+			return nil, fmt.Errorf("shipper: build tls creds: %w", err)
+		}
+		return []grpc.DialOption{grpc.WithTransportCredentials(creds)}, nil
 
 	default: // "none" or empty — insecure for local dev
 		return []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, nil
 	}
 }
 
-// buildMTLSCreds loads client certificate and optional CA from the auth config.
-func buildMTLSCreds(auth config.AuthConfig) (credentials.TransportCredentials, error) {
-	cert, err := tls.LoadX509KeyPair(auth.CertFile, auth.KeyFile)
-	if err != nil {
-		return nil, fmt.Errorf("load client cert: %w", err)
-	}
+// buildTLSCreds builds TLS transport credentials from the auth config: the
+// server is verified against CAFile if set (system roots otherwise), and
+// with clientCert the client certificate is loaded for mTLS.
+func buildTLSCreds(auth config.AuthConfig, clientCert bool) (credentials.TransportCredentials, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
 
-	tlsCfg := &tls.Config{
-		Certificates: []tls.Certificate{cert},
+	if clientCert {
+		cert, err := tls.LoadX509KeyPair(auth.CertFile, auth.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client cert: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
 	}
 
 	if auth.CAFile != "" {
@@ -7,8 +7,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	"github.com/obsidianstack/obsidianstack/agent/internal/compute"
 	"github.com/obsidianstack/obsidianstack/agent/internal/config"
@@ -21,6 +24,7 @@ type mockServer struct {
 	mu       sync.Mutex
 	received []*pb.PipelineSnapshot
 	rejectN  int  // reject the first N calls with an error
+	failN    int  // fail the first N calls with codes.Unavailable
 	okResp   bool // the Ok field in SendResponse
 }
 
@@ -28,6 +32,10 @@ func (m *mockServer) SendSnapshot(_ context.Context, snap *pb.PipelineSnapshot)
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.failN > 0 {
+		m.failN--
+		return nil, status.Error(codes.Unavailable, "mock outage")
+	}
 	if m.rejectN > 0 {
 		m.rejectN--
 		return &pb.SendResponse{Ok: false, Message: "mock rejection"}, nil
@@ -37,6 +45,36 @@ func (m *mockServer) SendSnapshot(_ context.Context, snap *pb.PipelineSnapshot)
 	return &pb.SendResponse{Ok: m.okResp || true}, nil
 }
 
+// batchServer is a mockServer that also implements SendSnapshots, recording
+// the size of every batch. Snapshots whose source ID is "bad" are rejected.
+type batchServer struct {
+	mockServer
+	batches []int
+}
+
+func (m *batchServer) SendSnapshots(_ context.Context, b *pb.SnapshotBatch) (*pb.BatchResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.batches = append(m.batches, len(b.Snapshots))
+	resp := &pb.BatchResponse{}
+	for _, snap := range b.Snapshots {
+		if snap.SourceId == "bad" {
+			resp.Results = append(resp.Results, &pb.SendResponse{Ok: false, Message: "mock rejection"})
+			continue
+		}
+		m.received = append(m.received, snap)
+		resp.Results = append(resp.Results, &pb.SendResponse{Ok: true})
+	}
+	return resp, nil
+}
+
+func (m *batchServer) batchSizes() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]int(nil), m.batches...)
+}
+
 func (m *mockServer) snapshots() []*pb.PipelineSnapshot {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -47,7 +85,19 @@ func (m *mockServer) snapshots() []*pb.PipelineSnapshot {
 
 // startTestServer starts an in-process gRPC server and returns
 // a dial function that connects to it over a buffered pipe.
-func startTestServer(t *testing.T, srv *mockServer) dialFunc {
+func startTestServer(t *testing.T, srv pb.SnapshotServiceServer) dialFunc {
+	t.Helper()
+
+	addr := listenTestServer(t, srv)
+	return func(ctx context.Context, _ string, _ config.AgentConfig) (*grpc.ClientConn, error) {
+		return grpc.DialContext(ctx, addr, //nolint:staticcheck
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+}
+
+// listenTestServer starts an in-process gRPC server on a loopback port and
+// returns its address.
+func listenTestServer(t *testing.T, srv pb.SnapshotServiceServer) string {
 	t.Helper()
 
 	lis, err := net.Listen("tcp", "127.0.0.1:0")
@@ -65,11 +115,27 @@ func startTestServer(t *testing.T, srv *mockServer) dialFunc {
 	}()
 	t.Cleanup(gs.Stop)
 
-	addr := lis.Addr().String()
-	return func(ctx context.Context, _ string, _ config.AgentConfig) (*grpc.ClientConn, error) {
-		return grpc.DialContext(ctx, addr, //nolint:staticcheck
-			grpc.WithTransportCredentials(insecure.NewCredentials()))
+	return lis.Addr().String()
+}
+
+// dialEndpoint is a dialFunc that connects to whatever endpoint the shipper
+// is configured with, so tests can observe endpoint changes.
+func dialEndpoint(ctx context.Context, endpoint string, _ config.AgentConfig) (*grpc.ClientConn, error) {
+	return grpc.DialContext(ctx, endpoint, //nolint:staticcheck
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+// waitFor polls cond until it returns true or the deadline passes.
+func waitFor(t *testing.T, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
 	}
+	return false
 }
 
 // makeComputeResult builds a minimal compute.Result for testing.
@@ -90,6 +156,17 @@ func makeComputeResult(id string) *compute.Result {
 	}
 }
 
+// newTestShipper returns a Shipper for cfg that is closed when the test ends.
+func newTestShipper(t *testing.T, cfg config.AgentConfig) *Shipper {
+	t.Helper()
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
 func agentCfg() config.AgentConfig {
 	return config.AgentConfig{
 		ServerEndpoint: "unused-overridden-by-dialFn",
@@ -104,7 +181,7 @@ func TestShipper_DeliversSnapshot(t *testing.T) {
 	srv := &mockServer{}
 	dial := startTestServer(t, srv)
 
-	s := New(agentCfg())
+	s := newTestShipper(t, agentCfg())
 	s.dialFn = dial
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -139,7 +216,7 @@ func TestShipper_MultipleSnapshots(t *testing.T) {
 	srv := &mockServer{}
 	dial := startTestServer(t, srv)
 
-	s := New(agentCfg())
+	s := newTestShipper(t, agentCfg())
 	s.dialFn = dial
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -167,7 +244,7 @@ func TestShipper_MultipleSnapshots(t *testing.T) {
 func TestShipper_BufferEvictsOldest(t *testing.T) {
 	// BufferSize=3; Ship 5 items while the shipper is not running.
 	// Only the 3 most recent should survive.
-	s := New(config.AgentConfig{BufferSize: 3})
+	s := newTestShipper(t, config.AgentConfig{BufferSize: 3})
 
 	for i := 0; i < 5; i++ {
 		res := makeComputeResult("src")
@@ -175,18 +252,10 @@ func TestShipper_BufferEvictsOldest(t *testing.T) {
 		s.Ship(res, nil)
 	}
 
-	// Drain the buffer manually and check which remain.
 	var scores []float64
-	for {
-		select {
-		case snap := <-s.buf:
-			scores = append(scores, snap.StrengthScore)
-		default:
-			goto done
-		}
+	for _, e := range s.q.peek(10) {
+		scores = append(scores, e.snap.StrengthScore)
 	}
-done:
-
 	if len(scores) != 3 {
 		t.Fatalf("buffer has %d items, want 3", len(scores))
 	}
@@ -196,12 +265,143 @@ done:
 			t.Errorf("scores[%d] = %.0f, want %.0f", i, scores[i], want)
 		}
 	}
+	if got := s.Dropped(); got != 2 {
+		t.Errorf("Dropped() = %d, want 2", got)
+	}
+}
+
+func TestShipper_TransientErrorKeepsSnapshot(t *testing.T) {
+	srv := &mockServer{failN: 1}
+	dial := startTestServer(t, srv)
+
+	s := newTestShipper(t, agentCfg())
+	s.dialFn = dial
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go s.Run(ctx)
+
+	s.Ship(makeComputeResult("retry-me"), nil)
+
+	deadline := time.Now().Add(4 * time.Second)
+	for time.Now().Before(deadline) && len(srv.snapshots()) == 0 {
+		time.Sleep(20 * time.Millisecond)
+	}
+	snaps := srv.snapshots()
+	if len(snaps) != 1 || snaps[0].SourceId != "retry-me" {
+		t.Fatalf("server received %d snapshots, want the retried one", len(snaps))
+	}
+	if d := s.Depth(); d != 0 {
+		t.Errorf("Depth() after delivery = %d, want 0", d)
+	}
+}
+
+func TestShipper_BatchesBacklog(t *testing.T) {
+	srv := &batchServer{}
+	cfg := agentCfg()
+	cfg.BufferSize = 100
+	cfg.BatchSize = 10
+	cfg.ShipInterval = time.Hour
+
+	s := newTestShipper(t, cfg)
+	s.dialFn = startTestServer(t, srv)
+	for i := 0; i < 25; i++ {
+		s.Ship(makeComputeResult("src"), nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go s.Run(ctx)
+
+	// Full batches go out at once; the partial one waits for ship_interval.
+	if !waitFor(t, func() bool { return len(srv.snapshots()) == 20 }) {
+		t.Fatalf("server received %d snapshots, want 20", len(srv.snapshots()))
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := srv.batchSizes(); len(got) != 2 || got[0] != 10 || got[1] != 10 {
+		t.Errorf("batch sizes = %v, want [10 10]", got)
+	}
+	if d := s.Depth(); d != 5 {
+		t.Errorf("Depth() = %d, want 5 waiting for ship_interval", d)
+	}
+}
+
+func TestShipper_PartialBatchFlushedOnShipInterval(t *testing.T) {
+	srv := &batchServer{}
+	cfg := agentCfg()
+	cfg.BatchSize = 10
+	cfg.ShipInterval = 100 * time.Millisecond
+
+	s := newTestShipper(t, cfg)
+	s.dialFn = startTestServer(t, srv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go s.Run(ctx)
+
+	s.Ship(makeComputeResult("first"), nil)
+	if !waitFor(t, func() bool { return len(srv.snapshots()) == 1 }) {
+		t.Fatal("first snapshot not delivered")
+	}
+	s.Ship(makeComputeResult("second"), nil)
+	s.Ship(makeComputeResult("third"), nil)
+	if !waitFor(t, func() bool { return len(srv.snapshots()) == 3 }) {
+		t.Fatalf("server received %d snapshots, want 3", len(srv.snapshots()))
+	}
+	if got := srv.batchSizes(); len(got) != 2 || got[1] != 2 {
+		t.Errorf("batch sizes = %v, want [1 2]", got)
+	}
+}
+
+func TestShipper_BatchRejectionsAcked(t *testing.T) {
+	srv := &batchServer{}
+	cfg := agentCfg()
+	cfg.BatchSize = 3
+
+	s := newTestShipper(t, cfg)
+	s.dialFn = startTestServer(t, srv)
+	for _, id := range []string{"a", "bad", "c"} {
+		s.Ship(makeComputeResult(id), nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go s.Run(ctx)
+
+	if !waitFor(t, func() bool { return s.Depth() == 0 }) {
+		t.Fatalf("Depth() = %d, want 0 (rejected snapshot is not retried)", s.Depth())
+	}
+	if got := srv.snapshots(); len(got) != 2 || got[0].SourceId != "a" || got[1].SourceId != "c" {
+		t.Errorf("server stored %d snapshots, want a and c", len(got))
+	}
+}
+
+func TestShipper_BatchFallsBackToUnary(t *testing.T) {
+	srv := &mockServer{} // predates SendSnapshots
+	cfg := agentCfg()
+	cfg.BatchSize = 10
+
+	s := newTestShipper(t, cfg)
+	s.dialFn = startTestServer(t, srv)
+	for i := 0; i < 3; i++ {
+		s.Ship(makeComputeResult("src"), nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go s.Run(ctx)
+
+	if !waitFor(t, func() bool { return len(srv.snapshots()) == 3 }) {
+		t.Errorf("server received %d snapshots, want 3", len(srv.snapshots()))
+	}
 }
 
 func TestShipper_ConvertToProto(t *testing.T) {
 	res := makeComputeResult("prom-test")
 	res.DropPct = 3.14
 	res.StrengthScore = 88.5
+	res.LatencyP95Ms = 420
+	res.Thresholds = compute.Thresholds{Healthy: 70, Degraded: 40}
 	res.Signals = []compute.SignalResult{
 		{Type: "metrics", ReceivedPM: 5000, DroppedPM: 160, DropPct: 3.1},
 		{Type: "traces", ReceivedPM: 100, DroppedPM: 3, DropPct: 2.9},
@@ -218,6 +418,12 @@ func TestShipper_ConvertToProto(t *testing.T) {
 	if snap.StrengthScore != 88.5 {
 		t.Errorf("StrengthScore = %v, want 88.5", snap.StrengthScore)
 	}
+	if snap.LatencyP95Ms != 420 {
+		t.Errorf("LatencyP95Ms = %v, want 420", snap.LatencyP95Ms)
+	}
+	if snap.ThresholdHealthy != 70 || snap.ThresholdDegraded != 40 {
+		t.Errorf("thresholds = %v/%v, want 70/40", snap.ThresholdHealthy, snap.ThresholdDegraded)
+	}
 	if len(snap.Signals) != 2 {
 		t.Fatalf("Signals len = %d, want 2", len(snap.Signals))
 	}
@@ -263,7 +469,7 @@ func TestShipper_GracefulShutdown(t *testing.T) {
 	srv := &mockServer{}
 	dial := startTestServer(t, srv)
 
-	s := New(agentCfg())
+	s := newTestShipper(t, agentCfg())
 	s.dialFn = dial
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -284,3 +490,102 @@ func TestShipper_GracefulShutdown(t *testing.T) {
 		t.Fatal("Run() did not return after context cancellation")
 	}
 }
+
+func TestShipper_ReconfigureRedialsNewEndpoint(t *testing.T) {
+	oldSrv, newSrv := &mockServer{}, &mockServer{}
+	cfg := agentCfg()
+	cfg.ServerEndpoint = listenTestServer(t, oldSrv)
+
+	s := newTestShipper(t, cfg)
+	s.dialFn = dialEndpoint
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go s.Run(ctx)
+
+	s.Ship(makeComputeResult("before"), nil)
+	if !waitFor(t, func() bool { return len(oldSrv.snapshots()) == 1 }) {
+		t.Fatal("old server did not receive the first snapshot")
+	}
+
+	cfg.ServerEndpoint = listenTestServer(t, newSrv)
+	s.Reconfigure(cfg)
+	s.Ship(makeComputeResult("after"), nil)
+
+	if !waitFor(t, func() bool { return len(newSrv.snapshots()) == 1 }) {
+		t.Fatalf("new server received %d snapshots, want 1", len(newSrv.snapshots()))
+	}
+	if got := newSrv.snapshots()[0].SourceId; got != "after" {
+		t.Errorf("new server SourceId = %q, want after", got)
+	}
+	if got := len(oldSrv.snapshots()); got != 1 {
+		t.Errorf("old server received %d snapshots after reconfigure, want 1", got)
+	}
+}
+
+func TestShipper_Metrics(t *testing.T) {
+	srv := &mockServer{}
+	s := newTestShipper(t, agentCfg())
+	s.dialFn = startTestServer(t, srv)
+
+	cs := s.Collectors()
+	depth := cs[2]
+	if got := testutil.ToFloat64(depth); got != 0 {
+		t.Fatalf("buffer depth before Ship = %v, want 0", got)
+	}
+	s.Ship(makeComputeResult("queued"), nil)
+	if got := testutil.ToFloat64(depth); got != 1 {
+		t.Errorf("buffer depth after Ship = %v, want 1", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go s.Run(ctx)
+
+	if !waitFor(t, func() bool { return len(srv.snapshots()) == 1 && s.Connected() }) {
+		t.Fatal("snapshot not delivered")
+	}
+	if got := testutil.ToFloat64(cs[4]); got != 1 {
+		t.Errorf("connected = %v, want 1", got)
+	}
+	if !waitFor(t, func() bool { return testutil.ToFloat64(depth) == 0 }) {
+		t.Errorf("buffer depth after delivery = %v, want 0", testutil.ToFloat64(depth))
+	}
+	if n := testutil.CollectAndCount(rpcDuration, "obsidian_agent_shipper_rpc_duration_seconds"); n == 0 {
+		t.Error("no RPC latency recorded")
+	}
+}
+
+func TestShipper_ReconfigureUnchangedKeepsConnection(t *testing.T) {
+	s := newTestShipper(t, agentCfg())
+	cfg := agentCfg()
+	cfg.ShipInterval = 5 * time.Second
+	s.Reconfigure(cfg)
+
+	select {
+	case <-s.reconnect:
+		t.Error("Reconfigure without server changes requested a redial")
+	default:
+	}
+}
+
+func TestDialOptions_APIKeyUsesTLS(t *testing.T) {
+	creds, err := buildTLSCreds(config.AuthConfig{Mode: "apikey"}, false)
+	if err != nil {
+		t.Fatalf("buildTLSCreds: %v", err)
+	}
+	if p := creds.Info().SecurityProtocol; p != "tls" {
+		t.Errorf("SecurityProtocol = %q, want tls", p)
+	}
+
+	cfg := agentCfg()
+	cfg.ServerAuth = config.AuthConfig{Mode: "apikey", CAFile: "/nonexistent/ca.crt"}
+	if _, err := dialOptions(cfg); err == nil {
+		t.Error("dialOptions with unreadable ca_file: got nil error, want error")
+	}
+
+	cfg.ServerAuth.Plaintext = true
+	if _, err := dialOptions(cfg); err != nil {
+		t.Errorf("dialOptions with plaintext: %v", err)
+	}
+}
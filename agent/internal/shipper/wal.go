@@ -0,0 +1,665 @@
+package shipper
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/obsidianstack/obsidianstack/agent/internal/config"
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+)
+
+const (
+	walSegmentExt = ".wal"
+	walCursorFile = "cursor"
+
+	// walHeaderSize is the per-record header: uint32 payload length followed
+	// by the uint32 CRC-32C of the payload, both little-endian.
+	walHeaderSize = 8
+
+	// walMaxRecord bounds the payload length read from a header, so a
+	// corrupt length cannot trigger a huge allocation.
+	walMaxRecord = 16 << 20
+
+	// walSegmentsPerMax sets the segment size to MaxBytes/walSegmentsPerMax.
+	// Eviction deletes whole segments, so this is also its granularity.
+	walSegmentsPerMax = 8
+)
+
+var walCRC = crc32.MakeTable(crc32.Castagnoli)
+
+// walSegment describes one segment file. Files are named after the index of
+// their first record, so lexical order is queue order.
+type walSegment struct {
+	first uint64 // index of the first record
+	count uint64 // number of valid records
+	size  int64  // bytes of valid records
+}
+
+func (s walSegment) end() uint64 { return s.first + s.count }
+
+// walPos is the read position: record idx starts at byte off of the segment
+// whose first record is seg.
+type walPos struct {
+	seg uint64
+	off int64
+	idx uint64
+}
+
+// walQueue is a queue backed by append-only segment files in a directory.
+//
+// Pushed snapshots are appended to the newest segment. The index of the
+// oldest unacknowledged record is persisted in a cursor file on every ack, so
+// on restart the queue resumes where it left off — delivery is at least once,
+// since an ack that was not yet persisted is replayed. Segments whose records
+// are all acknowledged are deleted. A torn record at the end of the newest
+// segment (crash mid-write) is truncated on open.
+type walQueue struct {
+	mu       sync.Mutex
+	dir      string
+	cfg      config.WALConfig
+	segBytes int64
+
+	segs   []walSegment // oldest first; the last one is open for appending
+	tail   *os.File
+	next   uint64 // index the next pushed record gets
+	cursor uint64 // index of the oldest unacknowledged record
+
+	rd      walPos   // next record to read into pending
+	rf      *os.File // open read handle for segment rd.seg, or nil
+	pending []entry  // read from disk but not yet acknowledged
+
+	drops    uint64
+	lastSync time.Time
+	unsynced bool // the tail has writes not yet fsynced
+	closed   bool
+	notify   chan struct{}
+	stop     chan struct{}    // closed by close to end syncLoop
+	now      func() time.Time // injectable for tests
+}
+
+// openWAL opens (or creates) the WAL in cfg.Dir and replays its contents.
+func openWAL(cfg config.WALConfig) (*walQueue, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create dir: %w", err)
+	}
+	q := &walQueue{
+		dir:      cfg.Dir,
+		cfg:      cfg,
+		segBytes: max(cfg.MaxBytes/walSegmentsPerMax, 1),
+		notify:   make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		now:      time.Now,
+	}
+	if err := q.load(); err != nil {
+		q.closeFiles()
+		return nil, err
+	}
+	if d := q.depthLocked(); d > 0 {
+		slog.Info("shipper: replaying buffered snapshots from wal", "dir", cfg.Dir, "queued", d)
+		signal(q.notify)
+	}
+	if cfg.Fsync == "interval" {
+		go q.syncLoop(cfg.FsyncInterval)
+	}
+	return q, nil
+}
+
+// syncLoop fsyncs writes left unsynced by push every interval, so the last
+// snapshots before the queue goes idle reach the disk too.
+func (q *walQueue) syncLoop(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case <-t.C:
+		}
+		q.mu.Lock()
+		if !q.closed && q.unsynced {
+			q.syncTail(true)
+		}
+		q.mu.Unlock()
+	}
+}
+
+// load scans the segment files, restores the cursor and opens the tail.
+func (q *walQueue) load() error {
+	names, err := filepath.Glob(filepath.Join(q.dir, "*"+walSegmentExt))
+	if err != nil {
+		return err
+	}
+	var firsts []uint64
+	for _, name := range names {
+		first, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(name), walSegmentExt), 10, 64)
+		if err != nil {
+			slog.Warn("shipper: ignoring unexpected file in wal dir", "file", name)
+			continue
+		}
+		firsts = append(firsts, first)
+	}
+	sort.Slice(firsts, func(i, j int) bool { return firsts[i] < firsts[j] })
+
+	for i, first := range firsts {
+		seg, err := q.scan(first, i == len(firsts)-1)
+		if err != nil {
+			return err
+		}
+		if seg.count == 0 && i < len(firsts)-1 {
+			os.Remove(q.segPath(first)) //nolint:errcheck // empty leftover
+			continue
+		}
+		q.segs = append(q.segs, seg)
+	}
+
+	if len(q.segs) > 0 {
+		q.next = q.segs[len(q.segs)-1].end()
+	}
+	q.cursor = q.readCursor()
+	if len(q.segs) > 0 && q.cursor < q.segs[0].first {
+		q.cursor = q.segs[0].first
+	}
+	q.cursor = min(q.cursor, q.next)
+
+	if len(q.segs) == 0 {
+		if err := q.newSegment(q.next); err != nil {
+			return err
+		}
+	} else {
+		f, err := os.OpenFile(q.segPath(q.segs[len(q.segs)-1].first), os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return fmt.Errorf("open tail segment: %w", err)
+		}
+		q.tail = f
+	}
+	q.dropAckedSegments()
+	return q.seek(q.cursor)
+}
+
+// scan counts the valid records of a segment. A corrupt or torn record ends
+// the segment there; in the newest segment the file is truncated to it so
+// appends continue from a clean boundary.
+func (q *walQueue) scan(first uint64, last bool) (walSegment, error) {
+	seg := walSegment{first: first}
+	f, err := os.Open(q.segPath(first))
+	if err != nil {
+		return seg, fmt.Errorf("open segment: %w", err)
+	}
+	defer f.Close()
+
+	for {
+		n, _, err := readRecord(f, seg.size)
+		if errors.Is(err, io.EOF) {
+			return seg, nil
+		}
+		if err != nil {
+			slog.Warn("shipper: wal segment damaged, discarding its tail",
+				"segment", q.segPath(first), "offset", seg.size, "err", err)
+			if last {
+				if err := os.Truncate(q.segPath(first), seg.size); err != nil {
+					return seg, fmt.Errorf("truncate damaged segment: %w", err)
+				}
+			}
+			return seg, nil
+		}
+		seg.count++
+		seg.size += n
+	}
+}
+
+// readRecord reads the record at off. It returns the record's total size and
+// payload, or io.EOF at a clean end of file.
+func readRecord(f *os.File, off int64) (int64, []byte, error) {
+	var hdr [walHeaderSize]byte
+	if _, err := f.ReadAt(hdr[:], off); err != nil {
+		if errors.Is(err, io.EOF) {
+			// A partial header is a torn write; nothing at all is a clean end.
+			if fi, statErr := f.Stat(); statErr == nil && fi.Size() > off {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+		}
+		return 0, nil, err
+	}
+	length := binary.LittleEndian.Uint32(hdr[0:4])
+	sum := binary.LittleEndian.Uint32(hdr[4:8])
+	if length > walMaxRecord {
+		return 0, nil, fmt.Errorf("record length %d exceeds limit", length)
+	}
+	payload := make([]byte, length)
+	if _, err := f.ReadAt(payload, off+walHeaderSize); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	if crc32.Checksum(payload, walCRC) != sum {
+		return 0, nil, errors.New("checksum mismatch")
+	}
+	return walHeaderSize + int64(length), payload, nil
+}
+
+func (q *walQueue) push(snap *pb.PipelineSnapshot) error {
+	payload, err := proto.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("wal: marshal snapshot: %w", err)
+	}
+	rec := make([]byte, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(rec[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(rec[4:8], crc32.Checksum(payload, walCRC))
+	copy(rec[walHeaderSize:], payload)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return errors.New("wal: closed")
+	}
+
+	tail := &q.segs[len(q.segs)-1]
+	if tail.count > 0 && tail.size+int64(len(rec)) > q.segBytes {
+		if err := q.rotate(); err != nil {
+			return err
+		}
+		tail = &q.segs[len(q.segs)-1]
+	}
+	if _, err := q.tail.Write(rec); err != nil {
+		// Cut off whatever part of the record made it to disk.
+		q.tail.Truncate(tail.size) //nolint:errcheck // best effort; scan repairs it on open
+		return fmt.Errorf("wal: write: %w", err)
+	}
+	tail.count++
+	tail.size += int64(len(rec))
+	q.next++
+	q.unsynced = true
+	q.syncTail(false)
+	q.enforceMaxBytes()
+
+	signal(q.notify)
+	return nil
+}
+
+// rotate finishes the tail segment and starts a new one.
+func (q *walQueue) rotate() error {
+	q.syncTail(true)
+	if err := q.tail.Close(); err != nil {
+		return fmt.Errorf("wal: close segment: %w", err)
+	}
+	q.tail = nil
+	return q.newSegment(q.next)
+}
+
+// newSegment creates an empty segment whose first record is first and makes
+// it the tail.
+func (q *walQueue) newSegment(first uint64) error {
+	f, err := os.OpenFile(q.segPath(first), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("wal: create segment: %w", err)
+	}
+	q.tail = f
+	q.segs = append(q.segs, walSegment{first: first})
+	return nil
+}
+
+// syncTail fsyncs the tail segment according to the fsync policy. force
+// syncs regardless of the interval (segment rotation, close, syncLoop).
+func (q *walQueue) syncTail(force bool) {
+	switch q.cfg.Fsync {
+	case "never":
+		return
+	case "interval":
+		if !force && q.now().Sub(q.lastSync) < q.cfg.FsyncInterval {
+			return
+		}
+	}
+	if err := q.tail.Sync(); err != nil {
+		slog.Warn("shipper: wal fsync failed", "err", err)
+	}
+	q.lastSync = q.now()
+	q.unsynced = false
+}
+
+// enforceMaxBytes deletes the oldest segments while the WAL exceeds
+// MaxBytes. Unsent snapshots in them are counted as dropped. The tail
+// segment is never deleted.
+func (q *walQueue) enforceMaxBytes() {
+	var total int64
+	for _, s := range q.segs {
+		total += s.size
+	}
+	for total > q.cfg.MaxBytes && len(q.segs) > 1 {
+		old := q.segs[0]
+		if old.end() > q.cursor {
+			lost := old.end() - max(old.first, q.cursor)
+			q.drops += lost
+			slog.Warn("shipper: wal full, evicted oldest segment",
+				"dropped", lost, "max_bytes", q.cfg.MaxBytes)
+			q.advanceCursor(old.end())
+		}
+		q.removeSegment(0)
+		total -= old.size
+	}
+}
+
+// removeSegment deletes segment i from disk and from q.segs.
+func (q *walQueue) removeSegment(i int) {
+	first := q.segs[i].first
+	if q.rf != nil && q.rd.seg == first {
+		q.rf.Close()
+		q.rf = nil
+	}
+	if err := os.Remove(q.segPath(first)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("shipper: could not remove wal segment", "segment", q.segPath(first), "err", err)
+	}
+	q.segs = append(q.segs[:i], q.segs[i+1:]...)
+}
+
+// dropAckedSegments deletes every segment, except the tail, whose records
+// are all acknowledged.
+func (q *walQueue) dropAckedSegments() {
+	for len(q.segs) > 1 && q.segs[0].end() <= q.cursor {
+		q.removeSegment(0)
+	}
+}
+
+// advanceCursor moves the cursor to next, discarding pending entries below
+// it and moving the read position forward if it fell behind.
+func (q *walQueue) advanceCursor(next uint64) {
+	if next <= q.cursor {
+		return
+	}
+	q.cursor = next
+	i := 0
+	for i < len(q.pending) && q.pending[i].idx < next {
+		i++
+	}
+	q.pending = q.pending[i:]
+	if q.rd.idx < next {
+		if err := q.seek(next); err != nil {
+			slog.Warn("shipper: wal seek failed", "err", err)
+		}
+	}
+}
+
+func (q *walQueue) peek(n int) []entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+
+	// Expire from the head; later entries are newer.
+	var expired uint64
+	for len(q.pending) > 0 && q.expired(q.pending[0].snap) {
+		expired++
+		q.ack1(q.pending[0].idx + 1)
+	}
+	for len(q.pending) < n {
+		e, ok := q.readNext()
+		if !ok {
+			break
+		}
+		if len(q.pending) == 0 && q.expired(e.snap) {
+			expired++
+			q.ack1(e.idx + 1)
+			continue
+		}
+		q.pending = append(q.pending, e)
+	}
+	if expired > 0 {
+		q.drops += expired
+		slog.Warn("shipper: dropped snapshots older than wal max_age",
+			"dropped", expired, "max_age", q.cfg.MaxAge)
+	}
+
+	n = min(n, len(q.pending))
+	out := make([]entry, n)
+	copy(out, q.pending[:n])
+	return out
+}
+
+// expired reports whether snap is older than MaxAge.
+func (q *walQueue) expired(snap *pb.PipelineSnapshot) bool {
+	if q.cfg.MaxAge <= 0 || snap.TimestampUnix == 0 {
+		return false
+	}
+	return q.now().Sub(time.Unix(snap.TimestampUnix, 0)) > q.cfg.MaxAge
+}
+
+// readNext reads the record at the read position and advances it. Damaged
+// records end their segment; the records lost that way count as dropped.
+func (q *walQueue) readNext() (entry, bool) {
+	for q.rd.idx < q.next {
+		seg, ok := q.segment(q.rd.seg)
+		if !ok || q.rd.idx >= seg.end() {
+			if !q.nextSegment() {
+				return entry{}, false
+			}
+			continue
+		}
+		if q.rf == nil {
+			f, err := os.Open(q.segPath(seg.first))
+			if err != nil {
+				slog.Warn("shipper: cannot open wal segment", "segment", q.segPath(seg.first), "err", err)
+				q.skipSegment(seg)
+				continue
+			}
+			q.rf = f
+		}
+
+		n, payload, err := readRecord(q.rf, q.rd.off)
+		var snap pb.PipelineSnapshot
+		if err == nil {
+			err = proto.Unmarshal(payload, &snap)
+		}
+		if err != nil {
+			slog.Warn("shipper: damaged wal record, skipping rest of segment",
+				"segment", q.segPath(seg.first), "offset", q.rd.off, "err", err)
+			q.skipSegment(seg)
+			continue
+		}
+
+		e := entry{idx: q.rd.idx, snap: &snap}
+		q.rd.off += n
+		q.rd.idx++
+		return e, true
+	}
+	return entry{}, false
+}
+
+// skipSegment gives up on the unread records of seg and moves on.
+func (q *walQueue) skipSegment(seg walSegment) {
+	if lost := seg.end() - q.rd.idx; lost > 0 {
+		q.drops += lost
+	}
+	if len(q.pending) == 0 {
+		q.ack1(seg.end())
+		return
+	}
+	q.nextSegment()
+}
+
+// nextSegment moves the read position to the start of the segment after the
+// current one. It returns false if there is none.
+func (q *walQueue) nextSegment() bool {
+	if q.rf != nil {
+		q.rf.Close()
+		q.rf = nil
+	}
+	for _, s := range q.segs {
+		if s.first > q.rd.seg {
+			q.rd = walPos{seg: s.first, idx: s.first}
+			return true
+		}
+	}
+	return false
+}
+
+// segment returns the segment whose first record is first.
+func (q *walQueue) segment(first uint64) (walSegment, bool) {
+	for _, s := range q.segs {
+		if s.first == first {
+			return s, true
+		}
+	}
+	return walSegment{}, false
+}
+
+// seek sets the read position to record idx, dropping pending entries.
+func (q *walQueue) seek(idx uint64) error {
+	if q.rf != nil {
+		q.rf.Close()
+		q.rf = nil
+	}
+	q.pending = nil
+	for _, s := range q.segs {
+		if s.end() <= idx && s.first != q.segs[len(q.segs)-1].first {
+			continue
+		}
+		q.rd = walPos{seg: s.first, idx: s.first}
+		if idx <= s.first {
+			return nil
+		}
+		f, err := os.Open(q.segPath(s.first))
+		if err != nil {
+			return fmt.Errorf("wal: open segment: %w", err)
+		}
+		defer f.Close()
+		for q.rd.idx < idx && q.rd.idx < s.end() {
+			n, _, err := readRecord(f, q.rd.off)
+			if err != nil {
+				return fmt.Errorf("wal: seek: %w", err)
+			}
+			q.rd.off += n
+			q.rd.idx++
+		}
+		return nil
+	}
+	q.rd = walPos{seg: q.next, idx: q.next}
+	return nil
+}
+
+func (q *walQueue) ack(next uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.ack1(next)
+}
+
+// ack1 is ack with q.mu held: it advances and persists the cursor and
+// deletes segments that are fully acknowledged.
+func (q *walQueue) ack1(next uint64) {
+	if next <= q.cursor {
+		return
+	}
+	q.advanceCursor(min(next, q.next))
+	q.dropAckedSegments()
+	if err := q.writeCursor(); err != nil {
+		slog.Warn("shipper: could not persist wal cursor", "err", err)
+	}
+}
+
+// writeCursor atomically replaces the cursor file.
+func (q *walQueue) writeCursor() error {
+	tmp := filepath.Join(q.dir, walCursorFile+".tmp")
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(strconv.FormatUint(q.cursor, 10)); err != nil {
+		f.Close()
+		return err
+	}
+	if q.cfg.Fsync == "always" {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(q.dir, walCursorFile))
+}
+
+// readCursor returns the persisted cursor, or 0 if there is none.
+func (q *walQueue) readCursor() uint64 {
+	b, err := os.ReadFile(filepath.Join(q.dir, walCursorFile))
+	if err != nil {
+		return 0
+	}
+	c, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		slog.Warn("shipper: ignoring unreadable wal cursor", "err", err)
+		return 0
+	}
+	return c
+}
+
+func (q *walQueue) ready() <-chan struct{} { return q.notify }
+
+func (q *walQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.depthLocked()
+}
+
+func (q *walQueue) depthLocked() int {
+	var n uint64
+	for _, s := range q.segs {
+		if s.end() > q.cursor {
+			n += s.end() - max(s.first, q.cursor)
+		}
+	}
+	return int(n)
+}
+
+func (q *walQueue) dropped() uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.drops
+}
+
+func (q *walQueue) close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.stop)
+	if q.tail != nil {
+		q.syncTail(true)
+	}
+	return q.closeFiles()
+}
+
+func (q *walQueue) closeFiles() error {
+	var err error
+	if q.rf != nil {
+		q.rf.Close()
+		q.rf = nil
+	}
+	if q.tail != nil {
+		err = q.tail.Close()
+		q.tail = nil
+	}
+	return err
+}
+
+func (q *walQueue) segPath(first uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", first, walSegmentExt))
+}
@@ -0,0 +1,248 @@
+package shipper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/obsidianstack/obsidianstack/agent/internal/config"
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+)
+
+func walCfg(dir string) config.WALConfig {
+	return config.WALConfig{
+		Dir:      dir,
+		MaxBytes: config.DefaultWALMaxBytes,
+		Fsync:    "always",
+	}
+}
+
+func openTestWAL(t *testing.T, cfg config.WALConfig) *walQueue {
+	t.Helper()
+	q, err := openWAL(cfg)
+	if err != nil {
+		t.Fatalf("openWAL: %v", err)
+	}
+	t.Cleanup(func() { q.close() })
+	return q
+}
+
+func walSnap(id string) *pb.PipelineSnapshot {
+	return &pb.PipelineSnapshot{SourceId: id, TimestampUnix: time.Now().Unix()}
+}
+
+// sourceIDs returns the source IDs of up to n entries at the head of q.
+func sourceIDs(q queue, n int) []string {
+	var out []string
+	for _, e := range q.peek(n) {
+		out = append(out, e.snap.SourceId)
+	}
+	return out
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	names, err := filepath.Glob(filepath.Join(dir, "*"+walSegmentExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestWAL_ReplaysUnackedAfterReopen(t *testing.T) {
+	cfg := walCfg(t.TempDir())
+	q := openTestWAL(t, cfg)
+	for _, id := range []string{"a", "b", "c"} {
+		if err := q.push(walSnap(id)); err != nil {
+			t.Fatalf("push: %v", err)
+		}
+	}
+	head := q.peek(1)
+	q.ack(head[0].idx + 1)
+	q.close()
+
+	q = openTestWAL(t, cfg)
+	if d := q.depth(); d != 2 {
+		t.Errorf("depth after reopen = %d, want 2", d)
+	}
+	if got := sourceIDs(q, 10); len(got) != 2 || got[0] != "b" || got[1] != "c" {
+		t.Errorf("replayed %v, want [b c]", got)
+	}
+
+	// New records continue after the replayed ones.
+	q.push(walSnap("d"))
+	if got := sourceIDs(q, 10); len(got) != 3 || got[2] != "d" {
+		t.Errorf("queue after push = %v, want [b c d]", got)
+	}
+}
+
+func TestWAL_AckDeletesConsumedSegments(t *testing.T) {
+	cfg := walCfg(t.TempDir())
+	cfg.MaxBytes = 8 * 16 // 16-byte segments: one snapshot each
+	q := openTestWAL(t, cfg)
+	for _, id := range []string{"a", "b", "c"} {
+		q.push(walSnap(id))
+	}
+	if n := len(segmentFiles(t, cfg.Dir)); n != 3 {
+		t.Fatalf("segment files = %d, want 3", n)
+	}
+
+	all := q.peek(3)
+	q.ack(all[2].idx + 1)
+	if n := len(segmentFiles(t, cfg.Dir)); n != 1 {
+		t.Errorf("segment files after ack = %d, want 1 (the tail)", n)
+	}
+	if d := q.depth(); d != 0 {
+		t.Errorf("depth after ack = %d, want 0", d)
+	}
+}
+
+func TestWAL_MaxBytesEvictsOldest(t *testing.T) {
+	cfg := walCfg(t.TempDir())
+	cfg.MaxBytes = 8 * 16
+	q := openTestWAL(t, cfg)
+	for i := 0; i < 20; i++ {
+		q.push(walSnap(string(rune('a' + i))))
+	}
+
+	if q.dropped() == 0 {
+		t.Fatal("no snapshots dropped after exceeding max_bytes")
+	}
+	if got := uint64(q.depth()) + q.dropped(); got != 20 {
+		t.Errorf("depth + dropped = %d, want 20", got)
+	}
+	var size int64
+	for _, name := range segmentFiles(t, cfg.Dir) {
+		fi, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size += fi.Size()
+	}
+	if size > cfg.MaxBytes {
+		t.Errorf("wal size = %d, want <= %d", size, cfg.MaxBytes)
+	}
+	// The newest snapshot always survives.
+	ids := sourceIDs(q, 20)
+	if len(ids) == 0 || ids[len(ids)-1] != "t" {
+		t.Errorf("queue = %v, want it to end with the newest snapshot t", ids)
+	}
+}
+
+func TestWAL_MaxAgeDiscardsExpired(t *testing.T) {
+	cfg := walCfg(t.TempDir())
+	cfg.MaxAge = time.Hour
+	q := openTestWAL(t, cfg)
+
+	old := walSnap("old")
+	old.TimestampUnix = time.Now().Add(-2 * time.Hour).Unix()
+	q.push(old)
+	q.push(walSnap("fresh"))
+
+	if got := sourceIDs(q, 10); len(got) != 1 || got[0] != "fresh" {
+		t.Errorf("queue = %v, want [fresh]", got)
+	}
+	if d := q.dropped(); d != 1 {
+		t.Errorf("dropped = %d, want 1", d)
+	}
+
+	// Entries already peeked expire too once they age past MaxAge.
+	q.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if got := sourceIDs(q, 10); len(got) != 0 {
+		t.Errorf("queue after aging = %v, want empty", got)
+	}
+}
+
+func TestWAL_TruncatesTornTail(t *testing.T) {
+	cfg := walCfg(t.TempDir())
+	q := openTestWAL(t, cfg)
+	q.push(walSnap("a"))
+	q.push(walSnap("b"))
+	q.close()
+
+	// Simulate a crash in the middle of writing a third record.
+	segs := segmentFiles(t, cfg.Dir)
+	f, err := os.OpenFile(segs[len(segs)-1], os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0x40, 0, 0, 0, 0xde, 0xad})
+	f.Close()
+
+	q = openTestWAL(t, cfg)
+	if d := q.depth(); d != 2 {
+		t.Fatalf("depth after torn write = %d, want 2", d)
+	}
+	q.push(walSnap("c"))
+	q.close()
+
+	q = openTestWAL(t, cfg)
+	if got := sourceIDs(q, 10); len(got) != 3 || got[2] != "c" {
+		t.Errorf("queue = %v, want [a b c]", got)
+	}
+}
+
+func TestShipper_WALSurvivesRestart(t *testing.T) {
+	cfg := agentCfg()
+	cfg.WAL = walCfg(t.TempDir())
+
+	// Server unreachable: snapshots stay on disk across a restart.
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s.Ship(makeComputeResult("first"), nil)
+	s.Ship(makeComputeResult("second"), nil)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	srv := &mockServer{}
+	s = newTestShipper(t, cfg)
+	s.dialFn = startTestServer(t, srv)
+	if d := s.Depth(); d != 2 {
+		t.Errorf("Depth() after restart = %d, want 2", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go s.Run(ctx)
+
+	if !waitFor(t, func() bool { return len(srv.snapshots()) == 2 }) {
+		t.Fatalf("server received %d snapshots, want 2", len(srv.snapshots()))
+	}
+	if got := srv.snapshots(); got[0].SourceId != "first" || got[1].SourceId != "second" {
+		t.Errorf("delivered %s, %s; want first, second", got[0].SourceId, got[1].SourceId)
+	}
+}
+
+// TestWAL_IntervalSyncsIdleQueue checks that a write left unsynced because
+// it came within fsync_interval of the previous sync is synced by the timer
+// once pushes stop.
+func TestWAL_IntervalSyncsIdleQueue(t *testing.T) {
+	cfg := walCfg(t.TempDir())
+	cfg.Fsync, cfg.FsyncInterval = "interval", 100*time.Millisecond
+	q := openTestWAL(t, cfg)
+	now := time.Now()
+	q.now = func() time.Time { return now }
+	for _, id := range []string{"a", "b"} { // a syncs, b is within the interval
+		if err := q.push(walSnap(id)); err != nil {
+			t.Fatalf("push: %v", err)
+		}
+	}
+	unsynced := func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return q.unsynced
+	}
+	if !unsynced() {
+		t.Fatal("second push within the interval was synced")
+	}
+	for deadline := time.Now().Add(2 * time.Second); unsynced(); time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("idle queue not synced by the interval timer")
+		}
+	}
+}
@@ -39,16 +39,18 @@ type PipelineSnapshot struct {
 	LatencyP95Ms     float64                `protobuf:"fixed64,12,opt,name=latency_p95_ms,json=latencyP95Ms,proto3" json:"latency_p95_ms,omitempty"`             // P95 export latency in milliseconds
 	LatencyP99Ms     float64                `protobuf:"fixed64,13,opt,name=latency_p99_ms,json=latencyP99Ms,proto3" json:"latency_p99_ms,omitempty"`             // P99 export latency in milliseconds
 	StrengthScore    float64                `protobuf:"fixed64,14,opt,name=strength_score,json=strengthScore,proto3" json:"strength_score,omitempty"`            // composite health score 0-100
-	UptimePct        float64                `protobuf:"fixed64,15,opt,name=uptime_pct,json=uptimePct,proto3" json:"uptime_pct,omitempty"`                        // uptime percentage since last restart (0-100)
+	UptimePct        float64                `protobuf:"fixed64,15,opt,name=uptime_pct,json=uptimePct,proto3" json:"uptime_pct,omitempty"`                        // % of the last 20 scrapes that succeeded (0-100)
 	Signals          []*SignalStats         `protobuf:"bytes,16,rep,name=signals,proto3" json:"signals,omitempty"`                                               // per-signal-type breakdown
 	Certs            []*CertStatus          `protobuf:"bytes,17,rep,name=certs,proto3" json:"certs,omitempty"`                                                   // TLS cert status per endpoint
 	ErrorMessage     string                 `protobuf:"bytes,18,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`                 // non-empty if the scrape itself failed
 	// extra holds component-specific numeric metrics that don't fit the generic
 	// fields above. For otelcol: queue_size, queue_capacity, exporter_sent_*,
 	// receiver_refused_*, processor_dropped_*.
-	Extra         map[string]float64 `protobuf:"bytes,19,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	Extra             map[string]float64 `protobuf:"bytes,19,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	ThresholdHealthy  float64            `protobuf:"fixed64,20,opt,name=threshold_healthy,json=thresholdHealthy,proto3" json:"threshold_healthy,omitempty"`    // score at or above which the agent reported "healthy"
+	ThresholdDegraded float64            `protobuf:"fixed64,21,opt,name=threshold_degraded,json=thresholdDegraded,proto3" json:"threshold_degraded,omitempty"` // score at or above which the agent reported "degraded"
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *PipelineSnapshot) Reset() {
@@ -214,6 +216,20 @@ func (x *PipelineSnapshot) GetExtra() map[string]float64 {
 	return nil
 }
 
+func (x *PipelineSnapshot) GetThresholdHealthy() float64 {
+	if x != nil {
+		return x.ThresholdHealthy
+	}
+	return 0
+}
+
+func (x *PipelineSnapshot) GetThresholdDegraded() float64 {
+	if x != nil {
+		return x.ThresholdDegraded
+	}
+	return 0
+}
+
 // SignalStats holds per-signal-type (metrics/logs/traces) throughput and drop data.
 type SignalStats struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -421,11 +437,103 @@ func (x *SendResponse) GetMessage() string {
 	return ""
 }
 
+// SnapshotBatch is a group of snapshots sent in one SendSnapshots call,
+// oldest first.
+type SnapshotBatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Snapshots     []*PipelineSnapshot    `protobuf:"bytes,1,rep,name=snapshots,proto3" json:"snapshots,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotBatch) Reset() {
+	*x = SnapshotBatch{}
+	mi := &file_obsidian_v1_snapshot_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotBatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotBatch) ProtoMessage() {}
+
+func (x *SnapshotBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_obsidian_v1_snapshot_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotBatch.ProtoReflect.Descriptor instead.
+func (*SnapshotBatch) Descriptor() ([]byte, []int) {
+	return file_obsidian_v1_snapshot_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SnapshotBatch) GetSnapshots() []*PipelineSnapshot {
+	if x != nil {
+		return x.Snapshots
+	}
+	return nil
+}
+
+// BatchResponse acknowledges a SnapshotBatch. results[i] is the outcome for
+// snapshots[i]; a missing result means the snapshot was not processed.
+type BatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*SendResponse        `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchResponse) Reset() {
+	*x = BatchResponse{}
+	mi := &file_obsidian_v1_snapshot_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchResponse) ProtoMessage() {}
+
+func (x *BatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_obsidian_v1_snapshot_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchResponse.ProtoReflect.Descriptor instead.
+func (*BatchResponse) Descriptor() ([]byte, []int) {
+	return file_obsidian_v1_snapshot_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchResponse) GetResults() []*SendResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
 var File_obsidian_v1_snapshot_proto protoreflect.FileDescriptor
 
 const file_obsidian_v1_snapshot_proto_rawDesc = "" +
 	"\n" +
-	"\x1aobsidian/v1/snapshot.proto\x12\vobsidian.v1\"\x8a\x06\n" +
+	"\x1aobsidian/v1/snapshot.proto\x12\vobsidian.v1\"\xe6\x06\n" +
 	"\x10PipelineSnapshot\x12\x1b\n" +
 	"\tsource_id\x18\x01 \x01(\tR\bsourceId\x12\x1f\n" +
 	"\vsource_type\x18\x02 \x01(\tR\n" +
@@ -448,7 +556,9 @@ const file_obsidian_v1_snapshot_proto_rawDesc = "" +
 	"\asignals\x18\x10 \x03(\v2\x18.obsidian.v1.SignalStatsR\asignals\x12-\n" +
 	"\x05certs\x18\x11 \x03(\v2\x17.obsidian.v1.CertStatusR\x05certs\x12#\n" +
 	"\rerror_message\x18\x12 \x01(\tR\ferrorMessage\x12>\n" +
-	"\x05extra\x18\x13 \x03(\v2(.obsidian.v1.PipelineSnapshot.ExtraEntryR\x05extra\x1a8\n" +
+	"\x05extra\x18\x13 \x03(\v2(.obsidian.v1.PipelineSnapshot.ExtraEntryR\x05extra\x12+\n" +
+	"\x11threshold_healthy\x18\x14 \x01(\x01R\x10thresholdHealthy\x12-\n" +
+	"\x12threshold_degraded\x18\x15 \x01(\x01R\x11thresholdDegraded\x1a8\n" +
 	"\n" +
 	"ExtraEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
@@ -470,9 +580,14 @@ const file_obsidian_v1_snapshot_proto_rawDesc = "" +
 	"\tnot_after\x18\x06 \x01(\tR\bnotAfter\"8\n" +
 	"\fSendResponse\x12\x0e\n" +
 	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage2[\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"L\n" +
+	"\rSnapshotBatch\x12;\n" +
+	"\tsnapshots\x18\x01 \x03(\v2\x1d.obsidian.v1.PipelineSnapshotR\tsnapshots\"D\n" +
+	"\rBatchResponse\x123\n" +
+	"\aresults\x18\x01 \x03(\v2\x19.obsidian.v1.SendResponseR\aresults2\xa4\x01\n" +
 	"\x0fSnapshotService\x12H\n" +
-	"\fSendSnapshot\x12\x1d.obsidian.v1.PipelineSnapshot\x1a\x19.obsidian.v1.SendResponseBCZAgithub.com/obsidianstack/obsidianstack/gen/obsidian/v1;obsidianv1b\x06proto3"
+	"\fSendSnapshot\x12\x1d.obsidian.v1.PipelineSnapshot\x1a\x19.obsidian.v1.SendResponse\x12G\n" +
+	"\rSendSnapshots\x12\x1a.obsidian.v1.SnapshotBatch\x1a\x1a.obsidian.v1.BatchResponseBCZAgithub.com/obsidianstack/obsidianstack/gen/obsidian/v1;obsidianv1b\x06proto3"
 
 var (
 	file_obsidian_v1_snapshot_proto_rawDescOnce sync.Once
@@ -486,25 +601,31 @@ func file_obsidian_v1_snapshot_proto_rawDescGZIP() []byte {
 	return file_obsidian_v1_snapshot_proto_rawDescData
 }
 
-var file_obsidian_v1_snapshot_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_obsidian_v1_snapshot_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_obsidian_v1_snapshot_proto_goTypes = []any{
 	(*PipelineSnapshot)(nil), // 0: obsidian.v1.PipelineSnapshot
 	(*SignalStats)(nil),      // 1: obsidian.v1.SignalStats
 	(*CertStatus)(nil),       // 2: obsidian.v1.CertStatus
 	(*SendResponse)(nil),     // 3: obsidian.v1.SendResponse
-	nil,                      // 4: obsidian.v1.PipelineSnapshot.ExtraEntry
+	(*SnapshotBatch)(nil),    // 4: obsidian.v1.SnapshotBatch
+	(*BatchResponse)(nil),    // 5: obsidian.v1.BatchResponse
+	nil,                      // 6: obsidian.v1.PipelineSnapshot.ExtraEntry
 }
 var file_obsidian_v1_snapshot_proto_depIdxs = []int32{
 	1, // 0: obsidian.v1.PipelineSnapshot.signals:type_name -> obsidian.v1.SignalStats
 	2, // 1: obsidian.v1.PipelineSnapshot.certs:type_name -> obsidian.v1.CertStatus
-	4, // 2: obsidian.v1.PipelineSnapshot.extra:type_name -> obsidian.v1.PipelineSnapshot.ExtraEntry
-	0, // 3: obsidian.v1.SnapshotService.SendSnapshot:input_type -> obsidian.v1.PipelineSnapshot
-	3, // 4: obsidian.v1.SnapshotService.SendSnapshot:output_type -> obsidian.v1.SendResponse
-	4, // [4:5] is the sub-list for method output_type
-	3, // [3:4] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	6, // 2: obsidian.v1.PipelineSnapshot.extra:type_name -> obsidian.v1.PipelineSnapshot.ExtraEntry
+	0, // 3: obsidian.v1.SnapshotBatch.snapshots:type_name -> obsidian.v1.PipelineSnapshot
+	3, // 4: obsidian.v1.BatchResponse.results:type_name -> obsidian.v1.SendResponse
+	0, // 5: obsidian.v1.SnapshotService.SendSnapshot:input_type -> obsidian.v1.PipelineSnapshot
+	4, // 6: obsidian.v1.SnapshotService.SendSnapshots:input_type -> obsidian.v1.SnapshotBatch
+	3, // 7: obsidian.v1.SnapshotService.SendSnapshot:output_type -> obsidian.v1.SendResponse
+	5, // 8: obsidian.v1.SnapshotService.SendSnapshots:output_type -> obsidian.v1.BatchResponse
+	7, // [7:9] is the sub-list for method output_type
+	5, // [5:7] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_obsidian_v1_snapshot_proto_init() }
@@ -518,7 +639,7 @@ func file_obsidian_v1_snapshot_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_obsidian_v1_snapshot_proto_rawDesc), len(file_obsidian_v1_snapshot_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   5,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,7 +19,8 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	SnapshotService_SendSnapshot_FullMethodName = "/obsidian.v1.SnapshotService/SendSnapshot"
+	SnapshotService_SendSnapshot_FullMethodName  = "/obsidian.v1.SnapshotService/SendSnapshot"
+	SnapshotService_SendSnapshots_FullMethodName = "/obsidian.v1.SnapshotService/SendSnapshots"
 )
 
 // SnapshotServiceClient is the client API for SnapshotService service.
@@ -31,6 +32,11 @@ type SnapshotServiceClient interface {
 	// SendSnapshot delivers a single pipeline snapshot from agent to server.
 	// Using unary RPC so each snapshot can be individually buffered and retried.
 	SendSnapshot(ctx context.Context, in *PipelineSnapshot, opts ...grpc.CallOption) (*SendResponse, error)
+	// SendSnapshots delivers a batch of snapshots in one call. Each snapshot is
+	// acknowledged individually, so one invalid snapshot does not fail the
+	// rest of the batch. Servers that predate this RPC return UNIMPLEMENTED; agents
+	// then fall back to SendSnapshot.
+	SendSnapshots(ctx context.Context, in *SnapshotBatch, opts ...grpc.CallOption) (*BatchResponse, error)
 }
 
 type snapshotServiceClient struct {
@@ -51,6 +57,16 @@ func (c *snapshotServiceClient) SendSnapshot(ctx context.Context, in *PipelineSn
 	return out, nil
 }
 
+func (c *snapshotServiceClient) SendSnapshots(ctx context.Context, in *SnapshotBatch, opts ...grpc.CallOption) (*BatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, SnapshotService_SendSnapshots_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SnapshotServiceServer is the server API for SnapshotService service.
 // All implementations must embed UnimplementedSnapshotServiceServer
 // for forward compatibility.
@@ -60,6 +76,11 @@ type SnapshotServiceServer interface {
 	// SendSnapshot delivers a single pipeline snapshot from agent to server.
 	// Using unary RPC so each snapshot can be individually buffered and retried.
 	SendSnapshot(context.Context, *PipelineSnapshot) (*SendResponse, error)
+	// SendSnapshots delivers a batch of snapshots in one call. Each snapshot is
+	// acknowledged individually, so one invalid snapshot does not fail the
+	// rest of the batch. Servers that predate this RPC return UNIMPLEMENTED; agents
+	// then fall back to SendSnapshot.
+	SendSnapshots(context.Context, *SnapshotBatch) (*BatchResponse, error)
 	mustEmbedUnimplementedSnapshotServiceServer()
 }
 
@@ -73,6 +94,9 @@ type UnimplementedSnapshotServiceServer struct{}
 func (UnimplementedSnapshotServiceServer) SendSnapshot(context.Context, *PipelineSnapshot) (*SendResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method SendSnapshot not implemented")
 }
+func (UnimplementedSnapshotServiceServer) SendSnapshots(context.Context, *SnapshotBatch) (*BatchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SendSnapshots not implemented")
+}
 func (UnimplementedSnapshotServiceServer) mustEmbedUnimplementedSnapshotServiceServer() {}
 func (UnimplementedSnapshotServiceServer) testEmbeddedByValue()                         {}
 
@@ -112,6 +136,24 @@ func _SnapshotService_SendSnapshot_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SnapshotService_SendSnapshots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotBatch)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SnapshotServiceServer).SendSnapshots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SnapshotService_SendSnapshots_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SnapshotServiceServer).SendSnapshots(ctx, req.(*SnapshotBatch))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // SnapshotService_ServiceDesc is the grpc.ServiceDesc for SnapshotService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -123,6 +165,10 @@ var SnapshotService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SendSnapshot",
 			Handler:    _SnapshotService_SendSnapshot_Handler,
 		},
+		{
+			MethodName: "SendSnapshots",
+			Handler:    _SnapshotService_SendSnapshots_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "obsidian/v1/snapshot.proto",
@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func get(t *testing.T, h http.Handler, path string) (int, string) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	body, _ := io.ReadAll(rr.Body)
+	return rr.Code, string(body)
+}
+
+func TestHandler_Metrics(t *testing.T) {
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_events_total", Help: "Test."})
+	c.Add(3)
+	code, body := get(t, Handler(NewRegistry(c)), "/metrics")
+	if code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", code)
+	}
+	for _, want := range []string{"test_events_total 3", "go_goroutines"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q", want)
+		}
+	}
+}
+
+func TestHandler_Healthz(t *testing.T) {
+	code, _ := get(t, Handler(NewRegistry()), "/healthz")
+	if code != http.StatusOK {
+		t.Errorf("status: got %d, want 200", code)
+	}
+}
+
+func TestHandler_Readyz(t *testing.T) {
+	var dbErr error
+	h := Handler(NewRegistry(),
+		Check{Name: "grpc", Fn: func() error { return nil }},
+		Check{Name: "db", Fn: func() error { return dbErr }},
+	)
+	if code, _ := get(t, h, "/readyz"); code != http.StatusOK {
+		t.Errorf("ready: got status %d, want 200", code)
+	}
+
+	dbErr = errors.New("locked")
+	code, body := get(t, h, "/readyz")
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("not ready: got status %d, want 503", code)
+	}
+	if !strings.Contains(body, "db: locked") || strings.Contains(body, "grpc") {
+		t.Errorf("body: got %q, want only the failing check", body)
+	}
+}
@@ -0,0 +1,12 @@
+// Package admin serves the self-observability endpoints shared by
+// obsidianstack-agent and obsidianstack-server on their admin port:
+//
+//	GET /metrics  — internal Prometheus metrics plus Go runtime and process
+//	                collectors
+//	GET /healthz  — 200 while the process is running
+//	GET /readyz   — 200 when every readiness Check passes; 503 listing the
+//	                failing checks otherwise
+//
+// NewRegistry(collectors...) builds the registry; Handler(reg, checks...)
+// the mux; Serve(ctx, port, handler) runs it until ctx is cancelled.
+package admin
@@ -13,12 +13,16 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/pkg/admin"
 	"github.com/obsidianstack/obsidianstack/server/internal/alerts"
 	"github.com/obsidianstack/obsidianstack/server/internal/api"
 	"github.com/obsidianstack/obsidianstack/server/internal/auth"
 	"github.com/obsidianstack/obsidianstack/server/internal/config"
+	"github.com/obsidianstack/obsidianstack/server/internal/history"
+	"github.com/obsidianstack/obsidianstack/server/internal/metrics"
 	"github.com/obsidianstack/obsidianstack/server/internal/receiver"
 	"github.com/obsidianstack/obsidianstack/server/internal/store"
 	"github.com/obsidianstack/obsidianstack/server/internal/ws"
@@ -43,6 +47,7 @@ func main() {
 	slog.Info("config loaded",
 		"grpc_port", cfg.Server.GRPCPort,
 		"http_port", cfg.Server.HTTPPort,
+		"admin_port", cfg.Server.AdminPort,
 		"auth_mode", cfg.Server.Auth.Mode,
 		"snapshot_ttl", cfg.Server.Snapshot.TTL,
 	)
@@ -54,17 +59,72 @@ func main() {
 	st := store.New(cfg.Server.Snapshot.TTL)
 	go st.Run(ctx)
 
+	// Optional history store — every received snapshot is also written to
+	// SQLite and rows older than the retention are swept in the background.
+	// Alert state and resolved alerts are kept in the same database.
+	var hist history.Store
+	var alertStore alerts.Store
+	var ready []admin.Check
+	if cfg.Server.Storage.Enabled() {
+		db, err := history.OpenSQLite(cfg.Server.Storage.Path)
+		if err != nil {
+			slog.Error("failed to open history store", "err", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+		hist = db
+		alertStore = db
+		ready = append(ready, admin.Check{Name: "history", Fn: func() error {
+			ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			defer cancel()
+			return db.Ping(ctx)
+		}})
+		go history.RunRetention(ctx, hist, cfg.Server.Storage.Retention)
+		if r := cfg.Server.Storage.AlertRetention; r > 0 {
+			go history.RunAlertRetention(ctx, db, r)
+		}
+		slog.Info("history store opened",
+			"backend", cfg.Server.Storage.Backend,
+			"path", cfg.Server.Storage.Path,
+			"retention", cfg.Server.Storage.Retention,
+			"alert_retention", cfg.Server.Storage.AlertRetention,
+		)
+	}
+
 	// Alerts engine — evaluates rules on every incoming snapshot.
 	alertEngine := alerts.New(cfg.Server.Alerts)
+	if alertStore != nil {
+		if err := alertEngine.SetStore(ctx, alertStore); err != nil {
+			slog.Error("failed to restore alert state", "err", err)
+			os.Exit(1)
+		}
+	}
 
-	// gRPC server with optional API key authentication interceptor.
-	interceptor := auth.APIKeyInterceptor(
+	// gRPC server with optional API key or client-certificate authorisation,
+	// served over TLS when a server certificate is configured.
+	interceptors := []grpc.UnaryServerInterceptor{auth.APIKeyInterceptor(
 		cfg.Server.Auth.Mode,
 		cfg.Server.Auth.EffectiveHeader(),
 		cfg.Server.Auth.Key(),
-	)
-	grpcSrv := grpc.NewServer(grpc.UnaryInterceptor(interceptor))
-	pb.RegisterSnapshotServiceServer(grpcSrv, receiver.New(st, alertEngine))
+	)}
+	if cfg.Server.Auth.Mode == "mtls" {
+		interceptors = append(interceptors, auth.MTLSInterceptor(cfg.Server.Auth.Identities))
+	}
+	grpcOpts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(interceptors...)}
+	if cfg.Server.TLS.Enabled() {
+		tlsCfg, err := auth.ServerTLS(cfg.Server.TLS, auth.GRPCClientAuth(cfg.Server.Auth.Mode))
+		if err != nil {
+			slog.Error("failed to configure gRPC TLS", "err", err)
+			os.Exit(1)
+		}
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+	grpcSrv := grpc.NewServer(grpcOpts...)
+	rcv := receiver.New(st, alertEngine)
+	if hist != nil {
+		rcv.SetHistory(hist)
+	}
+	pb.RegisterSnapshotServiceServer(grpcSrv, rcv)
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.GRPCPort))
 	if err != nil {
@@ -74,7 +134,7 @@ func main() {
 	}
 
 	go func() {
-		slog.Info("gRPC receiver listening", "port", cfg.Server.GRPCPort)
+		slog.Info("gRPC receiver listening", "port", cfg.Server.GRPCPort, "tls", cfg.Server.TLS.Enabled())
 		if err := grpcSrv.Serve(lis); err != nil {
 			slog.Error("gRPC server stopped", "err", err)
 		}
@@ -82,12 +142,26 @@ func main() {
 
 	// WebSocket hub — broadcasts snapshots to UI clients every 5 seconds.
 	hub := ws.New(st, 5*time.Second)
+	hub.AllowOrigins(cfg.Server.Auth.HTTP.AllowedOrigins...)
 	go hub.Run(ctx)
-
-	// Combined HTTP server: REST API + WebSocket hub on HTTPPort.
+	// Acknowledgements are pushed at once so every dashboard sees who is on it.
+	alertEngine.OnEvent(func(event string, a *alerts.Alert) { hub.Publish(event, a) })
+
+	// Admin listener — the server's own metrics and health probes, without
+	// authentication, so it can be scraped and probed on an internal port.
+	// Ready once the history database, when enabled, answers a ping.
+	internal := append(metrics.Internal(st, hub), receiver.Collectors()...)
+	internal = append(internal, alerts.Collectors()...)
+	go admin.Serve(ctx, cfg.Server.AdminPort, admin.Handler(admin.NewRegistry(internal...), ready...))
+
+	// Combined HTTP server: REST API + WebSocket hub on HTTPPort, both behind
+	// API key / bearer token authentication when credentials are configured.
+	requireAuth := auth.HTTPMiddleware(cfg.Server.Auth.HTTP, cfg.Server.Auth.EffectiveHeader())
 	httpMux := http.NewServeMux()
-	httpMux.Handle("/api/", api.New(st, alertEngine))
-	httpMux.Handle("/ws/stream", hub)
+	httpMux.Handle("/api/", requireAuth(api.New(st, alertEngine, hist)))
+	httpMux.Handle("/ws/stream", requireAuth(hub))
+	// Pipeline health as Prometheus gauges, for scraping into Prometheus/Grafana.
+	httpMux.Handle("/metrics", requireAuth(metrics.Handler(metrics.NewPipelineCollector(st, alertEngine))))
 
 	// Optional: serve the pre-built React UI from a local directory.
 	// Usage:  ./bin/obsidianstack-server -config config/server.yaml -ui-dir ui/dist
@@ -110,9 +184,23 @@ func main() {
 		Addr:    fmt.Sprintf(":%d", cfg.Server.HTTPPort),
 		Handler: httpMux,
 	}
+	if cfg.Server.TLS.Enabled() {
+		tlsCfg, err := auth.ServerTLS(cfg.Server.TLS, auth.HTTPClientAuth(cfg.Server.TLS))
+		if err != nil {
+			slog.Error("failed to configure HTTP TLS", "err", err)
+			os.Exit(1)
+		}
+		httpSrv.TLSConfig = tlsCfg
+	}
 	go func() {
-		slog.Info("HTTP server listening", "port", cfg.Server.HTTPPort)
-		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		slog.Info("HTTP server listening", "port", cfg.Server.HTTPPort, "tls", cfg.Server.TLS.Enabled())
+		var err error
+		if httpSrv.TLSConfig != nil {
+			err = httpSrv.ListenAndServeTLS("", "") // certificate comes from TLSConfig
+		} else {
+			err = httpSrv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			slog.Error("HTTP server stopped", "err", err)
 		}
 	}()
@@ -0,0 +1,103 @@
+package alerts
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// Events passed to the function set with OnEvent.
+const (
+	EventAck   = "alert.ack"
+	EventUnack = "alert.unack"
+)
+
+var (
+	// ErrAlertNotFound is returned for an ID that is not a firing alert.
+	// Pending alerts have no ID yet; a firing alert keeps its ID when it
+	// re-fires, until it resolves.
+	ErrAlertNotFound = errors.New("alert not found")
+
+	// ErrAlertResolved is returned when acknowledging a resolved alert.
+	ErrAlertResolved = errors.New("alert is resolved")
+)
+
+// OnEvent sets fn to be called, outside the engine's lock, with a copy of
+// the alert after it is acknowledged or unacknowledged.
+func (e *Engine) OnEvent(fn func(event string, a *Alert)) {
+	e.mu.Lock()
+	e.onEvent = fn
+	e.mu.Unlock()
+}
+
+// Ack acknowledges the firing alert with the given ID on behalf of by,
+// replacing any earlier acknowledgement and note.
+func (e *Engine) Ack(id, by, note string) (*Alert, error) {
+	e.mu.Lock()
+	a, err := e.find(id)
+	if err != nil {
+		e.mu.Unlock()
+		return nil, err
+	}
+	now := e.now()
+	a.AcknowledgedBy = by
+	a.AcknowledgedAt = &now
+	a.Note = note
+	e.record(saveChange(a.RuleName+":"+a.SourceID, a))
+	cp := *a
+	e.mu.Unlock()
+	e.flush()
+
+	slog.Info("alert acknowledged", "id", id, "by", by)
+	e.emit(EventAck, &cp)
+	return &cp, nil
+}
+
+// Unack clears the acknowledgement of the firing alert with the given ID, so
+// its repeat notifications are sent again.
+func (e *Engine) Unack(id string) (*Alert, error) {
+	e.mu.Lock()
+	a, err := e.find(id)
+	if err != nil {
+		e.mu.Unlock()
+		return nil, err
+	}
+	a.AcknowledgedBy = ""
+	a.AcknowledgedAt = nil
+	a.Note = ""
+	e.record(saveChange(a.RuleName+":"+a.SourceID, a))
+	cp := *a
+	e.mu.Unlock()
+	e.flush()
+
+	slog.Info("alert unacknowledged", "id", id)
+	e.emit(EventUnack, &cp)
+	return &cp, nil
+}
+
+// find returns the firing alert with the given ID. Must be called with e.mu
+// held.
+func (e *Engine) find(id string) (*Alert, error) {
+	if id == "" {
+		return nil, ErrAlertNotFound
+	}
+	for _, a := range e.active {
+		if a.ID == id {
+			return a, nil
+		}
+	}
+	for _, a := range e.history {
+		if a.ID == id {
+			return nil, ErrAlertResolved
+		}
+	}
+	return nil, ErrAlertNotFound
+}
+
+func (e *Engine) emit(event string, a *Alert) {
+	e.mu.Lock()
+	fn := e.onEvent
+	e.mu.Unlock()
+	if fn != nil {
+		fn(event, a)
+	}
+}
@@ -0,0 +1,72 @@
+package alerts
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+func TestAck_SuppressesRepeatNotifications(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	e := testEngine(&now, config.AlertRule{Name: "drops", Condition: "drop_pct > 10", Cooldown: time.Minute})
+
+	var events []string
+	e.OnEvent(func(event string, a *Alert) { events = append(events, event+" "+a.AcknowledgedBy) })
+
+	e.Evaluate(snap(20))
+	id := e.active["drops:otel"].ID
+	a, err := e.Ack(id, "alice", "looking at the exporter")
+	if err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if a.AcknowledgedBy != "alice" || a.AcknowledgedAt == nil || !a.AcknowledgedAt.Equal(now) || a.Note != "looking at the exporter" {
+		t.Errorf("Ack returned %+v", a)
+	}
+
+	// The re-fire after the cooldown keeps the acknowledgement and is not
+	// notified.
+	now = now.Add(2 * time.Minute)
+	e.mu.Lock()
+	notify := e.match(e.rules[0], map[string]string{"source_id": "otel"}, 30, now)
+	refired := *e.active["drops:otel"]
+	e.mu.Unlock()
+	if notify != nil {
+		t.Errorf("acknowledged re-fire notified: %+v", notify)
+	}
+	if refired.ID != id || refired.AcknowledgedBy != "alice" {
+		t.Errorf("re-fired alert: got ID %q ack %q, want ID %q still acknowledged", refired.ID, refired.AcknowledgedBy, id)
+	}
+
+	if _, err := e.Unack(id); err != nil {
+		t.Fatalf("Unack: %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	e.mu.Lock()
+	notify = e.match(e.rules[0], map[string]string{"source_id": "otel"}, 30, now)
+	e.mu.Unlock()
+	if notify == nil {
+		t.Error("re-fire after Unack not notified")
+	}
+
+	if len(events) != 2 || events[0] != EventAck+" alice" || events[1] != EventUnack+" " {
+		t.Errorf("events: got %q", events)
+	}
+}
+
+func TestAck_Errors(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	e := testEngine(&now, config.AlertRule{Name: "drops", Condition: "drop_pct > 10"})
+
+	if _, err := e.Ack("nope", "alice", ""); !errors.Is(err, ErrAlertNotFound) {
+		t.Errorf("unknown ID: got %v, want ErrAlertNotFound", err)
+	}
+	e.Evaluate(snap(20))
+	id := e.active["drops:otel"].ID
+	now = now.Add(time.Second)
+	e.Evaluate(snap(0))
+	if _, err := e.Ack(id, "alice", ""); !errors.Is(err, ErrAlertResolved) {
+		t.Errorf("resolved alert: got %v, want ErrAlertResolved", err)
+	}
+}
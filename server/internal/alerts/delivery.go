@@ -0,0 +1,323 @@
+package alerts
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+// Headers of a signed http webhook request. The signature is
+// "sha256=" followed by the hex HMAC-SHA256, keyed with the signing secret,
+// of the timestamp, a ".", and the request body. Receivers should recompute
+// it and reject requests whose timestamp is too old to rule out replays.
+const (
+	TimestampHeader = "X-Obsidian-Timestamp"
+	SignatureHeader = "X-Obsidian-Signature"
+)
+
+// maxDeadLetters bounds the dead-letter list; the oldest entries are dropped.
+const maxDeadLetters = 100
+
+// Delivery statuses.
+const (
+	DeliveryFailed    = "failed"
+	DeliveryDelivered = "delivered"
+)
+
+var (
+	// ErrDeliveryNotFound is returned for an ID that is not on the
+	// dead-letter list.
+	ErrDeliveryNotFound = errors.New("delivery not found")
+
+	// ErrDeliverySuperseded is returned when re-sending a dead letter whose
+	// alert has had a newer notification, such as a trigger for an alert
+	// that has since resolved: sending it would report a stale state.
+	ErrDeliverySuperseded = errors.New("a newer notification for the alert has been sent")
+
+	// errSuperseded stops a delivery, retries included, once a newer
+	// notification for the same alert exists.
+	errSuperseded = errors.New("superseded by a newer notification for the alert")
+)
+
+// Delivery is a webhook notification that failed on every attempt, kept on
+// the dead-letter list for inspection and manual re-send.
+type Delivery struct {
+	ID          string    `json:"id"`
+	WebhookType string    `json:"webhook_type"`
+	URLEnv      string    `json:"url_env,omitempty"` // names the target without revealing its URL
+	AlertID     string    `json:"alert_id"`
+	RuleName    string    `json:"rule_name"`
+	SourceID    string    `json:"source_id"`
+	AlertState  string    `json:"alert_state"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error"`
+	FailedAt    time.Time `json:"failed_at"`
+	LastAttempt time.Time `json:"last_attempt"`
+	Status      string    `json:"status"` // "failed" | "delivered"
+
+	// Superseded is set once the alert has had a newer notification; the
+	// delivery can then no longer be resent.
+	Superseded bool `json:"superseded"`
+
+	webhook config.WebhookConfig
+	body    []byte // not exposed: a PagerDuty body holds the routing key
+	alert   *Alert // the notification, for its key and seq
+}
+
+// Sign returns the SignatureHeader value for body sent at timestamp ts (Unix
+// seconds) with secret.
+func Sign(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// send delivers body to wh at url, retrying as wh configures, and adds it to
+// the dead-letter list if every attempt fails. Notifications of one alert to
+// one webhook are sent one at a time, and one that a newer notification has
+// superseded is dropped, so a trigger being retried cannot land after the
+// resolve that followed it.
+func (e *Engine) send(wh config.WebhookConfig, url string, body []byte, a *Alert) {
+	key := laneKey(wh, a)
+	l := e.acquireLane(key)
+	defer e.releaseLane(key, l)
+
+	deliveries.WithLabelValues(wh.Type).Inc()
+	attempts, err := e.attempt(wh, url, body, a)
+	if errors.Is(err, errSuperseded) {
+		slog.Info("alerts: webhook delivery dropped — superseded",
+			"type", wh.Type,
+			"rule", a.RuleName,
+			"source", a.SourceID,
+			"state", a.State,
+			"attempts", attempts,
+		)
+		return
+	}
+	if err == nil {
+		slog.Debug("alerts: webhook delivered",
+			"type", wh.Type,
+			"rule", a.RuleName,
+			"state", a.State,
+			"attempts", attempts,
+		)
+		return
+	}
+
+	deliveryFailures.WithLabelValues(wh.Type).Inc()
+	slog.Error("alerts: webhook delivery failed — added to dead letters",
+		"type", wh.Type,
+		"rule", a.RuleName,
+		"attempts", attempts,
+		"err", err,
+	)
+	now := e.now()
+	e.addDeadLetter(&Delivery{
+		WebhookType: wh.Type,
+		URLEnv:      wh.URLEnv,
+		AlertID:     a.ID,
+		RuleName:    a.RuleName,
+		SourceID:    a.SourceID,
+		AlertState:  a.State,
+		Attempts:    attempts,
+		LastError:   err.Error(),
+		FailedAt:    now,
+		LastAttempt: now,
+		Status:      DeliveryFailed,
+		webhook:     wh,
+		body:        body,
+		alert:       a,
+	})
+}
+
+// attempt posts body, notifying a, to wh at url until it is accepted, the
+// failure is not retryable, the attempts configured for wh are used up, or a
+// is superseded (errSuperseded, with the attempts made so far). The wait
+// between attempts doubles from the configured backoff up to its cap, and a
+// longer Retry-After from the receiver is honoured within the cap.
+func (e *Engine) attempt(wh config.WebhookConfig, url string, body []byte, a *Alert) (int, error) {
+	r := wh.Retry.WithDefaults()
+	backoff := r.Backoff
+	for n := 1; ; n++ {
+		if e.superseded(a) {
+			return n - 1, errSuperseded
+		}
+		err := e.post(wh, url, body)
+		if err == nil {
+			return n, nil
+		}
+		var de *deliveryError
+		if !errors.As(err, &de) || !de.retryable || n >= r.MaxAttempts {
+			return n, err
+		}
+		wait := min(max(backoff, de.retryAfter), r.MaxBackoff)
+		retries.WithLabelValues(wh.Type).Inc()
+		slog.Warn("alerts: webhook delivery failed — retrying",
+			"type", wh.Type,
+			"attempt", n,
+			"in", wait,
+			"err", err,
+		)
+		time.Sleep(wait)
+		backoff = min(2*backoff, r.MaxBackoff)
+	}
+}
+
+// superseded reports whether a newer notification than a exists for its
+// alert.
+func (e *Engine) superseded(a *Alert) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.supersededLocked(a)
+}
+
+// supersededLocked is superseded with e.mu held.
+func (e *Engine) supersededLocked(a *Alert) bool {
+	return a.seq != 0 && e.notifySeq[a.RuleName+":"+a.SourceID] > a.seq
+}
+
+// laneKey identifies the webhook target and alert of a delivery.
+func laneKey(wh config.WebhookConfig, a *Alert) string {
+	return strings.Join([]string{wh.Type, wh.URLEnv, wh.RoutingKeyEnv, a.RuleName, a.SourceID}, "\x00")
+}
+
+// lane serialises the deliveries of one alert to one webhook.
+type lane struct {
+	mu   sync.Mutex
+	refs int // senders holding or waiting for mu; guarded by Engine.lanesMu
+}
+
+// acquireLane locks the lane for key, creating it if needed.
+func (e *Engine) acquireLane(key string) *lane {
+	e.lanesMu.Lock()
+	l := e.lanes[key]
+	if l == nil {
+		l = &lane{}
+		e.lanes[key] = l
+	}
+	l.refs++
+	e.lanesMu.Unlock()
+	l.mu.Lock()
+	return l
+}
+
+// releaseLane unlocks l, forgetting it once no sender needs it.
+func (e *Engine) releaseLane(key string, l *lane) {
+	l.mu.Unlock()
+	e.lanesMu.Lock()
+	if l.refs--; l.refs == 0 {
+		delete(e.lanes, key)
+	}
+	e.lanesMu.Unlock()
+}
+
+// addDeadLetter appends d to the dead-letter list with a new ID.
+func (e *Engine) addDeadLetter(d *Delivery) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.deliverySeq++
+	d.ID = "d" + strconv.FormatUint(e.deliverySeq, 10)
+	e.deadLetters = append(e.deadLetters, d)
+	if len(e.deadLetters) > maxDeadLetters {
+		e.deadLetters = e.deadLetters[len(e.deadLetters)-maxDeadLetters:]
+	}
+}
+
+// Deliveries returns copies of the dead-letter list, newest first.
+func (e *Engine) Deliveries() []Delivery {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Delivery, 0, len(e.deadLetters))
+	for i := len(e.deadLetters) - 1; i >= 0; i-- {
+		d := *e.deadLetters[i]
+		d.Superseded = e.supersededLocked(d.alert)
+		out = append(out, d)
+	}
+	return out
+}
+
+// Resend makes one more attempt at the dead-lettered delivery with the given
+// ID, with the webhook URL and secrets as they are now in the environment.
+// A delivery that succeeds leaves the list and is returned with status
+// "delivered"; one that fails stays with its attempt recorded, and the error
+// is returned with it. A delivery whose alert has had a newer notification
+// is refused with ErrDeliverySuperseded.
+func (e *Engine) Resend(id string) (Delivery, error) {
+	e.mu.Lock()
+	d := e.findDelivery(id)
+	if d == nil {
+		e.mu.Unlock()
+		return Delivery{}, ErrDeliveryNotFound
+	}
+	wh, body, a := d.webhook, d.body, d.alert
+	e.mu.Unlock()
+
+	// Take the delivery's place in line, then check that no newer
+	// notification has been sent meanwhile.
+	key := laneKey(wh, a)
+	l := e.acquireLane(key)
+	defer e.releaseLane(key, l)
+	e.mu.Lock()
+	if d = e.findDelivery(id); d == nil {
+		e.mu.Unlock()
+		return Delivery{}, ErrDeliveryNotFound
+	}
+	if e.supersededLocked(a) {
+		cp := *d
+		cp.Superseded = true
+		e.mu.Unlock()
+		return cp, ErrDeliverySuperseded
+	}
+	e.mu.Unlock()
+
+	var err error
+	if url := wh.URL(); url != "" {
+		err = e.post(wh, url, body)
+	} else {
+		err = fmt.Errorf("webhook URL is not set: %s is empty", wh.URLEnv)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	d = e.findDelivery(id)
+	if d == nil { // resent concurrently, or pushed off the list
+		return Delivery{}, ErrDeliveryNotFound
+	}
+	d.Attempts++
+	d.LastAttempt = e.now()
+	if err != nil {
+		d.LastError = err.Error()
+		return *d, err
+	}
+	d.Status = DeliveryDelivered
+	for i := range e.deadLetters {
+		if e.deadLetters[i] == d {
+			e.deadLetters = append(e.deadLetters[:i], e.deadLetters[i+1:]...)
+			break
+		}
+	}
+	slog.Info("alerts: dead-lettered webhook delivery resent", "id", id, "type", wh.Type)
+	return *d, nil
+}
+
+// findDelivery returns the dead letter with the given ID, or nil. Must be
+// called with e.mu held.
+func (e *Engine) findDelivery(id string) *Delivery {
+	for _, d := range e.deadLetters {
+		if d.ID == id {
+			return d
+		}
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package alerts
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+// flakyServer answers with status while it is non-zero, then 200, counting
+// requests.
+type flakyServer struct {
+	status   atomic.Int32
+	requests atomic.Int32
+	last     atomic.Pointer[http.Request]
+	body     atomic.Pointer[[]byte]
+}
+
+func newFlakyServer(t *testing.T, status int) *flakyServer {
+	t.Helper()
+	f := &flakyServer{}
+	f.status.Store(int32(status))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.requests.Add(1)
+		b, _ := io.ReadAll(r.Body)
+		f.last.Store(r)
+		f.body.Store(&b)
+		if s := f.status.Load(); s != 0 {
+			w.WriteHeader(int(s))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("HOOK_URL", srv.URL)
+	return f
+}
+
+func httpWebhook(retry config.RetryConfig) config.WebhookConfig {
+	return config.WebhookConfig{Type: "http", URLEnv: "HOOK_URL", Retry: retry}
+}
+
+var testAlert = &Alert{ID: "drops:otel:1", RuleName: "drops", SourceID: "otel", State: StateFiring}
+
+func TestDeliver_RetriesTransientFailures(t *testing.T) {
+	srv := newFlakyServer(t, http.StatusBadGateway)
+	e := New(config.AlertsConfig{Webhooks: []config.WebhookConfig{
+		httpWebhook(config.RetryConfig{MaxAttempts: 3, Backoff: 20 * time.Millisecond}),
+	}})
+	go func() {
+		time.Sleep(5 * time.Millisecond) // within the first backoff
+		srv.status.Store(0)
+	}()
+
+	e.deliver(testAlert, nil)
+	if n := srv.requests.Load(); n != 2 {
+		t.Errorf("requests: got %d, want 2", n)
+	}
+	if d := e.Deliveries(); len(d) != 0 {
+		t.Errorf("dead letters after a successful retry: %+v", d)
+	}
+}
+
+func TestDeliver_DoesNotRetryClientErrors(t *testing.T) {
+	srv := newFlakyServer(t, http.StatusBadRequest)
+	e := New(config.AlertsConfig{Webhooks: []config.WebhookConfig{
+		httpWebhook(config.RetryConfig{MaxAttempts: 3, Backoff: time.Millisecond}),
+	}})
+
+	e.deliver(testAlert, nil)
+	if n := srv.requests.Load(); n != 1 {
+		t.Errorf("requests: got %d, want 1", n)
+	}
+	d := e.Deliveries()
+	if len(d) != 1 || d[0].Attempts != 1 || d[0].LastError != "webhook returned HTTP 400" {
+		t.Errorf("dead letters: got %+v", d)
+	}
+}
+
+func TestDeliver_DeadLettersAndResend(t *testing.T) {
+	srv := newFlakyServer(t, http.StatusServiceUnavailable)
+	e := New(config.AlertsConfig{Webhooks: []config.WebhookConfig{
+		httpWebhook(config.RetryConfig{MaxAttempts: 2, Backoff: time.Millisecond}),
+	}})
+
+	e.deliver(testAlert, nil)
+	list := e.Deliveries()
+	if len(list) != 1 {
+		t.Fatalf("dead letters: got %d, want 1", len(list))
+	}
+	d := list[0]
+	if d.Attempts != 2 || d.Status != DeliveryFailed || d.AlertID != testAlert.ID || d.URLEnv != "HOOK_URL" {
+		t.Errorf("dead letter: got %+v", d)
+	}
+
+	if got, err := e.Resend(d.ID); err == nil || got.Attempts != 3 {
+		t.Errorf("Resend while failing: got %+v, %v; want 3 attempts and an error", got, err)
+	}
+	srv.status.Store(0)
+	got, err := e.Resend(d.ID)
+	if err != nil || got.Status != DeliveryDelivered {
+		t.Fatalf("Resend: got %+v, %v", got, err)
+	}
+	if n := srv.requests.Load(); n != 4 {
+		t.Errorf("requests: got %d, want 4", n)
+	}
+	if b := *srv.body.Load(); !strings.Contains(string(b), `"rule_name":"drops"`) {
+		t.Errorf("resent body: %s", b)
+	}
+	if len(e.Deliveries()) != 0 {
+		t.Error("delivered entry still on the dead-letter list")
+	}
+	if _, err := e.Resend(d.ID); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("Resend of a delivered entry: got %v, want ErrDeliveryNotFound", err)
+	}
+}
+
+// stateServer records the alert state of each request it gets, failing the
+// states in fail with status.
+type stateServer struct {
+	mu     sync.Mutex
+	states []string
+}
+
+func newStateServer(t *testing.T, status int, fail ...string) *stateServer {
+	t.Helper()
+	s := &stateServer{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Alert struct {
+				State string `json:"state"`
+			} `json:"alert"`
+		}
+		json.NewDecoder(r.Body).Decode(&body) //nolint:errcheck
+		s.mu.Lock()
+		s.states = append(s.states, body.Alert.State)
+		s.mu.Unlock()
+		if slices.Contains(fail, body.Alert.State) {
+			w.WriteHeader(status)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("HOOK_URL", srv.URL)
+	return s
+}
+
+func (s *stateServer) got() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.states)
+}
+
+// waitFor polls cond until it holds or a few seconds have passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+// TestDeliver_ResolveSupersedesRetriedTrigger resolves an alert while its
+// trigger is being retried: the trigger must not land after the resolve.
+func TestDeliver_ResolveSupersedesRetriedTrigger(t *testing.T) {
+	srv := newStateServer(t, http.StatusServiceUnavailable, StateFiring)
+	e := New(config.AlertsConfig{
+		Rules: []config.AlertRule{{Name: "drops", Condition: "drop_pct > 10"}},
+		Webhooks: []config.WebhookConfig{
+			httpWebhook(config.RetryConfig{MaxAttempts: 10, Backoff: 20 * time.Millisecond}),
+		},
+	})
+
+	e.Evaluate(snap(20))
+	waitFor(t, "the trigger", func() bool { return len(srv.got()) > 0 })
+	e.Evaluate(snap(0))
+	waitFor(t, "the resolve", func() bool { return slices.Contains(srv.got(), StateResolved) })
+
+	time.Sleep(100 * time.Millisecond) // several backoffs
+	got := srv.got()
+	if got[len(got)-1] != StateResolved {
+		t.Errorf("requests: got %v, want the resolve last", got)
+	}
+	if d := e.Deliveries(); len(d) != 0 {
+		t.Errorf("superseded trigger dead-lettered: %+v", d)
+	}
+}
+
+func TestResend_RefusesSupersededDelivery(t *testing.T) {
+	newStateServer(t, http.StatusBadRequest, StateFiring, StateResolved)
+	e := New(config.AlertsConfig{
+		Rules:    []config.AlertRule{{Name: "drops", Condition: "drop_pct > 10"}},
+		Webhooks: []config.WebhookConfig{httpWebhook(config.RetryConfig{})},
+	})
+
+	e.Evaluate(snap(20))
+	waitFor(t, "the trigger dead letter", func() bool { return len(e.Deliveries()) == 1 })
+	e.Evaluate(snap(0))
+	waitFor(t, "the resolve dead letter", func() bool { return len(e.Deliveries()) == 2 })
+
+	list := e.Deliveries() // newest first
+	resolve, trigger := list[0], list[1]
+	if trigger.AlertState != StateFiring || !trigger.Superseded || resolve.Superseded {
+		t.Fatalf("dead letters: got %+v", list)
+	}
+	if _, err := e.Resend(trigger.ID); !errors.Is(err, ErrDeliverySuperseded) {
+		t.Errorf("Resend of a trigger after its resolve: got %v, want ErrDeliverySuperseded", err)
+	}
+	if _, err := e.Resend(resolve.ID); err == nil || errors.Is(err, ErrDeliverySuperseded) {
+		t.Errorf("Resend of the resolve: got %v, want the HTTP error", err)
+	}
+}
+
+func TestDeliver_HeadersAndSignature(t *testing.T) {
+	srv := newFlakyServer(t, 0)
+	t.Setenv("HOOK_TOKEN", "Bearer t0k3n")
+	t.Setenv("HOOK_SECRET", "s3cret")
+	wh := httpWebhook(config.RetryConfig{})
+	wh.Headers = map[string]string{"X-Team": "sre"}
+	wh.HeadersEnv = map[string]string{"Authorization": "HOOK_TOKEN"}
+	wh.SigningSecretEnv = "HOOK_SECRET"
+	e := New(config.AlertsConfig{Webhooks: []config.WebhookConfig{wh}})
+	e.now = func() time.Time { return time.Unix(1_700_000_000, 0) }
+
+	e.deliver(testAlert, nil)
+	r, body := srv.last.Load(), *srv.body.Load()
+	if r.Header.Get("X-Team") != "sre" || r.Header.Get("Authorization") != "Bearer t0k3n" {
+		t.Errorf("custom headers: got %v", r.Header)
+	}
+	if r.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type: got %q", r.Header.Get("Content-Type"))
+	}
+	ts := r.Header.Get(TimestampHeader)
+	if ts != "1700000000" {
+		t.Errorf("%s: got %q", TimestampHeader, ts)
+	}
+	if got, want := r.Header.Get(SignatureHeader), Sign("s3cret", ts, body); got != want || !strings.HasPrefix(got, "sha256=") {
+		t.Errorf("%s: got %q, want %q", SignatureHeader, got, want)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"5":                             5 * time.Second,
+		"-1":                            0,
+		"soon":                          0,
+		"Wed, 01 Jan 2025 12:00:30 GMT": 30 * time.Second,
+		"Wed, 01 Jan 2025 11:00:00 GMT": 0,
+	}
+	for v, want := range tests {
+		if got := retryAfter(v, now); got != want {
+			t.Errorf("retryAfter(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
@@ -1,4 +1,60 @@
 // Package alerts implements the rule evaluation engine and webhook delivery
 // for ObsidianStack alerting. Rules are evaluated against pipeline snapshots;
-// webhooks are delivered to Teams, Slack, PagerDuty, or generic HTTP targets.
+// webhooks are delivered to Teams, Slack, PagerDuty, generic HTTP targets, or
+// by email.
+// Rule conditions are parsed once by New with package expr. A rule's match
+// selector limits the pipelines it is evaluated for, and the first of its
+// overrides that selects a pipeline replaces the rule's severity and cooldown.
+//
+// An alert is pending while its condition has held for less than the rule's
+// for duration, firing once it has (webhooks are only notified then), and
+// resolved when the condition clears — or, with keep_firing_for, once it has
+// stayed clear that long. Pending alerts that clear are dropped without a
+// notification.
+//
+// Silences (AddSilence, Silences, ExpireSilence) and the recurring
+// maintenance windows from the config mute alerts by rule-name glob and
+// pipeline selector. Muted alerts are still evaluated and listed, marked
+// silenced, but no webhook is sent when they fire; a firing alert whose
+// silence ends is notified at its next re-fire. An alert notified before it
+// was silenced still notifies its resolve, closing the incident it opened.
+//
+// Ack records who is handling a firing alert, when, and a note; until Unack
+// its re-fires after the cooldown are not notified. An alert keeps the ID it
+// got when it first fired across re-fires, so an ack made by ID stays valid. Both are reported to the
+// function set with OnEvent, which the server uses to push them to dashboard
+// clients.
+//
+// With SetStore, alert state is persisted to a Store (history.SQLite) and
+// restored at startup, so a restart neither re-fires active alerts, resets
+// cooldowns nor lifts silences; History queries the resolved alerts it keeps.
+//
+// Notifications go to named receivers, each a set of webhooks. A rule that
+// names a receiver sends there; otherwise the config's route tree picks the
+// receivers by severity, rule name and pipeline selector, with continue
+// semantics as in Prometheus Alertmanager. Alerts routed to no webhook are
+// logged.
+//
+// Slack, Teams and http payloads are rendered by package payload, from the
+// webhook's template or the built-in one for its type.
+//
+// PagerDuty targets use the Events API v2: a trigger when an alert fires,
+// carrying the snapshot fields and diagnostic hints in custom_details, and a
+// resolve when it clears, both under a dedup key built from rule and source
+// so that re-fires update one incident.
+//
+// smtp targets send email with text and HTML bodies linking to the pipeline
+// on the dashboard. Notifications for a recipient are collected for the
+// target's group wait and sent as one email.
+//
+// HTTP targets get the webhook's custom headers, and http ones with a
+// signing secret an HMAC-SHA256 signature (Sign). Network errors, 429 and
+// 5xx responses are retried with exponential backoff; a delivery that fails
+// every attempt is kept on a dead-letter list (Deliveries) from which Resend
+// tries it again. Notifications of one alert to one webhook are sent in
+// order, and one superseded by a newer notification, such as a trigger still
+// being retried when the alert resolves, is dropped or refused by Resend.
+//
+// Delivery attempts, retries and failures per webhook type are counted in
+// the Prometheus metrics returned by Collectors.
 package alerts
@@ -9,13 +9,22 @@ import (
 
 	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
 
+	"github.com/obsidianstack/obsidianstack/server/internal/alerts/expr"
+	"github.com/obsidianstack/obsidianstack/server/internal/alerts/payload"
 	"github.com/obsidianstack/obsidianstack/server/internal/config"
 )
 
 const (
-	defaultCooldown    = 15 * time.Minute
-	maxHistoryLen      = 200
-	recentWindowHours  = 1
+	defaultCooldown   = 15 * time.Minute
+	maxHistoryLen     = 200
+	recentWindowHours = 1
+)
+
+// Alert states.
+const (
+	StatePending  = "pending"  // condition holds, waiting out the rule's for duration
+	StateFiring   = "firing"   // notified; kept until the condition clears
+	StateResolved = "resolved" // condition cleared after firing
 )
 
 // Alert represents a single alert event produced by the rule engine.
@@ -26,9 +35,26 @@ type Alert struct {
 	Severity   string     `json:"severity"`
 	Message    string     `json:"message"`
 	Value      float64    `json:"value"`
-	FiredAt    time.Time  `json:"fired_at"`
+	ActiveAt   time.Time  `json:"active_at"`         // when the condition started holding
+	FiredAt    time.Time  `json:"fired_at,omitzero"` // unset while pending
 	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
-	State      string     `json:"state"` // "firing" | "resolved"
+	State      string     `json:"state"` // "pending" | "firing" | "resolved"
+
+	// Silenced is set while a silence or maintenance window matches the
+	// alert; SilencedBy lists their IDs ("maintenance:<name>" for windows).
+	Silenced   bool     `json:"silenced"`
+	SilencedBy []string `json:"silenced_by,omitempty"`
+
+	// Acknowledgement by on-call: while set, re-fires after the cooldown
+	// are not notified. Cleared by Unack; a new alert starts unacknowledged.
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	Note           string     `json:"note,omitempty"`
+
+	labels    map[string]string // pipeline labels, for matching silences
+	lastMatch time.Time         // last evaluation the condition held, for keep_firing_for
+	notified  bool              // webhooks were sent when it fired
+	seq       uint64            // orders the alert's notifications; 0 if none
 }
 
 // Engine evaluates alert rules against incoming PipelineSnapshots and delivers
@@ -36,116 +62,319 @@ type Alert struct {
 //
 // Engine is safe for concurrent use.
 type Engine struct {
-	rules    []config.AlertRule
-	webhooks []config.WebhookConfig
+	rules []config.AlertRule
+	conds []*expr.Expr // parsed rules[i].Condition; nil if it does not parse
+
+	receivers     map[string][]config.WebhookConfig // webhooks by receiver name
+	route         config.Route                      // root of the routing tree
+	ruleReceivers map[string]string                 // receiver named by a rule, by rule name
+	mailers       map[mailerKey]*mailer             // batch the smtp webhooks' email
+	templates     map[string]*payload.Template      // parsed webhook templates, by text
+	dashboardURL  string
 
 	mu       sync.Mutex
-	active   map[string]*Alert   // key: "ruleName:sourceID"
+	active   map[string]*Alert    // pending and firing; key: "ruleName:sourceID"
 	lastFire map[string]time.Time // last fire time per key (for cooldown)
 	history  []*Alert             // recently resolved alerts
+	silences []*Silence
+	windows  []window // parsed maintenance windows
 	client   *http.Client
+	now      func() time.Time // injectable for deterministic tests
+	onEvent  func(event string, a *Alert)
+	store    Store    // nil keeps state in memory only
+	changes  []change // queued for store
+
+	deadLetters []*Delivery       // webhook deliveries that failed every attempt
+	deliverySeq uint64            // last dead-letter ID
+	notifySeq   map[string]uint64 // last notification per alert key
+
+	lanesMu sync.Mutex
+	lanes   map[string]*lane // serialise deliveries per webhook and alert
+
+	flushMu sync.Mutex // orders writes to store
 }
 
 // New creates an Engine from the server alert configuration.
 // An Engine with empty rules is valid — Evaluate becomes a no-op.
+// config.Load rejects conditions that do not parse; a rule that reaches New
+// with one anyway is logged and never fires.
 func New(cfg config.AlertsConfig) *Engine {
+	conds := make([]*expr.Expr, len(cfg.Rules))
+	ruleReceivers := make(map[string]string)
+	for i, rule := range cfg.Rules {
+		if rule.Receiver != "" {
+			ruleReceivers[rule.Name] = rule.Receiver
+		}
+		c, err := expr.Parse(rule.Condition)
+		if err != nil {
+			slog.Error("alerts: rule disabled — bad condition", "rule", rule.Name, "err", err)
+			continue
+		}
+		conds[i] = c
+	}
+	var windows []window
+	for _, mw := range cfg.Maintenance {
+		w, err := newWindow(mw)
+		if err != nil {
+			slog.Error("alerts: maintenance window disabled", "name", mw.Name, "err", err)
+			continue
+		}
+		windows = append(windows, w)
+	}
+	receivers, root := newRouting(cfg)
+	templates := make(map[string]*payload.Template)
+	for _, webhooks := range receivers {
+		for _, wh := range webhooks {
+			if wh.Template == "" || templates[wh.Template] != nil {
+				continue
+			}
+			t, err := payload.Parse(wh.Template)
+			if err != nil {
+				slog.Error("alerts: webhook template disabled — using the built-in one", "type", wh.Type, "err", err)
+				continue
+			}
+			templates[wh.Template] = t
+		}
+	}
 	return &Engine{
-		rules:    cfg.Rules,
-		webhooks: cfg.Webhooks,
-		active:   make(map[string]*Alert),
-		lastFire: make(map[string]time.Time),
-		client:   &http.Client{Timeout: 10 * time.Second},
+		rules:         cfg.Rules,
+		conds:         conds,
+		receivers:     receivers,
+		route:         root,
+		ruleReceivers: ruleReceivers,
+		mailers:       newMailers(receivers, cfg.DashboardURL),
+		templates:     templates,
+		dashboardURL:  cfg.DashboardURL,
+		windows:       windows,
+		active:        make(map[string]*Alert),
+		lastFire:      make(map[string]time.Time),
+		notifySeq:     make(map[string]uint64),
+		lanes:         make(map[string]*lane),
+		client:        &http.Client{Timeout: 10 * time.Second},
+		now:           time.Now,
 	}
 }
 
-// Evaluate tests all configured rules against snap.
-// Alerts that fire are stored and webhook delivery is triggered asynchronously.
-// Alerts that were firing but whose condition is now false are resolved.
+// Evaluate tests the configured rules whose match selector picks snap
+// against it, with the severity and cooldown of the first matching override.
+//
+// A rule whose condition holds starts a pending alert. It fires once the
+// condition has held for the rule's for duration (immediately when unset),
+// and webhook delivery is triggered asynchronously. A firing alert re-fires
+// when its cooldown has passed and the condition still holds. If the
+// condition stops holding, a pending alert is dropped silently and a firing
+// one resolves — after keep_firing_for has passed without a match, when set.
+// Webhooks are not notified for alerts that are silenced when they fire, nor
+// of their resolve; an alert whose firing was notified always notifies its
+// resolve, silenced or not, so incidents it opened get closed.
 func (e *Engine) Evaluate(snap *pb.PipelineSnapshot) {
 	if len(e.rules) == 0 {
 		return
 	}
 
-	now := time.Now()
-	for _, rule := range e.rules {
-		key := rule.Name + ":" + snap.SourceId
-		fires, value := evalCondition(rule.Condition, snap)
+	now := e.now()
+	labels := sourceLabels(snap)
+	for i, rule := range e.rules {
+		if e.conds[i] == nil || !rule.Match.Matches(labels) {
+			continue
+		}
+		rule = applyOverrides(rule, labels)
+		fires, value := e.conds[i].Eval(snap)
 
 		e.mu.Lock()
-
+		var notify *Alert
 		if fires {
-			cooldown := rule.Cooldown
-			if cooldown <= 0 {
-				cooldown = defaultCooldown
-			}
-			if now.Sub(e.lastFire[key]) > cooldown {
-				sev := rule.Severity
-				if sev == "" {
-					sev = "warning"
-				}
-				a := &Alert{
-					ID:       fmt.Sprintf("%s:%s:%d", rule.Name, snap.SourceId, now.UnixNano()),
-					RuleName: rule.Name,
-					SourceID: snap.SourceId,
-					Severity: sev,
-					Value:    value,
-					Message: fmt.Sprintf("[%s] %s fired on %s — %s = %.2f",
-						sev, rule.Name, snap.SourceId, rule.Condition, value),
-					FiredAt: now,
-					State:   "firing",
-				}
-				e.active[key] = a
-				e.lastFire[key] = now
-				alertCopy := *a
-				e.mu.Unlock()
-
-				slog.Warn("alert fired",
-					"rule", rule.Name,
-					"source", snap.SourceId,
-					"value", value,
-					"severity", sev,
-				)
-				go e.deliver(&alertCopy)
-			} else {
-				e.mu.Unlock()
-			}
+			notify = e.match(rule, labels, value, now)
 		} else {
-			if a, ok := e.active[key]; ok && a.State == "firing" {
-				resolved := now
-				a.State = "resolved"
-				a.ResolvedAt = &resolved
-				delete(e.active, key)
-
-				e.history = append(e.history, a)
-				if len(e.history) > maxHistoryLen {
-					e.history = e.history[len(e.history)-maxHistoryLen:]
-				}
-				alertCopy := *a
-				e.mu.Unlock()
-
-				slog.Info("alert resolved",
-					"rule", rule.Name,
-					"source", snap.SourceId,
-				)
-				go e.deliver(&alertCopy)
-			} else {
-				e.mu.Unlock()
+			notify = e.clear(rule, labels, now)
+		}
+		if notify != nil {
+			key := rule.Name + ":" + notify.SourceID
+			e.notifySeq[key]++
+			notify.seq = e.notifySeq[key]
+		}
+		e.mu.Unlock()
+
+		if notify != nil {
+			go e.deliver(notify, snap)
+		}
+	}
+	e.flush()
+}
+
+// match records that rule's condition holds for sourceID and returns a copy
+// of the alert to deliver if it fired. Must be called with e.mu held.
+func (e *Engine) match(rule config.AlertRule, labels map[string]string, value float64, now time.Time) *Alert {
+	sourceID := labels["source_id"]
+	key := rule.Name + ":" + sourceID
+	a, ok := e.active[key]
+	if !ok {
+		a = &Alert{
+			RuleName: rule.Name,
+			SourceID: sourceID,
+			Severity: severityOf(rule),
+			ActiveAt: now,
+			State:    StatePending,
+			labels:   labels,
+		}
+	}
+	a.lastMatch = now
+
+	if a.State == StatePending {
+		a.Value = value
+		if now.Sub(a.ActiveAt) < rule.For {
+			if !ok {
+				e.active[key] = a
+				e.record(saveChange(key, a))
+				slog.Info("alert pending", "rule", rule.Name, "source", sourceID, "for", rule.For)
 			}
+			return nil
+		}
+	}
+	// Due to fire, or firing and due to re-fire once the cooldown has passed.
+	cooldown := rule.Cooldown
+	if cooldown <= 0 {
+		cooldown = defaultCooldown
+	}
+	if now.Sub(e.lastFire[key]) <= cooldown {
+		return nil
+	}
+
+	fired := *a
+	if fired.ID == "" { // first fire; re-fires keep the ID, so acks stay valid
+		fired.ID = fmt.Sprintf("%s:%s:%d", rule.Name, sourceID, now.UnixNano())
+	}
+	fired.Value = value
+	fired.Message = fmt.Sprintf("[%s] %s fired on %s — %s (value %.2f)",
+		fired.Severity, rule.Name, sourceID, rule.Condition, value)
+	fired.FiredAt = now
+	fired.State = StateFiring
+	fired.SilencedBy = e.silencedBy(rule.Name, labels, now)
+	fired.Silenced = len(fired.SilencedBy) > 0
+	acked := a.State == StateFiring && a.AcknowledgedAt != nil // a repeat
+	if !fired.Silenced && !acked {
+		fired.notified = true
+	}
+	e.active[key] = &fired
+	e.lastFire[key] = now
+	c := saveChange(key, &fired)
+	c.fired = now
+	e.record(c)
+
+	slog.Warn("alert fired",
+		"rule", rule.Name,
+		"source", sourceID,
+		"value", value,
+		"severity", fired.Severity,
+		"silenced_by", fired.SilencedBy,
+		"acknowledged", acked,
+	)
+	if fired.Silenced || acked {
+		return nil
+	}
+	cp := fired
+	return &cp
+}
+
+// clear records that rule's condition no longer holds for sourceID and
+// returns a copy of the alert to deliver if it resolved. Must be called with
+// e.mu held.
+func (e *Engine) clear(rule config.AlertRule, labels map[string]string, now time.Time) *Alert {
+	sourceID := labels["source_id"]
+	key := rule.Name + ":" + sourceID
+	a, ok := e.active[key]
+	if !ok {
+		return nil
+	}
+	if a.State == StatePending {
+		delete(e.active, key)
+		e.record(change{key: key, delete: true})
+		slog.Debug("alert pending cleared", "rule", rule.Name, "source", sourceID)
+		return nil
+	}
+	if now.Sub(a.lastMatch) < rule.KeepFiringFor {
+		return nil
+	}
+
+	resolved := now
+	a.State = StateResolved
+	a.ResolvedAt = &resolved
+	a.SilencedBy = e.silencedBy(rule.Name, labels, now)
+	a.Silenced = len(a.SilencedBy) > 0
+	delete(e.active, key)
+	stored := *a
+	e.record(change{key: key, delete: true, resolved: &stored})
+
+	e.history = append(e.history, a)
+	if len(e.history) > maxHistoryLen {
+		e.history = e.history[len(e.history)-maxHistoryLen:]
+	}
+
+	slog.Info("alert resolved",
+		"rule", rule.Name,
+		"source", sourceID,
+		"silenced_by", a.SilencedBy,
+	)
+	if !a.notified {
+		return nil
+	}
+	cp := *a
+	return &cp
+}
+
+// sourceLabels returns the labels rule selectors match snap on.
+func sourceLabels(snap *pb.PipelineSnapshot) map[string]string {
+	return map[string]string{
+		"source_id":   snap.SourceId,
+		"source_type": snap.SourceType,
+		"cluster":     snap.Cluster,
+		"namespace":   snap.Namespace,
+		"node_type":   snap.NodeType,
+	}
+}
+
+// applyOverrides returns rule with the severity and cooldown of its first
+// override that selects labels, if any.
+func applyOverrides(rule config.AlertRule, labels map[string]string) config.AlertRule {
+	for _, o := range rule.Overrides {
+		if !o.Match.Matches(labels) {
+			continue
+		}
+		if o.Severity != "" {
+			rule.Severity = o.Severity
 		}
+		if o.Cooldown > 0 {
+			rule.Cooldown = o.Cooldown
+		}
+		break
+	}
+	return rule
+}
+
+// severityOf returns rule's severity, defaulting to warning.
+func severityOf(rule config.AlertRule) string {
+	if rule.Severity == "" {
+		return "warning"
 	}
+	return rule.Severity
 }
 
-// Active returns copies of all currently firing alerts plus any alerts
-// resolved within the past hour, sorted newest first.
+// Active returns copies of all pending and firing alerts plus any alerts
+// resolved within the past hour, sorted newest first. Pending and firing
+// alerts are marked silenced by the silences and maintenance windows matching
+// them now; resolved ones by those that matched when they resolved.
 func (e *Engine) Active() []*Alert {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	cutoff := time.Now().Add(-recentWindowHours * time.Hour)
+	now := e.now()
+	cutoff := now.Add(-recentWindowHours * time.Hour)
 	out := make([]*Alert, 0, len(e.active))
 
 	for _, a := range e.active {
 		cp := *a
+		cp.SilencedBy = e.silencedBy(a.RuleName, a.labels, now)
+		cp.Silenced = len(cp.SilencedBy) > 0
 		out = append(out, &cp)
 	}
 	for _, a := range e.history {
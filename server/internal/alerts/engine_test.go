@@ -0,0 +1,216 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+// testEngine returns an Engine for rules whose clock is *now.
+func testEngine(now *time.Time, rules ...config.AlertRule) *Engine {
+	e := New(config.AlertsConfig{Rules: rules})
+	e.now = func() time.Time { return *now }
+	return e
+}
+
+func snap(dropPct float64) *pb.PipelineSnapshot {
+	return &pb.PipelineSnapshot{SourceId: "otel", DropPct: dropPct}
+}
+
+// stateOf returns the state of the single alert e reports for rule, or ""
+// if there is none.
+func stateOf(t *testing.T, e *Engine, rule string) string {
+	t.Helper()
+	var state string
+	for _, a := range e.Active() {
+		if a.RuleName != rule {
+			continue
+		}
+		if state != "" {
+			t.Fatalf("more than one alert for %s", rule)
+		}
+		state = a.State
+	}
+	return state
+}
+
+func TestEvaluate_FiresImmediatelyWithoutFor(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	e := testEngine(&now, config.AlertRule{Name: "drops", Condition: "drop_pct > 10"})
+
+	e.Evaluate(snap(20))
+	if got := stateOf(t, e, "drops"); got != StateFiring {
+		t.Fatalf("state: got %q, want firing", got)
+	}
+
+	now = now.Add(time.Second)
+	e.Evaluate(snap(0))
+	if got := stateOf(t, e, "drops"); got != StateResolved {
+		t.Errorf("state after clearing: got %q, want resolved", got)
+	}
+}
+
+func TestEvaluate_PendingUntilFor(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	now := start
+	e := testEngine(&now, config.AlertRule{Name: "drops", Condition: "drop_pct > 10", For: 2 * time.Minute})
+
+	e.Evaluate(snap(20))
+	if got := stateOf(t, e, "drops"); got != StatePending {
+		t.Fatalf("state: got %q, want pending", got)
+	}
+
+	now = start.Add(time.Minute)
+	e.Evaluate(snap(30))
+	if got := stateOf(t, e, "drops"); got != StatePending {
+		t.Fatalf("state after 1m: got %q, want pending", got)
+	}
+
+	now = start.Add(2 * time.Minute)
+	e.Evaluate(snap(40))
+	active := e.Active()
+	if len(active) != 1 || active[0].State != StateFiring {
+		t.Fatalf("after 2m: got %+v, want one firing alert", active)
+	}
+	a := active[0]
+	if !a.ActiveAt.Equal(start) || !a.FiredAt.Equal(now) {
+		t.Errorf("ActiveAt/FiredAt: got %v/%v, want %v/%v", a.ActiveAt, a.FiredAt, start, now)
+	}
+	if a.Value != 40 {
+		t.Errorf("Value: got %v, want 40", a.Value)
+	}
+}
+
+func TestEvaluate_PendingDroppedWhenConditionClears(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	e := testEngine(&now, config.AlertRule{Name: "drops", Condition: "drop_pct > 10", For: time.Minute})
+
+	e.Evaluate(snap(20))
+	now = now.Add(30 * time.Second)
+	e.Evaluate(snap(0))
+	if got := stateOf(t, e, "drops"); got != "" {
+		t.Fatalf("state after clearing while pending: got %q, want no alert", got)
+	}
+
+	// A new match restarts the for timer.
+	now = now.Add(30 * time.Second)
+	e.Evaluate(snap(20))
+	now = now.Add(30 * time.Second)
+	e.Evaluate(snap(20))
+	if got := stateOf(t, e, "drops"); got != StatePending {
+		t.Errorf("state: got %q, want pending", got)
+	}
+}
+
+func TestEvaluate_KeepFiringFor(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	e := testEngine(&now, config.AlertRule{Name: "drops", Condition: "drop_pct > 10", KeepFiringFor: time.Minute})
+
+	e.Evaluate(snap(20))
+	now = now.Add(30 * time.Second)
+	e.Evaluate(snap(0))
+	if got := stateOf(t, e, "drops"); got != StateFiring {
+		t.Fatalf("state 30s after clearing: got %q, want firing", got)
+	}
+
+	// Matching again restarts the keep_firing_for window.
+	now = now.Add(20 * time.Second)
+	e.Evaluate(snap(20))
+	now = now.Add(50 * time.Second)
+	e.Evaluate(snap(0))
+	if got := stateOf(t, e, "drops"); got != StateFiring {
+		t.Fatalf("state 50s after last match: got %q, want firing", got)
+	}
+
+	now = now.Add(10 * time.Second)
+	e.Evaluate(snap(0))
+	if got := stateOf(t, e, "drops"); got != StateResolved {
+		t.Errorf("state 60s after last match: got %q, want resolved", got)
+	}
+}
+
+func TestEvaluate_CooldownSuppressesRefire(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	e := testEngine(&now, config.AlertRule{Name: "drops", Condition: "drop_pct > 10", Cooldown: 10 * time.Minute})
+
+	e.Evaluate(snap(20))
+	first := e.Active()[0]
+
+	now = now.Add(5 * time.Minute)
+	e.Evaluate(snap(20))
+	if got := e.Active()[0].FiredAt; !got.Equal(first.FiredAt) {
+		t.Fatalf("re-fired within cooldown: FiredAt %v, want %v", got, first.FiredAt)
+	}
+
+	now = now.Add(6 * time.Minute)
+	e.Evaluate(snap(20))
+	active := e.Active()
+	if len(active) != 1 || !active[0].FiredAt.Equal(now) || active[0].ID != first.ID || active[0].State != StateFiring {
+		t.Errorf("after cooldown: got %+v, want one re-fired alert keeping ID %q", active, first.ID)
+	}
+}
+
+func TestEvaluate_MatchScopesRule(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	e := testEngine(&now, config.AlertRule{
+		Name:      "low-throughput",
+		Condition: "throughput < 100",
+		Match:     config.Selector{"source_type": "otelcol", "cluster": "!staging-*"},
+	})
+
+	for _, s := range []*pb.PipelineSnapshot{
+		{SourceId: "otel-prod", SourceType: "otelcol", Cluster: "prod-eu"},
+		{SourceId: "otel-staging", SourceType: "otelcol", Cluster: "staging-eu"},
+		{SourceId: "loki-prod", SourceType: "loki", Cluster: "prod-eu"},
+	} {
+		e.Evaluate(s)
+	}
+
+	active := e.Active()
+	if len(active) != 1 || active[0].SourceID != "otel-prod" {
+		t.Fatalf("got %+v, want one alert for otel-prod", active)
+	}
+}
+
+func TestEvaluate_OverridesSeverityAndCooldown(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	e := testEngine(&now, config.AlertRule{
+		Name:      "drops",
+		Condition: "drop_pct > 10",
+		Severity:  "warning",
+		Cooldown:  time.Hour,
+		Overrides: []config.RuleOverride{
+			{Match: config.Selector{"cluster": "prod-*"}, Severity: "critical", Cooldown: time.Minute},
+			{Match: config.Selector{"cluster": "prod-eu"}, Severity: "info"}, // shadowed by the first
+		},
+	})
+	prod := &pb.PipelineSnapshot{SourceId: "prod", Cluster: "prod-eu", DropPct: 20}
+	dev := &pb.PipelineSnapshot{SourceId: "dev", Cluster: "dev", DropPct: 20}
+	e.Evaluate(prod)
+	e.Evaluate(dev)
+
+	sev := make(map[string]string)
+	for _, a := range e.Active() {
+		sev[a.SourceID] = a.Severity
+	}
+	if sev["prod"] != "critical" || sev["dev"] != "warning" {
+		t.Errorf("severities: got %v, want prod=critical dev=warning", sev)
+	}
+
+	// prod re-fires after its one-minute cooldown; dev keeps its hour.
+	now = now.Add(2 * time.Minute)
+	before := make(map[string]time.Time)
+	for _, a := range e.Active() {
+		before[a.SourceID] = a.FiredAt
+	}
+	e.Evaluate(prod)
+	e.Evaluate(dev)
+	for _, a := range e.Active() {
+		refired := !a.FiredAt.Equal(before[a.SourceID])
+		if want := a.SourceID == "prod"; refired != want {
+			t.Errorf("%s re-fired = %v, want %v", a.SourceID, refired, want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Package expr parses and evaluates alert rule conditions against a
+// PipelineSnapshot.
+//
+// A condition is one or more comparisons joined with && and ||, negated with
+// ! and grouped with parentheses; && binds tighter than ||:
+//
+//	drop_pct > 10
+//	state == critical || (strength_score < 60 && cluster != staging)
+//	source_type == otelcol && signal.logs.drop_pct >= 5
+//	extra.queue_size > 5000 && !(namespace =~ "dev-.*")
+//
+// Numeric fields compare with > >= < <= == != against a number:
+//
+//	drop_pct, recovery_rate, strength_score, throughput (throughput_per_min),
+//	uptime_pct, latency_p50_ms, latency_p95_ms, latency_p99_ms,
+//	cert_days_left, extra.<key>, signal.<type>.{received_pm,dropped_pm,drop_pct}
+//
+// String fields compare with == and != against a word or quoted string, and
+// with =~ and !~ against a regular expression that must match the whole
+// value:
+//
+//	state, source_id, source_type, node_type, cluster, namespace, error_message
+//
+// Quoted strings use '...' or "..." and are taken literally, without escape
+// sequences, so regular expressions need no extra backslashes.
+//
+// A comparison on a value the snapshot does not report — an absent extra key,
+// or cert_days_left without certificates — is false whatever the operator.
+// cert_days_left holds if any certificate satisfies it. A signal type the
+// snapshot does not carry has all its stats at 0, so that
+// signal.logs.received_pm < 1 fires when logs stop arriving altogether.
+//
+// Parse reports unknown fields, operators that do not suit the field, values
+// of the wrong type, bad regular expressions and syntax errors, so a typo is
+// caught when the config is loaded instead of silently never firing.
+// Eval returns whether the condition holds and the value that triggered it:
+// that of the first numeric comparison that made it true, or 0.
+package expr
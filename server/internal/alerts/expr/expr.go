@@ -0,0 +1,353 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+)
+
+// Expr is a parsed condition. It is immutable and safe for concurrent use.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses a condition. See the package documentation for the syntax.
+func Parse(s string) (*Expr, error) {
+	toks, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tEOF {
+		return nil, fmt.Errorf("unexpected %q at column %d", t.text, t.pos+1)
+	}
+	return &Expr{src: s, root: root}, nil
+}
+
+// Eval reports whether the condition holds for snap, and the value of the
+// first numeric comparison that made it hold (0 if none did).
+func (e *Expr) Eval(snap *pb.PipelineSnapshot) (bool, float64) {
+	r := e.root.eval(snap)
+	return r.ok, r.value
+}
+
+// String returns the condition as written.
+func (e *Expr) String() string { return e.src }
+
+// --- evaluation -------------------------------------------------------------
+
+// result is the outcome of evaluating a node. hasValue is set when value is
+// the triggering value of a numeric comparison.
+type result struct {
+	ok       bool
+	value    float64
+	hasValue bool
+}
+
+type node interface {
+	eval(*pb.PipelineSnapshot) result
+}
+
+type orNode struct{ l, r node }
+
+func (n orNode) eval(s *pb.PipelineSnapshot) result {
+	if l := n.l.eval(s); l.ok {
+		return l
+	}
+	return n.r.eval(s)
+}
+
+type andNode struct{ l, r node }
+
+func (n andNode) eval(s *pb.PipelineSnapshot) result {
+	l := n.l.eval(s)
+	if !l.ok {
+		return result{}
+	}
+	r := n.r.eval(s)
+	if !r.ok {
+		return result{}
+	}
+	if l.hasValue {
+		return l
+	}
+	return r
+}
+
+type notNode struct{ x node }
+
+func (n notNode) eval(s *pb.PipelineSnapshot) result {
+	return result{ok: !n.x.eval(s).ok}
+}
+
+type numberCmp struct {
+	get  numberGetter
+	op   string
+	want float64
+}
+
+func (n numberCmp) eval(s *pb.PipelineSnapshot) result {
+	for _, v := range n.get(s) {
+		if compareFloat(v, n.op, n.want) {
+			return result{ok: true, value: v, hasValue: true}
+		}
+	}
+	return result{}
+}
+
+type stringCmp struct {
+	get  stringGetter
+	op   string
+	want string
+	re   *regexp.Regexp // for =~ and !~
+}
+
+func (n stringCmp) eval(s *pb.PipelineSnapshot) result {
+	v := n.get(s)
+	var ok bool
+	switch n.op {
+	case "==":
+		ok = v == n.want
+	case "!=":
+		ok = v != n.want
+	case "=~":
+		ok = n.re.MatchString(v)
+	case "!~":
+		ok = !n.re.MatchString(v)
+	}
+	return result{ok: ok}
+}
+
+// compareFloat applies a comparison operator to two float64 values.
+func compareFloat(v float64, op string, threshold float64) bool {
+	switch op {
+	case ">":
+		return v > threshold
+	case ">=":
+		return v >= threshold
+	case "<":
+		return v < threshold
+	case "<=":
+		return v <= threshold
+	case "==":
+		return v == threshold
+	case "!=":
+		return v != threshold
+	default:
+		return false
+	}
+}
+
+// --- parsing ----------------------------------------------------------------
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tEOF {
+		p.i++
+	}
+	return t
+}
+
+// parseOr parses: and { "||" and }
+func (p *parser) parseOr() (node, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tOr {
+		p.next()
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = orNode{l, r}
+	}
+	return l, nil
+}
+
+// parseAnd parses: unary { "&&" unary }
+func (p *parser) parseAnd() (node, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tAnd {
+		p.next()
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = andNode{l, r}
+	}
+	return l, nil
+}
+
+// parseUnary parses: "!" unary | "(" or ")" | comparison
+func (p *parser) parseUnary() (node, error) {
+	switch t := p.peek(); t.kind {
+	case tNot:
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	case tLParen:
+		p.next()
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != tRParen {
+			return nil, fmt.Errorf("missing ) for ( at column %d", t.pos+1)
+		}
+		return x, nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses: field op value
+func (p *parser) parseComparison() (node, error) {
+	f := p.next()
+	if f.kind != tWord {
+		return nil, unexpected(f, "a field name")
+	}
+	op := p.next()
+	if op.kind != tOp {
+		return nil, unexpected(op, "a comparison operator after "+f.text)
+	}
+	v := p.next()
+	if v.kind != tWord && v.kind != tString {
+		return nil, unexpected(v, "a value after "+f.text+" "+op.text)
+	}
+
+	if get, ok := numericField(f.text); ok {
+		if op.text == "=~" || op.text == "!~" {
+			return nil, fmt.Errorf("%s is numeric: operator %s needs a string field", f.text, op.text)
+		}
+		want, err := strconv.ParseFloat(v.text, 64)
+		if v.kind != tWord || err != nil {
+			return nil, fmt.Errorf("%s is numeric: %q is not a number", f.text, v.text)
+		}
+		return numberCmp{get: get, op: op.text, want: want}, nil
+	}
+
+	if get, ok := stringFields[f.text]; ok {
+		n := stringCmp{get: get, op: op.text, want: v.text}
+		switch op.text {
+		case "==", "!=":
+		case "=~", "!~":
+			re, err := regexp.Compile("^(?:" + v.text + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: bad regular expression %q: %w", f.text, op.text, v.text, err)
+			}
+			n.re = re
+		default:
+			return nil, fmt.Errorf("%s is a string: operator %s needs a numeric field", f.text, op.text)
+		}
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("unknown field %q at column %d", f.text, f.pos+1)
+}
+
+func unexpected(t token, want string) error {
+	if t.kind == tEOF {
+		return fmt.Errorf("expected %s, got end of condition", want)
+	}
+	return fmt.Errorf("expected %s, got %q at column %d", want, t.text, t.pos+1)
+}
+
+// --- lexing -----------------------------------------------------------------
+
+type tokenKind int
+
+const (
+	tEOF    tokenKind = iota
+	tWord             // field name, bare value or number
+	tString           // quoted value, quotes removed
+	tOp               // comparison operator
+	tAnd
+	tOr
+	tNot
+	tLParen
+	tRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // byte offset in the condition
+}
+
+// wordBreaks end a bare word in addition to whitespace.
+const wordBreaks = "()<>=!&|~'\""
+
+func lex(s string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		two := ""
+		if i+1 < len(s) {
+			two = s[i : i+2]
+		}
+		switch {
+		case isSpace(c):
+			i++
+		case c == '(':
+			toks = append(toks, token{tLParen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, token{tRParen, ")", i})
+			i++
+		case two == "&&":
+			toks = append(toks, token{tAnd, two, i})
+			i += 2
+		case two == "||":
+			toks = append(toks, token{tOr, two, i})
+			i += 2
+		case two == ">=" || two == "<=" || two == "==" || two == "!=" || two == "=~" || two == "!~":
+			toks = append(toks, token{tOp, two, i})
+			i += 2
+		case c == '>' || c == '<':
+			toks = append(toks, token{tOp, string(c), i})
+			i++
+		case c == '!':
+			toks = append(toks, token{tNot, "!", i})
+			i++
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at column %d", i+1)
+			}
+			toks = append(toks, token{tString, s[i+1 : i+1+end], i})
+			i += end + 2
+		case strings.IndexByte(wordBreaks, c) >= 0:
+			return nil, fmt.Errorf("unexpected %q at column %d", c, i+1)
+		default:
+			start := i
+			for i < len(s) && !isSpace(s[i]) && strings.IndexByte(wordBreaks, s[i]) < 0 {
+				i++
+			}
+			toks = append(toks, token{tWord, s[start:i], start})
+		}
+	}
+	return append(toks, token{tEOF, "", len(s)}), nil
+}
+
+func isSpace(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' }
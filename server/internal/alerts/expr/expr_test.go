@@ -0,0 +1,130 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+)
+
+func testSnapshot() *pb.PipelineSnapshot {
+	return &pb.PipelineSnapshot{
+		SourceId:      "otel-prod-1",
+		SourceType:    "otelcol",
+		NodeType:      "k8s",
+		Cluster:       "prod-eu",
+		Namespace:     "monitoring",
+		State:         "degraded",
+		DropPct:       12.5,
+		StrengthScore: 70,
+		UptimePct:     99.5,
+		Signals: []*pb.SignalStats{
+			{Type: "logs", ReceivedPm: 600, DroppedPm: 60, DropPct: 10},
+		},
+		Certs: []*pb.CertStatus{
+			{Endpoint: "https://a", DaysLeft: 90},
+			{Endpoint: "https://b", DaysLeft: 9},
+		},
+		Extra: map[string]float64{"queue_size": 5200},
+	}
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		cond  string
+		want  bool
+		value float64
+	}{
+		// The original single-comparison forms.
+		{"drop_pct > 10", true, 12.5},
+		{"drop_pct > 20", false, 0},
+		{"strength_score < 60", false, 0},
+		{"state == degraded", true, 0},
+		{"state == critical", false, 0},
+		{"cert_days_left < 14", true, 9},
+		{"cert_days_left < 5", false, 0},
+
+		{"state != healthy", true, 0},
+		{"drop_pct != 12.5", false, 0},
+		{"source_type == otelcol && cluster == prod-eu", true, 0},
+		{"state == critical || drop_pct >= 12.5", true, 12.5},
+		{"state == critical || drop_pct > 50", false, 0},
+		{"cluster == prod-eu && drop_pct > 10", true, 12.5},
+		{"(state == critical || state == degraded) && uptime_pct < 99.9", true, 99.5},
+		{"!(state == healthy)", true, 0},
+		{"!drop_pct > 10", false, 0},
+		{"state == healthy || state == degraded && drop_pct > 50", false, 0}, // && binds tighter
+
+		{"extra.queue_size > 5000", true, 5200},
+		{"extra.missing > 0", false, 0},
+		{"extra.missing != 1", false, 0},
+		{"signal.logs.drop_pct >= 10", true, 10},
+		{"signal.logs.received_pm < 100", false, 0},
+		{"signal.traces.drop_pct >= 0", true, 0}, // absent: 0
+		{"signal.traces.received_pm < 1", true, 0},
+		{"signal.traces.received_pm == 0", true, 0},
+
+		{`source_id =~ "otel-prod-\d+"`, true, 0},
+		{`source_id =~ 'otel'`, false, 0}, // anchored: must match the whole value
+		{`cluster !~ "staging|dev-.*"`, true, 0},
+		{`namespace == "monitoring"`, true, 0},
+		{`node_type == k8s && error_message == ""`, true, 0},
+	}
+	snap := testSnapshot()
+	for _, tt := range tests {
+		t.Run(tt.cond, func(t *testing.T) {
+			e, err := Parse(tt.cond)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got, value := e.Eval(snap)
+			if got != tt.want || value != tt.value {
+				t.Errorf("Eval = (%v, %v), want (%v, %v)", got, value, tt.want, tt.value)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]string{
+		"drop_pcct > 10":              `unknown field "drop_pcct"`,
+		"extra. > 1":                  `unknown field "extra."`,
+		"signal.logs.lag > 1":         `unknown field "signal.logs.lag"`,
+		"drop_pct > ten":              `"ten" is not a number`,
+		`drop_pct > "10"`:             `"10" is not a number`,
+		"drop_pct =~ 1":               "needs a string field",
+		"state > critical":            "needs a numeric field",
+		`cluster =~ "prod-("`:         "bad regular expression",
+		"drop_pct 10":                 "expected a comparison operator",
+		"drop_pct >":                  "got end of condition",
+		"drop_pct > 10 &&":            "expected a field name",
+		"(drop_pct > 10":              "missing )",
+		"drop_pct > 10)":              `unexpected ")"`,
+		"drop_pct > 10 & state == up": "unexpected '&'",
+		"drop_pct = 10":               "unexpected '='",
+		`cluster == "prod`:            "unterminated string",
+		"":                            "got end of condition",
+	}
+	for cond, want := range tests {
+		t.Run(cond, func(t *testing.T) {
+			_, err := Parse(cond)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not contain %q", err, want)
+			}
+		})
+	}
+}
+
+func TestExpr_String(t *testing.T) {
+	const cond = "drop_pct > 10 && cluster == prod"
+	e, err := Parse(cond)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if e.String() != cond {
+		t.Errorf("String() = %q, want %q", e.String(), cond)
+	}
+}
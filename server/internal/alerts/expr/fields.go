@@ -0,0 +1,94 @@
+package expr
+
+import (
+	"strings"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+)
+
+// numberGetter returns the values of a numeric field in a snapshot. It
+// returns none when the snapshot does not report the field and several for
+// cert_days_left. A signal the snapshot does not carry reports 0: nothing
+// received or dropped.
+type numberGetter func(*pb.PipelineSnapshot) []float64
+
+// stringGetter returns the value of a string field in a snapshot.
+type stringGetter func(*pb.PipelineSnapshot) string
+
+// numericFields are the scalar numeric snapshot fields.
+var numericFields = map[string]func(*pb.PipelineSnapshot) float64{
+	"drop_pct":           func(s *pb.PipelineSnapshot) float64 { return s.DropPct },
+	"recovery_rate":      func(s *pb.PipelineSnapshot) float64 { return s.RecoveryRate },
+	"strength_score":     func(s *pb.PipelineSnapshot) float64 { return s.StrengthScore },
+	"throughput":         func(s *pb.PipelineSnapshot) float64 { return s.ThroughputPerMin },
+	"throughput_per_min": func(s *pb.PipelineSnapshot) float64 { return s.ThroughputPerMin },
+	"uptime_pct":         func(s *pb.PipelineSnapshot) float64 { return s.UptimePct },
+	"latency_p50_ms":     func(s *pb.PipelineSnapshot) float64 { return s.LatencyP50Ms },
+	"latency_p95_ms":     func(s *pb.PipelineSnapshot) float64 { return s.LatencyP95Ms },
+	"latency_p99_ms":     func(s *pb.PipelineSnapshot) float64 { return s.LatencyP99Ms },
+}
+
+// signalStats are the per-signal fields, named signal.<type>.<stat>.
+var signalStats = map[string]func(*pb.SignalStats) float64{
+	"received_pm": func(s *pb.SignalStats) float64 { return s.ReceivedPm },
+	"dropped_pm":  func(s *pb.SignalStats) float64 { return s.DroppedPm },
+	"drop_pct":    func(s *pb.SignalStats) float64 { return s.DropPct },
+}
+
+// stringFields are the string snapshot fields.
+var stringFields = map[string]stringGetter{
+	"state":         func(s *pb.PipelineSnapshot) string { return s.State },
+	"source_id":     func(s *pb.PipelineSnapshot) string { return s.SourceId },
+	"source_type":   func(s *pb.PipelineSnapshot) string { return s.SourceType },
+	"node_type":     func(s *pb.PipelineSnapshot) string { return s.NodeType },
+	"cluster":       func(s *pb.PipelineSnapshot) string { return s.Cluster },
+	"namespace":     func(s *pb.PipelineSnapshot) string { return s.Namespace },
+	"error_message": func(s *pb.PipelineSnapshot) string { return s.ErrorMessage },
+}
+
+// numericField returns the getter for a numeric field name, or false if name
+// is not a numeric field.
+func numericField(name string) (numberGetter, bool) {
+	if get, ok := numericFields[name]; ok {
+		return func(s *pb.PipelineSnapshot) []float64 { return []float64{get(s)} }, true
+	}
+	if name == "cert_days_left" {
+		return certDaysLeft, true
+	}
+	if key, ok := strings.CutPrefix(name, "extra."); ok && key != "" {
+		return func(s *pb.PipelineSnapshot) []float64 {
+			if v, ok := s.Extra[key]; ok {
+				return []float64{v}
+			}
+			return nil
+		}, true
+	}
+	if rest, ok := strings.CutPrefix(name, "signal."); ok {
+		i := strings.LastIndexByte(rest, '.')
+		if i <= 0 {
+			return nil, false
+		}
+		typ, stat := rest[:i], rest[i+1:]
+		get, ok := signalStats[stat]
+		if !ok {
+			return nil, false
+		}
+		return func(s *pb.PipelineSnapshot) []float64 {
+			for _, sig := range s.Signals {
+				if sig.Type == typ {
+					return []float64{get(sig)}
+				}
+			}
+			return []float64{0}
+		}, true
+	}
+	return nil, false
+}
+
+func certDaysLeft(s *pb.PipelineSnapshot) []float64 {
+	out := make([]float64, len(s.Certs))
+	for i, c := range s.Certs {
+		out[i] = float64(c.DaysLeft)
+	}
+	return out
+}
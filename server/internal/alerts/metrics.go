@@ -0,0 +1,30 @@
+package alerts
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	deliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "obsidian_server_webhook_deliveries_total",
+		Help: "Webhook notifications attempted, by webhook type.",
+	}, []string{"type"})
+
+	deliveryFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "obsidian_server_webhook_delivery_failures_total",
+		Help: "Webhook notifications that could not be delivered, by webhook type.",
+	}, []string{"type"})
+
+	retries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "obsidian_server_webhook_retries_total",
+		Help: "Webhook requests retried after a transient failure, by webhook type.",
+	}, []string{"type"})
+
+	storeErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "obsidian_server_alert_store_errors_total",
+		Help: "Alert state changes that could not be persisted.",
+	})
+)
+
+// Collectors returns the alerting engine's internal metrics for registration.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{deliveries, deliveryFailures, retries, storeErrors}
+}
@@ -0,0 +1,101 @@
+package alerts
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/server/internal/alerts/payload"
+	"github.com/obsidianstack/obsidianstack/server/internal/diagnostics"
+)
+
+// maxPagerDutySummary is the longest summary the Events API v2 accepts.
+const maxPagerDutySummary = 1024
+
+// pdEvent is a PagerDuty Events API v2 event.
+type pdEvent struct {
+	RoutingKey  string     `json:"routing_key"`
+	EventAction string     `json:"event_action"` // "trigger" | "resolve"
+	DedupKey    string     `json:"dedup_key"`
+	Payload     *pdPayload `json:"payload,omitempty"` // trigger only
+	Client      string     `json:"client,omitempty"`
+}
+
+type pdPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"` // critical | error | warning | info
+	Timestamp     string                 `json:"timestamp,omitempty"`
+	Component     string                 `json:"component,omitempty"`
+	Group         string                 `json:"group,omitempty"`
+	Class         string                 `json:"class,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+// pagerDutyBody returns a as an Events API v2 trigger, or a resolve once it
+// has resolved. Every fire of a rule on a source shares one dedup key, so
+// re-fires update the same incident and the resolve closes it.
+func pagerDutyBody(routingKey string, a *Alert, snap *pb.PipelineSnapshot) ([]byte, error) {
+	if routingKey == "" {
+		return nil, errors.New("pagerduty: routing key is empty")
+	}
+	ev := pdEvent{
+		RoutingKey:  routingKey,
+		EventAction: "trigger",
+		DedupKey:    pagerDutyDedupKey(a),
+		Client:      "ObsidianStack",
+	}
+	if a.State == StateResolved {
+		ev.EventAction = "resolve"
+	} else {
+		ev.Payload = &pdPayload{
+			Summary:       payload.Truncate(maxPagerDutySummary, a.Message),
+			Source:        a.SourceID,
+			Severity:      pagerDutySeverity(a.Severity),
+			Timestamp:     a.FiredAt.UTC().Format(time.RFC3339),
+			Component:     a.labels["source_type"],
+			Group:         a.labels["cluster"],
+			Class:         a.RuleName,
+			CustomDetails: pagerDutyDetails(a, snap),
+		}
+	}
+	return json.Marshal(ev)
+}
+
+// pagerDutyDedupKey identifies the incident for a's rule and source.
+func pagerDutyDedupKey(a *Alert) string {
+	return "obsidianstack:" + a.RuleName + ":" + a.SourceID
+}
+
+// pagerDutySeverity maps an alert severity to a PagerDuty one.
+func pagerDutySeverity(s string) string {
+	switch s {
+	case "critical", "warning", "info":
+		return s
+	default:
+		return "error"
+	}
+}
+
+// pagerDutyDetails returns the custom_details of a trigger: the alert, the
+// pipeline labels and, when known, the snapshot fields and diagnostic hints.
+func pagerDutyDetails(a *Alert, snap *pb.PipelineSnapshot) map[string]interface{} {
+	details := map[string]interface{}{
+		"alert_id": a.ID,
+		"rule":     a.RuleName,
+		"value":    a.Value,
+		"labels":   a.labels,
+	}
+	if snap == nil {
+		return details
+	}
+	opts := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	if b, err := opts.Marshal(snap); err == nil {
+		details["snapshot"] = json.RawMessage(b)
+	}
+	details["diagnostics"] = diagnostics.Compute(snap)
+	return details
+}
@@ -0,0 +1,90 @@
+package alerts
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+func TestPagerDuty_TriggerAndResolve(t *testing.T) {
+	events := make(chan pdEvent, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev pdEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		events <- ev
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+	t.Setenv("PD_URL", srv.URL)
+	t.Setenv("PD_KEY", "R0UT1NGK3Y")
+
+	now := time.Unix(1_700_000_000, 0)
+	e := New(config.AlertsConfig{
+		Rules:    []config.AlertRule{{Name: "drops", Condition: "drop_pct > 10", Severity: "critical"}},
+		Webhooks: []config.WebhookConfig{{Type: "pagerduty", URLEnv: "PD_URL", RoutingKeyEnv: "PD_KEY"}},
+	})
+	e.now = func() time.Time { return now }
+	next := func() pdEvent {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event received")
+			return pdEvent{}
+		}
+	}
+
+	bad := &pb.PipelineSnapshot{SourceId: "otel", SourceType: "otelcol", Cluster: "prod-eu", DropPct: 20}
+	e.Evaluate(bad)
+	ev := next()
+	if ev.EventAction != "trigger" || ev.RoutingKey != "R0UT1NGK3Y" || ev.DedupKey != "obsidianstack:drops:otel" {
+		t.Errorf("trigger: got %+v", ev)
+	}
+	p := ev.Payload
+	if p == nil || p.Severity != "critical" || p.Source != "otel" || p.Component != "otelcol" || p.Group != "prod-eu" || p.Class != "drops" {
+		t.Fatalf("payload: got %+v", p)
+	}
+	snap, _ := p.CustomDetails["snapshot"].(map[string]interface{})
+	if snap["drop_pct"] != 20.0 {
+		t.Errorf("custom_details.snapshot: got %v", p.CustomDetails["snapshot"])
+	}
+	if hints, _ := p.CustomDetails["diagnostics"].([]interface{}); len(hints) == 0 {
+		t.Errorf("custom_details.diagnostics: got %v", p.CustomDetails["diagnostics"])
+	}
+
+	// A re-fire after the cooldown updates the same incident.
+	now = now.Add(16 * time.Minute)
+	e.Evaluate(bad)
+	if ev := next(); ev.DedupKey != "obsidianstack:drops:otel" || ev.EventAction != "trigger" {
+		t.Errorf("re-fire: got %+v", ev)
+	}
+
+	now = now.Add(time.Minute)
+	e.Evaluate(&pb.PipelineSnapshot{SourceId: "otel"})
+	if ev := next(); ev.EventAction != "resolve" || ev.DedupKey != "obsidianstack:drops:otel" || ev.Payload != nil {
+		t.Errorf("resolve: got %+v", ev)
+	}
+}
+
+func TestPagerDuty_RequiresRoutingKey(t *testing.T) {
+	a := &Alert{RuleName: "drops", SourceID: "otel", State: StateFiring}
+	if _, err := pagerDutyBody("", a, nil); err == nil {
+		t.Error("empty routing key: want an error")
+	}
+}
+
+func TestPagerDutySeverity(t *testing.T) {
+	for in, want := range map[string]string{"critical": "critical", "warning": "warning", "info": "info", "": "error"} {
+		if got := pagerDutySeverity(in); got != want {
+			t.Errorf("pagerDutySeverity(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
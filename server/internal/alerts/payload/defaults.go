@@ -0,0 +1,83 @@
+package payload
+
+// Built-in templates, by webhook type.
+const (
+	// slackDefault is a Slack Block Kit message: a header, the alert message,
+	// its source, severity and value, the diagnostic hints of a firing alert
+	// and a button to the pipeline. text is the fallback for notifications.
+	slackDefault = `{
+  "text": {{json (printf "%s %s on %s" (upper .Alert.State) .Alert.RuleName .Alert.SourceID)}},
+  "blocks": [
+    {"type": "header", "text": {"type": "plain_text", "text": {{json (truncate 150 (printf "%s %s: %s" (icon .Alert) (upper .Alert.State) .Alert.RuleName))}}}},
+    {"type": "section", "text": {"type": "mrkdwn", "text": {{if eq .Alert.State "resolved"}}{{json (printf "%s on %s has resolved." .Alert.RuleName .Alert.SourceID)}}{{else}}{{json (truncate 3000 .Alert.Message)}}{{end}}}},
+    {"type": "section", "fields": [
+      {"type": "mrkdwn", "text": {{json (printf "*Source*\n%s" .Alert.SourceID)}}},
+      {"type": "mrkdwn", "text": {{json (printf "*Severity*\n%s" .Alert.Severity)}}},
+      {"type": "mrkdwn", "text": {{json (printf "*Value*\n%.2f" .Alert.Value)}}},
+      {"type": "mrkdwn", "text": {{json (printf "*Pipeline state*\n%s" (or .Snapshot.State "unknown"))}}}
+    ]}
+    {{- if ne .Alert.State "resolved"}}{{range .Diagnostics}}{{if ne .Level "ok"}},
+    {"type": "context", "elements": [{"type": "mrkdwn", "text": {{json (truncate 3000 (printf "*%s* (%s) — %s" .Title .Level .Detail))}}}]}
+    {{- end}}{{end}}{{end}}
+    {{- if .PipelineURL}},
+    {"type": "actions", "elements": [{"type": "button", "text": {"type": "plain_text", "text": "Open pipeline"}, "url": {{json .PipelineURL}}}]}
+    {{- end}}
+  ]
+}`
+
+	// teamsDefault is a Microsoft Teams message carrying an Adaptive Card
+	// with the same content as slackDefault.
+	teamsDefault = `{
+  "type": "message",
+  "attachments": [{
+    "contentType": "application/vnd.microsoft.card.adaptive",
+    "content": {
+      "$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+      "type": "AdaptiveCard",
+      "version": "1.4",
+      "msteams": {"width": "Full"},
+      "body": [
+        {"type": "TextBlock", "size": "Large", "weight": "Bolder", "wrap": true,
+         "color": {{if eq .Alert.State "resolved"}}"Good"{{else if eq .Alert.Severity "critical"}}"Attention"{{else if eq .Alert.Severity "warning"}}"Warning"{{else}}"Accent"{{end}},
+         "text": {{json (printf "%s %s: %s" (icon .Alert) (upper .Alert.State) .Alert.RuleName)}}},
+        {"type": "TextBlock", "wrap": true, "text": {{if eq .Alert.State "resolved"}}{{json (printf "%s on %s has resolved." .Alert.RuleName .Alert.SourceID)}}{{else}}{{json .Alert.Message}}{{end}}},
+        {"type": "FactSet", "facts": [
+          {"title": "Source", "value": {{json .Alert.SourceID}}},
+          {"title": "Severity", "value": {{json .Alert.Severity}}},
+          {"title": "Value", "value": {{json (printf "%.2f" .Alert.Value)}}},
+          {"title": "Pipeline state", "value": {{json (or .Snapshot.State "unknown")}}}
+        ]}
+        {{- if ne .Alert.State "resolved"}}{{range .Diagnostics}}{{if ne .Level "ok"}},
+        {"type": "TextBlock", "wrap": true, "isSubtle": true, "text": {{json (printf "**%s** (%s) — %s" .Title .Level .Detail)}}}
+        {{- end}}{{end}}{{end}}
+      ]
+      {{- if .PipelineURL}},
+      "actions": [{"type": "Action.OpenUrl", "title": "Open pipeline", "url": {{json .PipelineURL}}}]
+      {{- end}}
+    }
+  }]
+}`
+
+	// httpDefault is the alert as JSON.
+	httpDefault = `{"alert": {{json .Alert}}}`
+)
+
+var defaults = map[string]*Template{
+	"slack": mustParse(slackDefault),
+	"teams": mustParse(teamsDefault),
+	"http":  mustParse(httpDefault),
+}
+
+// Default returns the built-in template for webhookType ("slack", "teams"
+// or "http"), or nil for a type without one.
+func Default(webhookType string) *Template {
+	return defaults[webhookType]
+}
+
+func mustParse(text string) *Template {
+	t, err := Parse(text)
+	if err != nil {
+		panic("payload: built-in template: " + err.Error())
+	}
+	return t
+}
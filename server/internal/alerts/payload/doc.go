@@ -0,0 +1,21 @@
+// Package payload renders webhook notification bodies from Go text/template
+// templates.
+//
+// A template renders Data: the Alert, the PipelineSnapshot it was evaluated
+// against, the snapshot's diagnostic hints, and links to the dashboard and
+// the pipeline on it. The result must be JSON; the json function quotes any
+// value for it:
+//
+//	{"text": {{json (printf "%s fired on %s" .Alert.RuleName .Alert.SourceID)}},
+//	 "drop_pct": {{.Snapshot.DropPct}}}
+//
+// Besides the text/template built-ins, templates can call json, upper,
+// lower, truncate (truncate 80 .Alert.Message) and icon (an emoji for the
+// alert's state and severity: icon .Alert).
+//
+// Parse rejects a template that does not parse, fails to render, or renders
+// something other than JSON for a sample firing and a sample resolved
+// alert, so that config.Load catches template errors. Default returns the
+// built-in template for a webhook type: Slack Block Kit, a Teams Adaptive
+// Card, and {"alert": ...} for http.
+package payload
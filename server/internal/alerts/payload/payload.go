@@ -0,0 +1,171 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/server/internal/diagnostics"
+)
+
+// Data is what a template renders.
+type Data struct {
+	Alert       Alert
+	Snapshot    *pb.PipelineSnapshot // never nil; empty when not known
+	Diagnostics []diagnostics.Hint   // for Snapshot
+
+	// DashboardURL is the configured dashboard_url, and PipelineURL the
+	// alert's pipeline on it; both are empty without one.
+	DashboardURL string
+	PipelineURL  string
+}
+
+// Alert is the alert a notification is about, as templates see it.
+type Alert struct {
+	ID         string            `json:"id"`
+	RuleName   string            `json:"rule_name"`
+	SourceID   string            `json:"source_id"`
+	Severity   string            `json:"severity"`
+	Message    string            `json:"message"`
+	Value      float64           `json:"value"`
+	ActiveAt   time.Time         `json:"active_at"`
+	FiredAt    time.Time         `json:"fired_at,omitzero"`
+	ResolvedAt *time.Time        `json:"resolved_at,omitempty"`
+	State      string            `json:"state"` // "firing" | "resolved"
+	Labels     map[string]string `json:"labels,omitempty"`
+
+	Silenced       bool       `json:"silenced"`
+	SilencedBy     []string   `json:"silenced_by,omitempty"`
+	AcknowledgedBy string     `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	Note           string     `json:"note,omitempty"`
+}
+
+// Template is a parsed notification template.
+type Template struct {
+	t *template.Template
+}
+
+var funcs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"truncate": Truncate,
+	"icon":     icon,
+}
+
+// icon returns an emoji for a's state and severity.
+func icon(a Alert) string {
+	switch {
+	case a.State == "resolved":
+		return "✅"
+	case a.Severity == "critical":
+		return "🔴"
+	case a.Severity == "warning":
+		return "🟠"
+	default:
+		return "🔵"
+	}
+}
+
+// Parse parses text and checks that it renders JSON for sample alerts.
+func Parse(text string) (*Template, error) {
+	t, err := template.New("payload").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	tpl := &Template{t: t}
+	for _, d := range samples() {
+		if _, err := tpl.Execute(d); err != nil {
+			return nil, fmt.Errorf("rendering a %s alert: %w", d.Alert.State, err)
+		}
+	}
+	return tpl, nil
+}
+
+// Execute renders d. It fails if the result is not valid JSON.
+func (t *Template) Execute(d Data) ([]byte, error) {
+	if d.Snapshot == nil {
+		d.Snapshot = &pb.PipelineSnapshot{}
+	}
+	var buf bytes.Buffer
+	if err := t.t.Execute(&buf, d); err != nil {
+		return nil, err
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("template output is not valid JSON: %s", Truncate(200, buf.String()))
+	}
+	return buf.Bytes(), nil
+}
+
+// PipelineURL returns the page of the pipeline sourceID on the dashboard at
+// dashboardURL, or "" without a dashboard URL.
+func PipelineURL(dashboardURL, sourceID string) string {
+	if dashboardURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(dashboardURL, "/") + "/pipelines?source=" + url.QueryEscape(sourceID)
+}
+
+// Truncate shortens s to at most n bytes, ending it with an ellipsis when
+// cut, without splitting a UTF-8 sequence.
+func Truncate(n int, s string) string {
+	if len(s) <= n {
+		return s
+	}
+	i := max(n-len("…"), 0)
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return s[:i] + "…"
+}
+
+// samples returns Data for a firing and a resolved alert, to check
+// templates with.
+func samples() []Data {
+	fired := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	resolved := fired.Add(10 * time.Minute)
+	snap := &pb.PipelineSnapshot{
+		SourceId:         "otel-prod",
+		SourceType:       "otelcol",
+		Cluster:          "prod-eu",
+		State:            "critical",
+		DropPct:          12.5,
+		ThroughputPerMin: 1200,
+		StrengthScore:    41,
+		Signals:          []*pb.SignalStats{{Type: "logs", ReceivedPm: 1200, DroppedPm: 150, DropPct: 12.5}},
+		Extra:            map[string]float64{"queue_size": 4800},
+	}
+	alert := Alert{
+		ID:       "high-drop-rate:otel-prod:1767268800000000000",
+		RuleName: "high-drop-rate",
+		SourceID: "otel-prod",
+		Severity: "critical",
+		Message:  "[critical] high-drop-rate fired on otel-prod — drop_pct > 10 (value 12.50)",
+		Value:    12.5,
+		ActiveAt: fired.Add(-2 * time.Minute),
+		FiredAt:  fired,
+		State:    "firing",
+		Labels:   map[string]string{"source_id": "otel-prod", "source_type": "otelcol", "cluster": "prod-eu"},
+	}
+	firing := Data{
+		Alert:        alert,
+		Snapshot:     snap,
+		Diagnostics:  diagnostics.Compute(snap),
+		DashboardURL: "https://obsidian.example.com",
+		PipelineURL:  PipelineURL("https://obsidian.example.com", "otel-prod"),
+	}
+	res := firing
+	res.Alert.State = "resolved"
+	res.Alert.ResolvedAt = &resolved
+	return []Data{firing, res}
+}
@@ -0,0 +1,99 @@
+package payload
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]string{
+		"syntax":          `{"text": {{json .Alert.RuleName}`,
+		"unknown func":    `{"text": {{shout .Alert.RuleName}}}`,
+		"unknown field":   `{"text": {{json .Alert.Rule}}}`,
+		"nil resolved at": `{"resolved": {{json (.Alert.ResolvedAt.Format "15:04")}}}`,
+		"not json":        `{"text": {{.Alert.RuleName}}}`,
+	}
+	for name, text := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse(text); err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestExecute_Custom(t *testing.T) {
+	tpl, err := Parse(`{"summary": {{json (printf "%s/%s" .Alert.RuleName (index .Alert.Labels "cluster"))}},
+  "drop_pct": {{.Snapshot.DropPct}}, "hints": {{len .Diagnostics}}, "link": {{json .PipelineURL}}}`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	d := samples()[0]
+	b, err := tpl.Execute(d)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	var got struct {
+		Summary string  `json:"summary"`
+		DropPct float64 `json:"drop_pct"`
+		Hints   int     `json:"hints"`
+		Link    string  `json:"link"`
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("unmarshal %s: %v", b, err)
+	}
+	if got.Summary != "high-drop-rate/prod-eu" || got.DropPct != 12.5 || got.Hints == 0 ||
+		got.Link != "https://obsidian.example.com/pipelines?source=otel-prod" {
+		t.Errorf("got %+v", got)
+	}
+
+	// Without a snapshot, templates see an empty one.
+	d.Snapshot = nil
+	if _, err := tpl.Execute(d); err != nil {
+		t.Errorf("Execute without snapshot: %v", err)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	for _, typ := range []string{"slack", "teams", "http"} {
+		for _, d := range samples() {
+			b, err := Default(typ).Execute(d)
+			if err != nil {
+				t.Fatalf("%s, %s alert: %v", typ, d.Alert.State, err)
+			}
+			if !strings.Contains(string(b), "high-drop-rate") {
+				t.Errorf("%s, %s alert: rule name missing from %s", typ, d.Alert.State, b)
+			}
+		}
+	}
+	firing, _ := Default("slack").Execute(samples()[0])
+	var slack struct {
+		Blocks []struct{ Type string } `json:"blocks"`
+	}
+	json.Unmarshal(firing, &slack) //nolint:errcheck // valid JSON
+	if n := len(slack.Blocks); n < 4 || slack.Blocks[0].Type != "header" || slack.Blocks[n-1].Type != "actions" {
+		t.Errorf("slack blocks: got %+v", slack.Blocks)
+	}
+	if teams, _ := Default("teams").Execute(samples()[0]); !strings.Contains(string(teams), "application/vnd.microsoft.card.adaptive") {
+		t.Error("teams: no Adaptive Card attachment")
+	}
+	if Default("pagerduty") != nil {
+		t.Error("pagerduty: want no built-in template")
+	}
+}
+
+func TestTruncateAndPipelineURL(t *testing.T) {
+	if got := Truncate(5, "héllo wörld"); got != "h…" {
+		t.Errorf("Truncate: got %q", got)
+	}
+	if got := Truncate(20, "short"); got != "short" {
+		t.Errorf("Truncate: got %q", got)
+	}
+	if got := PipelineURL("https://o.example.com/", "a b"); got != "https://o.example.com/pipelines?source=a+b" {
+		t.Errorf("PipelineURL: got %q", got)
+	}
+	if got := PipelineURL("", "otel"); got != "" {
+		t.Errorf("PipelineURL without dashboard: got %q", got)
+	}
+}
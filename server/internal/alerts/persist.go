@@ -0,0 +1,174 @@
+package alerts
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// storeTimeout bounds each write to the Store.
+const storeTimeout = 5 * time.Second
+
+// change is an alert state change to write to the Store. Changes are
+// recorded in order under e.mu and written by flush once it is released.
+type change struct {
+	key      string
+	save     *StoredAlert // new active state
+	delete   bool         // active state removed
+	fired    time.Time    // new last fire time, if non-zero
+	resolved *Alert       // appended to the history
+	silence  *Silence     // new or changed silence
+}
+
+// SetStore makes the engine persist alert state to st, after restoring what
+// st holds: the pending and firing alerts of rules that are still
+// configured, cooldown timers, silences, and the alerts resolved within the
+// past hour.
+// Restored firing alerts wait out keep_firing_for again before resolving.
+// Must be called before the engine is used.
+func (e *Engine) SetStore(ctx context.Context, st Store) error {
+	stored, fires, err := st.LoadActive(ctx)
+	if err != nil {
+		return err
+	}
+	now := e.now()
+	recent, _, err := st.QueryHistory(ctx, HistoryQuery{
+		From:  now.Add(-recentWindowHours * time.Hour),
+		Limit: maxHistoryLen,
+	})
+	if err != nil {
+		return err
+	}
+	silences, err := st.LoadSilences(ctx)
+	if err != nil {
+		return err
+	}
+
+	rules := make(map[string]bool, len(e.rules))
+	for _, r := range e.rules {
+		rules[r.Name] = true
+	}
+	var restored int
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, s := range stored {
+		if !rules[s.Alert.RuleName] {
+			slog.Info("alerts: dropping stored alert of removed rule", "rule", s.Alert.RuleName, "source", s.Alert.SourceID)
+			if err := st.DeleteActive(ctx, s.Key); err != nil {
+				return err
+			}
+			continue
+		}
+		a := s.Alert
+		a.labels = s.Labels
+		a.notified = s.Notified
+		a.lastMatch = now
+		e.active[s.Key] = &a
+		restored++
+	}
+	for key, at := range fires {
+		e.lastFire[key] = at
+	}
+	for i := len(recent) - 1; i >= 0; i-- { // oldest first
+		e.history = append(e.history, recent[i])
+	}
+	for _, s := range silences {
+		s.State = ""
+		e.silences = append(e.silences, &s)
+	}
+	e.pruneSilences(now)
+	e.store = st
+
+	slog.Info("alerts: state restored",
+		"active", restored,
+		"cooldowns", len(fires),
+		"silences", len(e.silences),
+		"recently_resolved", len(recent),
+	)
+	return nil
+}
+
+// History returns the resolved alerts matching q, most recently resolved
+// first, and the total number matching. Without a Store it searches the
+// alerts resolved since the server started, up to the last 200.
+func (e *Engine) History(ctx context.Context, q HistoryQuery) ([]*Alert, int, error) {
+	e.mu.Lock()
+	st := e.store
+	var matched []*Alert
+	if st == nil {
+		for i := len(e.history) - 1; i >= 0; i-- {
+			if a := e.history[i]; q.matches(a) {
+				cp := *a
+				matched = append(matched, &cp)
+			}
+		}
+	}
+	e.mu.Unlock()
+
+	if st != nil {
+		return st.QueryHistory(ctx, q)
+	}
+	total := len(matched)
+	matched = matched[min(q.Offset, total):]
+	if q.Limit > 0 && len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return matched, total, nil
+}
+
+// record queues c for the Store, if one is set. Must be called with e.mu
+// held.
+func (e *Engine) record(c change) {
+	if e.store != nil {
+		e.changes = append(e.changes, c)
+	}
+}
+
+// saveChange returns a change storing the current state of the active
+// alert a under key. Must be called with e.mu held.
+func saveChange(key string, a *Alert) change {
+	return change{key: key, save: &StoredAlert{
+		Key:      key,
+		Alert:    *a,
+		Labels:   a.labels,
+		Notified: a.notified,
+	}}
+}
+
+// flush writes the queued changes to the Store in the order they were
+// recorded. Errors are logged: the in-memory state stays authoritative.
+func (e *Engine) flush() {
+	e.flushMu.Lock()
+	defer e.flushMu.Unlock()
+
+	e.mu.Lock()
+	st, changes := e.store, e.changes
+	e.changes = nil
+	e.mu.Unlock()
+
+	for _, c := range changes {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		var err error
+		if c.save != nil {
+			err = st.SaveActive(ctx, *c.save)
+		}
+		if c.delete && err == nil {
+			err = st.DeleteActive(ctx, c.key)
+		}
+		if !c.fired.IsZero() && err == nil {
+			err = st.SaveFire(ctx, c.key, c.fired)
+		}
+		if c.resolved != nil && err == nil {
+			err = st.AppendHistory(ctx, c.resolved)
+		}
+		if c.silence != nil && err == nil {
+			err = st.SaveSilence(ctx, *c.silence)
+		}
+		cancel()
+		if err != nil {
+			storeErrors.Inc()
+			slog.Error("alerts: persisting alert state failed", "key", c.key, "err", err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package alerts
+
+import (
+	"slices"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+// newRouting returns the webhooks of each receiver in cfg, including the
+// default receiver formed by cfg.Webhooks, and the root of the routing tree.
+// Without a configured route, or when the root names no receiver, alerts
+// go to the default receiver.
+func newRouting(cfg config.AlertsConfig) (map[string][]config.WebhookConfig, config.Route) {
+	receivers := make(map[string][]config.WebhookConfig, len(cfg.Receivers)+1)
+	if len(cfg.Webhooks) > 0 {
+		receivers[config.DefaultReceiver] = cfg.Webhooks
+	}
+	for _, r := range cfg.Receivers {
+		receivers[r.Name] = r.Webhooks
+	}
+	var root config.Route
+	if cfg.Route != nil {
+		root = *cfg.Route
+	}
+	if root.Receiver == "" {
+		root.Receiver = config.DefaultReceiver
+	}
+	return receivers, root
+}
+
+// receiversFor returns the names of the receivers a is sent to: the rule's
+// own receiver if it names one, otherwise those the routing tree picks.
+func (e *Engine) receiversFor(a *Alert) []string {
+	if r := e.ruleReceivers[a.RuleName]; r != "" {
+		return []string{r}
+	}
+	var names []string
+	route(e.route, e.route.Receiver, a, &names)
+	return names
+}
+
+// route appends to names the receivers r picks for a, which r is known to
+// match. Matching children are tried in order, stopping after the first one
+// without continue set; r's own receiver, or the inherited one when it
+// names none, is used only if no child matched.
+func route(r config.Route, inherited string, a *Alert, names *[]string) {
+	receiver := r.Receiver
+	if receiver == "" {
+		receiver = inherited
+	}
+	var matched bool
+	for _, child := range r.Routes {
+		if !routeMatches(child, a) {
+			continue
+		}
+		matched = true
+		route(child, receiver, a, names)
+		if !child.Continue {
+			break
+		}
+	}
+	if !matched && !slices.Contains(*names, receiver) {
+		*names = append(*names, receiver)
+	}
+}
+
+// routeMatches reports whether a passes r's severity, rule and selector
+// matchers.
+func routeMatches(r config.Route, a *Alert) bool {
+	if len(r.Severity) > 0 && !slices.Contains(r.Severity, a.Severity) {
+		return false
+	}
+	return matchesRule(r.Rule, a.RuleName) && r.Match.Matches(a.labels)
+}
@@ -0,0 +1,99 @@
+package alerts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+func TestReceiversFor(t *testing.T) {
+	e := New(config.AlertsConfig{
+		Rules: []config.AlertRule{
+			{Name: "drops", Condition: "drop_pct > 10"},
+			{Name: "cert_expiry", Condition: "cert_days_left < 14", Receiver: "platform"},
+		},
+		Webhooks: []config.WebhookConfig{{Type: "slack", URLEnv: "SLACK_URL"}},
+		Receivers: []config.Receiver{
+			{Name: "pagerduty"}, {Name: "payments"}, {Name: "platform"}, {Name: "audit"},
+		},
+		Route: &config.Route{
+			Receiver: "default",
+			Routes: []config.Route{
+				{Rule: "drop*", Receiver: "audit", Continue: true},
+				{Severity: []string{"critical"}, Receiver: "pagerduty", Routes: []config.Route{
+					{Match: config.Selector{"namespace": "payments"}, Receiver: "payments", Continue: true},
+					{Match: config.Selector{"cluster": "prod-*"}},
+				}},
+				{Rule: "drop*", Match: config.Selector{"cluster": "!prod-*"}, Receiver: "platform"},
+			},
+		},
+	})
+
+	tests := []struct {
+		name   string
+		alert  Alert
+		labels map[string]string
+		want   []string
+	}{
+		{"no route matches", Alert{RuleName: "degraded", Severity: "warning"}, nil, []string{"default"}},
+		{"severity", Alert{RuleName: "degraded", Severity: "critical"}, nil, []string{"pagerduty"}},
+		{"continue into sibling", Alert{RuleName: "drops", Severity: "critical"},
+			map[string]string{"namespace": "payments", "cluster": "prod-eu"}, []string{"audit", "payments", "pagerduty"}},
+		{"child inherits receiver", Alert{RuleName: "drops", Severity: "critical"},
+			map[string]string{"cluster": "prod-eu"}, []string{"audit", "pagerduty"}},
+		{"rule and selector", Alert{RuleName: "drops", Severity: "warning"},
+			map[string]string{"cluster": "staging"}, []string{"audit", "platform"}},
+		{"matched continue route replaces parent receiver", Alert{RuleName: "drops", Severity: "warning"},
+			map[string]string{"cluster": "prod-eu"}, []string{"audit"}},
+		{"rule receiver bypasses routes", Alert{RuleName: "cert_expiry", Severity: "critical"}, nil, []string{"platform"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.alert
+			a.labels = tt.labels
+			if got := e.receiversFor(&a); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeliver_SendsToRoutedReceiversOnly(t *testing.T) {
+	var mu sync.Mutex
+	got := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		got[r.URL.Path]++
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	t.Setenv("PAGE_URL", srv.URL+"/page")
+	t.Setenv("CHAT_URL", srv.URL+"/chat")
+
+	e := New(config.AlertsConfig{
+		Receivers: []config.Receiver{
+			{Name: "oncall", Webhooks: []config.WebhookConfig{{Type: "http", URLEnv: "PAGE_URL"}}},
+			{Name: "chat", Webhooks: []config.WebhookConfig{{Type: "http", URLEnv: "CHAT_URL"}}},
+			{Name: "empty"},
+		},
+		Route: &config.Route{
+			Receiver: "chat",
+			Routes: []config.Route{
+				{Severity: []string{"critical"}, Receiver: "oncall"},
+				{Severity: []string{"info"}, Receiver: "empty"},
+			},
+		},
+	})
+
+	e.deliver(&Alert{RuleName: "drops", Severity: "critical"}, nil)
+	e.deliver(&Alert{RuleName: "certs", Severity: "warning"}, nil)
+	e.deliver(&Alert{RuleName: "certs", Severity: "info"}, nil) // unrouted: logged only
+
+	if got["/page"] != 1 || got["/chat"] != 1 {
+		t.Errorf("deliveries by path: got %v, want one each to /page and /chat", got)
+	}
+}
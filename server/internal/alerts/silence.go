@@ -0,0 +1,254 @@
+package alerts
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+// Silence states.
+const (
+	SilencePending = "pending" // StartsAt is in the future
+	SilenceActive  = "active"
+	SilenceExpired = "expired" // EndsAt has passed or it was expired early
+)
+
+// Silence mutes the alerts it matches between StartsAt and EndsAt. Silenced
+// alerts are still evaluated and listed by Active, marked as silenced, but no
+// webhook is notified when they fire or resolve.
+type Silence struct {
+	ID string `json:"id"`
+
+	// Rule is a path.Match pattern on the rule name; empty matches every rule.
+	Rule string `json:"rule,omitempty"`
+
+	// Match selects the pipelines the silence applies to; empty selects all.
+	Match config.Selector `json:"match,omitempty"`
+
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Comment   string    `json:"comment"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// State is derived from the times when the silence is returned.
+	State string `json:"state"` // "pending" | "active" | "expired"
+}
+
+// ErrSilenceNotFound is returned by ExpireSilence for an unknown ID.
+var ErrSilenceNotFound = errors.New("silence not found")
+
+// AddSilence validates s and starts silencing with it. StartsAt defaults to
+// now; EndsAt and Comment are required. It returns the stored silence with
+// its ID and state set.
+func (e *Engine) AddSilence(s Silence) (Silence, error) {
+	now := e.now()
+	if s.StartsAt.IsZero() {
+		s.StartsAt = now
+	}
+	switch {
+	case s.EndsAt.IsZero():
+		return Silence{}, errors.New("ends_at is required")
+	case !s.EndsAt.After(s.StartsAt):
+		return Silence{}, errors.New("ends_at must be after starts_at")
+	case !s.EndsAt.After(now):
+		return Silence{}, errors.New("ends_at must be in the future")
+	case s.Comment == "":
+		return Silence{}, errors.New("comment is required")
+	}
+	if _, err := path.Match(s.Rule, ""); err != nil {
+		return Silence{}, fmt.Errorf("rule: bad pattern %q: %w", s.Rule, err)
+	}
+	if err := s.Match.Validate(); err != nil {
+		return Silence{}, fmt.Errorf("match: %w", err)
+	}
+
+	s.ID = newSilenceID()
+	s.CreatedAt = now
+
+	e.mu.Lock()
+	e.pruneSilences(now)
+	stored, saved := s, s
+	e.silences = append(e.silences, &stored)
+	e.record(change{key: "silence:" + s.ID, silence: &saved})
+	e.mu.Unlock()
+	e.flush()
+
+	slog.Info("silence created",
+		"id", s.ID,
+		"rule", s.Rule,
+		"match", s.Match,
+		"starts_at", s.StartsAt,
+		"ends_at", s.EndsAt,
+		"created_by", s.CreatedBy,
+	)
+	s.State = silenceState(s, now)
+	return s, nil
+}
+
+// Silences returns copies of all pending and active silences plus those
+// expired within the past hour, newest first.
+func (e *Engine) Silences() []Silence {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	e.pruneSilences(now)
+	out := make([]Silence, 0, len(e.silences))
+	for _, s := range e.silences {
+		cp := *s
+		cp.State = silenceState(cp, now)
+		out = append(out, cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// ExpireSilence ends the silence with the given ID now. Expiring a silence
+// that has already expired is a no-op.
+func (e *Engine) ExpireSilence(id string) (Silence, error) {
+	e.mu.Lock()
+	now := e.now()
+	for _, s := range e.silences {
+		if s.ID != id {
+			continue
+		}
+		if s.EndsAt.After(now) {
+			s.EndsAt = now
+			if s.StartsAt.After(now) {
+				s.StartsAt = now
+			}
+			stored := *s
+			e.record(change{key: "silence:" + id, silence: &stored})
+			slog.Info("silence expired", "id", id)
+		}
+		cp := *s
+		e.mu.Unlock()
+		e.flush()
+		cp.State = SilenceExpired
+		return cp, nil
+	}
+	e.mu.Unlock()
+	return Silence{}, ErrSilenceNotFound
+}
+
+// silencedBy returns the IDs of the silences and the names of the maintenance
+// windows (as "maintenance:<name>") muting rule for a pipeline with labels at
+// now. Must be called with e.mu held.
+func (e *Engine) silencedBy(rule string, labels map[string]string, now time.Time) []string {
+	var ids []string
+	for _, s := range e.silences {
+		if silenceState(*s, now) == SilenceActive && matchesRule(s.Rule, rule) && s.Match.Matches(labels) {
+			ids = append(ids, s.ID)
+		}
+	}
+	for _, w := range e.windows {
+		if matchesRule(w.rule, rule) && w.match.Matches(labels) && w.contains(now) {
+			ids = append(ids, "maintenance:"+w.name)
+		}
+	}
+	return ids
+}
+
+// pruneSilences drops silences that expired more than an hour before now.
+// Must be called with e.mu held.
+func (e *Engine) pruneSilences(now time.Time) {
+	cutoff := now.Add(-recentWindowHours * time.Hour)
+	kept := e.silences[:0]
+	for _, s := range e.silences {
+		if s.EndsAt.After(cutoff) {
+			kept = append(kept, s)
+		}
+	}
+	clear(e.silences[len(kept):])
+	e.silences = kept
+}
+
+func silenceState(s Silence, now time.Time) string {
+	switch {
+	case !now.Before(s.EndsAt):
+		return SilenceExpired
+	case now.Before(s.StartsAt):
+		return SilencePending
+	default:
+		return SilenceActive
+	}
+}
+
+// matchesRule reports whether the rule-name pattern (empty for any) matches
+// name.
+func matchesRule(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+func newSilenceID() string {
+	b := make([]byte, 8)
+	rand.Read(b) //nolint:errcheck // never fails
+	return hex.EncodeToString(b)
+}
+
+// window is a parsed config.MaintenanceWindow.
+type window struct {
+	name      string
+	rule      string
+	match     config.Selector
+	days      [7]bool // indexed by time.Weekday
+	hour, min int
+	dur       time.Duration
+	loc       *time.Location
+}
+
+func newWindow(w config.MaintenanceWindow) (window, error) {
+	start, err := time.Parse("15:04", w.Start)
+	if err != nil {
+		return window{}, fmt.Errorf("start %q: want HH:MM", w.Start)
+	}
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return window{}, err
+	}
+	out := window{
+		name:  w.Name,
+		rule:  w.Rule,
+		match: w.Match,
+		hour:  start.Hour(),
+		min:   start.Minute(),
+		dur:   w.Duration,
+		loc:   loc,
+	}
+	for _, d := range w.Days {
+		out.days[config.Weekdays[d]] = true
+	}
+	if len(w.Days) == 0 {
+		out.days = [7]bool{true, true, true, true, true, true, true}
+	}
+	return out, nil
+}
+
+// contains reports whether t falls inside an occurrence of w. An occurrence
+// lasts at most a week, so only those starting in the past seven days count.
+func (w window) contains(t time.Time) bool {
+	t = t.In(w.loc)
+	for d := 0; d <= 7; d++ {
+		day := t.AddDate(0, 0, -d)
+		if !w.days[day.Weekday()] {
+			continue
+		}
+		begin := time.Date(day.Year(), day.Month(), day.Day(), w.hour, w.min, 0, 0, w.loc)
+		if !t.Before(begin) && t.Before(begin.Add(w.dur)) {
+			return true
+		}
+	}
+	return false
+}
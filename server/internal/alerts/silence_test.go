@@ -0,0 +1,177 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+func TestSilence_SuppressesDelivery(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	e := testEngine(&now, config.AlertRule{Name: "drops", Condition: "drop_pct > 10"})
+
+	s, err := e.AddSilence(Silence{
+		Rule:    "dro*",
+		Match:   config.Selector{"source_id": "otel"},
+		EndsAt:  now.Add(time.Hour),
+		Comment: "collector upgrade",
+	})
+	if err != nil {
+		t.Fatalf("AddSilence: %v", err)
+	}
+
+	e.Evaluate(snap(20))
+	if a := e.active["drops:otel"]; a == nil || a.notified {
+		t.Fatalf("alert %+v: want firing without notification", a)
+	}
+	active := e.Active()
+	if !active[0].Silenced || len(active[0].SilencedBy) != 1 || active[0].SilencedBy[0] != s.ID {
+		t.Errorf("Active: got silenced=%v by %v, want silenced by %s", active[0].Silenced, active[0].SilencedBy, s.ID)
+	}
+
+	// Resolving after the silence ends still sends nothing: the firing
+	// notification was never delivered.
+	now = now.Add(2 * time.Hour)
+	e.mu.Lock()
+	resolved := e.clear(e.rules[0], map[string]string{"source_id": "otel"}, now)
+	e.mu.Unlock()
+	if resolved != nil {
+		t.Errorf("resolve notified for an alert that fired silenced: %+v", resolved)
+	}
+}
+
+// TestSilence_ResolveOfNotifiedAlertDelivered covers paging, then silencing
+// while the fix goes in: the resolve must still close the incident.
+func TestSilence_ResolveOfNotifiedAlertDelivered(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	e := testEngine(&now, config.AlertRule{Name: "drops", Condition: "drop_pct > 10"})
+
+	e.Evaluate(snap(20))
+	if a := e.active["drops:otel"]; a == nil || !a.notified {
+		t.Fatalf("alert %+v: want firing and notified", a)
+	}
+	if _, err := e.AddSilence(Silence{Rule: "drops", EndsAt: now.Add(time.Hour), Comment: "fixing"}); err != nil {
+		t.Fatalf("AddSilence: %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	e.mu.Lock()
+	resolved := e.clear(e.rules[0], map[string]string{"source_id": "otel"}, now)
+	e.mu.Unlock()
+	if resolved == nil || resolved.State != StateResolved || !resolved.Silenced {
+		t.Errorf("resolve of a notified alert while silenced: got %+v, want it delivered", resolved)
+	}
+}
+
+func TestSilence_OtherRuleOrSourceNotSilenced(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	e := testEngine(&now,
+		config.AlertRule{Name: "drops", Condition: "drop_pct > 10"},
+		config.AlertRule{Name: "degraded", Condition: "drop_pct > 5"},
+	)
+	if _, err := e.AddSilence(Silence{Rule: "drops", EndsAt: now.Add(time.Hour), Comment: "x"}); err != nil {
+		t.Fatalf("AddSilence: %v", err)
+	}
+	if _, err := e.AddSilence(Silence{Match: config.Selector{"source_id": "other"}, EndsAt: now.Add(time.Hour), Comment: "x"}); err != nil {
+		t.Fatalf("AddSilence: %v", err)
+	}
+
+	e.Evaluate(snap(20))
+	if !e.active["drops:otel"].Silenced || e.active["degraded:otel"].Silenced {
+		t.Errorf("want only drops silenced: drops=%v degraded=%v",
+			e.active["drops:otel"].Silenced, e.active["degraded:otel"].Silenced)
+	}
+}
+
+func TestSilence_StatesAndExpire(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	e := testEngine(&now)
+
+	later, err := e.AddSilence(Silence{StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour), Comment: "x"})
+	if err != nil {
+		t.Fatalf("AddSilence: %v", err)
+	}
+	if later.State != SilencePending {
+		t.Errorf("state: got %q, want pending", later.State)
+	}
+	if _, err := e.ExpireSilence(later.ID); err != nil {
+		t.Fatalf("ExpireSilence: %v", err)
+	}
+	if got := e.Silences()[0].State; got != SilenceExpired {
+		t.Errorf("state after expiring: got %q, want expired", got)
+	}
+
+	// Expired silences are listed for an hour, then dropped.
+	now = now.Add(61 * time.Minute)
+	if got := e.Silences(); len(got) != 0 {
+		t.Errorf("Silences an hour after expiry: got %+v, want none", got)
+	}
+	if _, err := e.ExpireSilence(later.ID); err != ErrSilenceNotFound {
+		t.Errorf("ExpireSilence after pruning: got %v, want ErrSilenceNotFound", err)
+	}
+
+	for name, s := range map[string]Silence{
+		"no end":       {Comment: "x"},
+		"end in past":  {StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour), Comment: "x"},
+		"no comment":   {EndsAt: now.Add(time.Hour)},
+		"bad selector": {EndsAt: now.Add(time.Hour), Comment: "x", Match: config.Selector{"region": "eu"}},
+	} {
+		if _, err := e.AddSilence(s); err == nil {
+			t.Errorf("%s: AddSilence succeeded, want an error", name)
+		}
+	}
+}
+
+func TestMaintenanceWindow_Contains(t *testing.T) {
+	w, err := newWindow(config.MaintenanceWindow{
+		Name:     "nightly",
+		Days:     []string{"sat"},
+		Start:    "23:00",
+		Duration: 3 * time.Hour,
+		Timezone: "Europe/Berlin",
+	})
+	if err != nil {
+		t.Fatalf("newWindow: %v", err)
+	}
+	berlin := w.loc
+	tests := []struct {
+		t    time.Time
+		want bool
+	}{
+		{time.Date(2026, 10, 10, 22, 59, 0, 0, berlin), false}, // Saturday, before start
+		{time.Date(2026, 10, 10, 23, 0, 0, 0, berlin), true},
+		{time.Date(2026, 10, 11, 1, 59, 0, 0, berlin), true}, // past midnight into Sunday
+		{time.Date(2026, 10, 11, 2, 0, 0, 0, berlin), false},
+		{time.Date(2026, 10, 11, 23, 30, 0, 0, berlin), false},                        // Sunday: not a start day
+		{time.Date(2026, 10, 10, 21, 30, 0, 0, time.UTC), true},                       // 23:30 in Berlin
+		{time.Date(2026, 10, 17, 23, 30, 0, 0, berlin).Add(7 * 24 * time.Hour), true}, // following weeks
+	}
+	for _, tt := range tests {
+		if got := w.contains(tt.t); got != tt.want {
+			t.Errorf("contains(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestMaintenanceWindow_SilencesAlerts(t *testing.T) {
+	now := time.Date(2026, 10, 14, 2, 30, 0, 0, time.UTC)
+	e := New(config.AlertsConfig{
+		Rules: []config.AlertRule{{Name: "drops", Condition: "drop_pct > 10"}},
+		Maintenance: []config.MaintenanceWindow{
+			{Name: "loki-upgrade", Rule: "drops", Start: "02:00", Duration: time.Hour},
+		},
+	})
+	e.now = func() time.Time { return now }
+
+	e.Evaluate(snap(20))
+	a := e.Active()[0]
+	if !a.Silenced || a.SilencedBy[0] != "maintenance:loki-upgrade" {
+		t.Errorf("got silenced=%v by %v, want maintenance:loki-upgrade", a.Silenced, a.SilencedBy)
+	}
+
+	now = now.Add(time.Hour)
+	if a := e.Active()[0]; a.Silenced {
+		t.Errorf("still silenced after the window: %v", a.SilencedBy)
+	}
+}
@@ -0,0 +1,318 @@
+package alerts
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sync"
+	"text/template"
+	"time"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/server/internal/alerts/payload"
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+	"github.com/obsidianstack/obsidianstack/server/internal/diagnostics"
+)
+
+// smtpTimeout bounds one email delivery, from dial to QUIT.
+const smtpTimeout = 30 * time.Second
+
+// mailerKey identifies the smtp webhooks that share a mailer: those sending
+// through the same server as the same sender. Recipients are not part of it,
+// so a recipient listed by several of them still gets one email per batch.
+type mailerKey struct {
+	addr, tls, usernameEnv, passwordEnv, from string
+	groupWait                                 time.Duration
+}
+
+func newMailerKey(c config.SMTPConfig) mailerKey {
+	return mailerKey{c.Addr(), c.TLS, c.UsernameEnv, c.PasswordEnv, c.From, c.GroupWait}
+}
+
+// newMailers returns a mailer for every distinct server and sender among
+// the smtp webhooks of receivers.
+func newMailers(receivers map[string][]config.WebhookConfig, dashboardURL string) map[mailerKey]*mailer {
+	mailers := make(map[mailerKey]*mailer)
+	for _, webhooks := range receivers {
+		for _, wh := range webhooks {
+			if wh.Type != "smtp" || wh.SMTP == nil {
+				continue
+			}
+			key := newMailerKey(*wh.SMTP)
+			if mailers[key] == nil {
+				mailers[key] = newMailer(*wh.SMTP, dashboardURL)
+			}
+		}
+	}
+	return mailers
+}
+
+// mailer collects notifications per recipient and emails each recipient
+// its batch once the group wait, counted from the batch's first
+// notification, has passed. Batches still waiting at shutdown are lost.
+type mailer struct {
+	cfg       config.SMTPConfig
+	dashboard string
+	wait      time.Duration
+
+	mu      sync.Mutex
+	batches map[string][]mailItem // by recipient address
+}
+
+// mailItem is one notification in a batch.
+type mailItem struct {
+	Alert *Alert
+	Link  string // the pipeline on the dashboard; empty without dashboard_url
+	Hints []diagnostics.Hint
+}
+
+func newMailer(cfg config.SMTPConfig, dashboardURL string) *mailer {
+	wait := cfg.GroupWait
+	if wait <= 0 {
+		wait = config.DefaultSMTPGroupWait
+	}
+	return &mailer{
+		cfg:       cfg,
+		dashboard: dashboardURL,
+		wait:      wait,
+		batches:   make(map[string][]mailItem),
+	}
+}
+
+// enqueue adds a, evaluated against snap, to the batch of each recipient in
+// to, starting the group wait for recipients without a pending batch.
+func (m *mailer) enqueue(a *Alert, snap *pb.PipelineSnapshot, to []string) {
+	item := mailItem{Alert: a, Link: payload.PipelineURL(m.dashboard, a.SourceID)}
+	if snap != nil && a.State != StateResolved {
+		item.Hints = diagnostics.Compute(snap)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rcpt := range to {
+		batch := m.batches[rcpt]
+		if len(batch) == 0 {
+			time.AfterFunc(m.wait, func() { m.flush(rcpt) })
+		}
+		if !containsNotification(batch, a) {
+			m.batches[rcpt] = append(batch, item)
+		}
+	}
+}
+
+// containsNotification reports whether batch already holds the notification
+// for a, as when two routes send it to the same recipient.
+func containsNotification(batch []mailItem, a *Alert) bool {
+	for _, it := range batch {
+		if it.Alert.ID == a.ID && it.Alert.State == a.State && it.Alert.FiredAt.Equal(a.FiredAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// flush emails rcpt its pending batch.
+func (m *mailer) flush(rcpt string) {
+	m.mu.Lock()
+	batch := m.batches[rcpt]
+	delete(m.batches, rcpt)
+	m.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	deliveries.WithLabelValues("smtp").Inc()
+	msg, err := buildEmail(m.cfg.From, rcpt, batch, time.Now())
+	if err == nil {
+		err = m.send(rcpt, msg)
+	}
+	if err != nil {
+		deliveryFailures.WithLabelValues("smtp").Inc()
+		slog.Error("alerts: email delivery failed", "to", rcpt, "alerts", len(batch), "err", err)
+		return
+	}
+	slog.Debug("alerts: email delivered", "to", rcpt, "alerts", len(batch))
+}
+
+// send delivers msg to rcpt through the configured server.
+func (m *mailer) send(rcpt string, msg []byte) error {
+	from, err := mail.ParseAddress(m.cfg.From)
+	if err != nil {
+		return fmt.Errorf("from: %w", err)
+	}
+	to, err := mail.ParseAddress(rcpt)
+	if err != nil {
+		return fmt.Errorf("to: %w", err)
+	}
+
+	addr := m.cfg.Addr()
+	tlsConfig := &tls.Config{ServerName: m.cfg.Host}
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	var conn net.Conn
+	if m.cfg.TLS == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout)) //nolint:errcheck // a fresh conn
+
+	c, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake: %w", err)
+	}
+	defer c.Close()
+
+	if m.cfg.TLS == "" || m.cfg.TLS == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("server does not offer STARTTLS (set tls: none to send in the clear)")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if user, pass := m.cfg.Credentials(); user != "" {
+		if err := c.Auth(smtp.PlainAuth("", user, pass, m.cfg.Host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("MAIL FROM: %w", err)
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("RCPT TO: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("DATA: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("DATA: %w", err)
+	}
+	return c.Quit()
+}
+
+// emailData is what the email templates render.
+type emailData struct {
+	Items            []mailItem
+	Firing, Resolved int
+}
+
+var (
+	emailText = template.Must(template.New("text").Parse(`{{range $i, $it := .Items}}{{if $i}}
+----
+{{end}}{{with .Alert}}{{if eq .State "resolved"}}RESOLVED{{else}}FIRING{{end}} [{{.Severity}}] {{.RuleName}} on {{.SourceID}}
+{{if eq .State "resolved"}}Resolved at {{.ResolvedAt.UTC.Format "2006-01-02 15:04:05 MST"}}{{else}}{{.Message}}
+Fired at {{.FiredAt.UTC.Format "2006-01-02 15:04:05 MST"}}{{end}}
+{{end}}{{range .Hints}}
+- {{.Title}}: {{.Detail}}{{end}}{{if .Link}}
+Pipeline: {{.Link}}{{end}}
+{{end}}
+--
+ObsidianStack alerting
+`))
+
+	emailHTML = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html><body style="font-family: sans-serif; color: #1a1a1a;">
+{{range .Items}}{{with .Alert}}<h3 style="margin-bottom: 4px;">
+<span style="color: {{if eq .State "resolved"}}#2e7d32{{else if eq .Severity "critical"}}#d32f2f{{else if eq .Severity "warning"}}#ef6c00{{else}}#0288d1{{end}};">
+{{if eq .State "resolved"}}RESOLVED{{else}}FIRING{{end}} [{{.Severity}}]</span> {{.RuleName}} on {{.SourceID}}</h3>
+{{if eq .State "resolved"}}<p>Resolved at {{.ResolvedAt.UTC.Format "2006-01-02 15:04:05 MST"}}</p>
+{{else}}<p>{{.Message}}<br>Fired at {{.FiredAt.UTC.Format "2006-01-02 15:04:05 MST"}}</p>
+{{end}}{{end}}{{with .Hints}}<ul>
+{{range .}}<li><b>{{.Title}}</b>: {{.Detail}}</li>
+{{end}}</ul>
+{{end}}{{if .Link}}<p><a href="{{.Link}}">Open pipeline in ObsidianStack</a></p>
+{{end}}<hr>
+{{end}}<p style="color: #777; font-size: small;">ObsidianStack alerting</p>
+</body></html>
+`))
+)
+
+// buildEmail renders the multipart/alternative email notifying to of items.
+func buildEmail(from, to string, items []mailItem, now time.Time) ([]byte, error) {
+	data := emailData{Items: items}
+	for _, it := range items {
+		if it.Alert.State == StateResolved {
+			data.Resolved++
+		} else {
+			data.Firing++
+		}
+	}
+
+	var msg bytes.Buffer
+	mw := multipart.NewWriter(&msg)
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", from)
+	fmt.Fprintf(&body, "To: %s\r\n", to)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", emailSubject(data)))
+	fmt.Fprintf(&body, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&body, "Message-ID: <%s@obsidianstack>\r\n", newMessageID())
+	fmt.Fprintf(&body, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&body, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", mw.Boundary())
+
+	for _, part := range []struct {
+		contentType string
+		render      func(*quotedprintable.Writer) error
+	}{
+		{"text/plain", func(w *quotedprintable.Writer) error { return emailText.Execute(w, data) }},
+		{"text/html", func(w *quotedprintable.Writer) error { return emailHTML.Execute(w, data) }},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if err := part.render(qp); err != nil {
+			return nil, fmt.Errorf("render %s: %w", part.contentType, err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	body.Write(msg.Bytes())
+	return body.Bytes(), nil
+}
+
+// emailSubject names the single alert of data, or counts them.
+func emailSubject(data emailData) string {
+	if len(data.Items) == 1 {
+		a := data.Items[0].Alert
+		if a.State == StateResolved {
+			return fmt.Sprintf("[RESOLVED] %s on %s", a.RuleName, a.SourceID)
+		}
+		return fmt.Sprintf("%s %s fired on %s", severityLabel(a.Severity), a.RuleName, a.SourceID)
+	}
+	return fmt.Sprintf("[ObsidianStack] %d alerts: %d firing, %d resolved",
+		len(data.Items), data.Firing, data.Resolved)
+}
+
+func newMessageID() string {
+	b := make([]byte, 12)
+	rand.Read(b) //nolint:errcheck // never fails
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,255 @@
+package alerts
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+// sinkMessage is one email accepted by an smtpSink.
+type sinkMessage struct {
+	auth string // decoded AUTH PLAIN response, if any
+	from string
+	to   []string
+	data string
+}
+
+// smtpSink is a minimal SMTP server that accepts every message and hands it
+// to the test. It offers AUTH PLAIN but not STARTTLS.
+type smtpSink struct {
+	addr string
+	msgs chan sinkMessage
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &smtpSink{addr: ln.Addr().String(), msgs: make(chan sinkMessage, 16)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+	reply("220 sink ESMTP")
+	var m sinkMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO":
+			reply("250-sink")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			if f := strings.Fields(line); len(f) == 3 {
+				b, _ := base64.StdEncoding.DecodeString(f[2])
+				m.auth = string(b)
+			}
+			reply("235 ok")
+		case "MAIL":
+			m.from = line[len("MAIL FROM:"):]
+			reply("250 ok")
+		case "RCPT":
+			m.to = append(m.to, line[len("RCPT TO:"):])
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			m.data = data.String()
+			s.msgs <- m
+			m = sinkMessage{}
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *smtpSink) hostPort() (string, int) {
+	host, port, _ := net.SplitHostPort(s.addr)
+	n, _ := strconv.Atoi(port)
+	return host, n
+}
+
+// next returns the next message the sink accepted.
+func (s *smtpSink) next(t *testing.T) sinkMessage {
+	t.Helper()
+	select {
+	case m := <-s.msgs:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email received")
+		return sinkMessage{}
+	}
+}
+
+// bodies returns the subject and the text and HTML parts of an email.
+func bodies(t *testing.T, data string) (subject, text, html string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parse email: %v", err)
+	}
+	subject, _ = new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("content type: %v", err)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart() // decodes quoted-printable
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("part: %v", err)
+		}
+		b, _ := io.ReadAll(p)
+		switch {
+		case strings.HasPrefix(p.Header.Get("Content-Type"), "text/plain"):
+			text = string(b)
+		case strings.HasPrefix(p.Header.Get("Content-Type"), "text/html"):
+			html = string(b)
+		}
+	}
+	return subject, text, html
+}
+
+func TestSMTP_GroupsNotificationsPerRecipient(t *testing.T) {
+	sink := newSMTPSink(t)
+	host, port := sink.hostPort()
+	t.Setenv("SMTP_USER", "obsidian")
+	t.Setenv("SMTP_PASS", "s3cret")
+
+	smtpCfg := &config.SMTPConfig{
+		Host:        host,
+		Port:        port,
+		TLS:         "none",
+		UsernameEnv: "SMTP_USER",
+		PasswordEnv: "SMTP_PASS",
+		From:        "ObsidianStack <alerts@example.com>",
+		To:          []string{"oncall@example.com", "team@example.com"},
+		GroupWait:   200 * time.Millisecond,
+	}
+	e := New(config.AlertsConfig{
+		DashboardURL: "https://obsidian.example.com/",
+		Rules: []config.AlertRule{
+			{Name: "drops", Condition: "drop_pct > 10", Severity: "critical"},
+			{Name: "degraded", Condition: "drop_pct > 5"},
+		},
+		Webhooks: []config.WebhookConfig{{Type: "smtp", SMTP: smtpCfg}},
+	})
+
+	e.Evaluate(&pb.PipelineSnapshot{SourceId: "otel", State: "critical", DropPct: 20})
+
+	got := map[string]sinkMessage{}
+	for range smtpCfg.To {
+		m := sink.next(t)
+		if len(m.to) != 1 {
+			t.Fatalf("recipients: got %v, want one per email", m.to)
+		}
+		got[m.to[0]] = m
+	}
+	m, ok := got["<oncall@example.com>"]
+	if !ok || got["<team@example.com>"].data == "" {
+		t.Fatalf("emails by recipient: got %v", got)
+	}
+	if m.from != "<alerts@example.com>" || m.auth != "\x00obsidian\x00s3cret" {
+		t.Errorf("envelope: from %q auth %q", m.from, m.auth)
+	}
+
+	subject, text, html := bodies(t, m.data)
+	if subject != "[ObsidianStack] 2 alerts: 2 firing, 0 resolved" {
+		t.Errorf("subject: got %q", subject)
+	}
+	link := "https://obsidian.example.com/pipelines?source=otel"
+	for _, want := range []string{"FIRING [critical] drops on otel", "FIRING [warning] degraded on otel", "Pipeline: " + link} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text body lacks %q:\n%s", want, text)
+		}
+	}
+	if !strings.Contains(html, "</span> drops on otel</h3>") || !strings.Contains(html, `<a href="`+link+`">`) {
+		t.Errorf("html body lacks the alert or link:\n%s", html)
+	}
+
+	select {
+	case extra := <-sink.msgs:
+		t.Errorf("unexpected extra email to %v", extra.to)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestSMTP_RequiresSTARTTLSByDefault(t *testing.T) {
+	sink := newSMTPSink(t)
+	host, port := sink.hostPort()
+
+	m := newMailer(config.SMTPConfig{Host: host, Port: port, From: "alerts@example.com"}, "")
+	err := m.send("oncall@example.com", []byte("Subject: x\r\n\r\nx\r\n"))
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("send without STARTTLS offered: got %v, want a STARTTLS error", err)
+	}
+}
+
+func TestEmailSubject(t *testing.T) {
+	resolved := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		items []mailItem
+		want  string
+	}{
+		{[]mailItem{{Alert: &Alert{RuleName: "drops", SourceID: "otel", Severity: "critical", State: StateFiring}}},
+			"[CRITICAL] drops fired on otel"},
+		{[]mailItem{{Alert: &Alert{RuleName: "drops", SourceID: "otel", State: StateResolved, ResolvedAt: &resolved}}},
+			"[RESOLVED] drops on otel"},
+	}
+	for _, tt := range tests {
+		if got := emailSubject(emailData{Items: tt.items}); got != tt.want {
+			t.Errorf("emailSubject = %q, want %q", got, tt.want)
+		}
+		if _, err := buildEmail("a@example.com", "b@example.com", tt.items, resolved); err != nil {
+			t.Errorf("buildEmail: %v", err)
+		}
+	}
+}
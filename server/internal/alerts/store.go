@@ -0,0 +1,75 @@
+package alerts
+
+import (
+	"context"
+	"time"
+)
+
+// Store persists alert state so that a restarted server resumes pending and
+// firing alerts, their cooldowns and the silences muting them instead of
+// re-firing them, and keeps the resolved-alert audit trail. history.SQLite implements it.
+type Store interface {
+	// LoadActive returns the stored pending and firing alerts and the last
+	// fire time of every key still within its cooldown bookkeeping.
+	LoadActive(ctx context.Context) ([]StoredAlert, map[string]time.Time, error)
+
+	// SaveActive inserts or replaces the stored state of a pending or firing
+	// alert.
+	SaveActive(ctx context.Context, a StoredAlert) error
+
+	// DeleteActive removes the stored state for key, if any.
+	DeleteActive(ctx context.Context, key string) error
+
+	// SaveFire records the last time the alert for key fired.
+	SaveFire(ctx context.Context, key string, at time.Time) error
+
+	// AppendHistory records a resolved alert.
+	AppendHistory(ctx context.Context, a *Alert) error
+
+	// QueryHistory returns the resolved alerts matching q, most recently
+	// resolved first, and how many match in total ignoring Limit and Offset.
+	QueryHistory(ctx context.Context, q HistoryQuery) ([]*Alert, int, error)
+
+	// LoadSilences returns the stored silences.
+	LoadSilences(ctx context.Context) ([]Silence, error)
+
+	// SaveSilence inserts or replaces a silence.
+	SaveSilence(ctx context.Context, s Silence) error
+}
+
+// StoredAlert is a pending or firing alert with the engine state needed to
+// resume it.
+type StoredAlert struct {
+	Key      string            `json:"key"` // "ruleName:sourceID"
+	Alert    Alert             `json:"alert"`
+	Labels   map[string]string `json:"labels"`
+	Notified bool              `json:"notified"`
+}
+
+// HistoryQuery filters resolved alerts. Empty fields match everything.
+type HistoryQuery struct {
+	Rule     string
+	SourceID string
+	Severity string
+
+	// From and To select alerts that were active at some point in
+	// [From, To): active before To and resolved at or after From.
+	From, To time.Time
+
+	Limit  int // at most this many alerts; 0 for no limit
+	Offset int // skip this many, for paging
+}
+
+// matches reports whether the resolved alert a passes q's filters.
+func (q HistoryQuery) matches(a *Alert) bool {
+	switch {
+	case q.Rule != "" && a.RuleName != q.Rule,
+		q.SourceID != "" && a.SourceID != q.SourceID,
+		q.Severity != "" && a.Severity != q.Severity,
+		a.ResolvedAt == nil,
+		!q.To.IsZero() && !a.ActiveAt.Before(q.To),
+		!q.From.IsZero() && a.ResolvedAt.Before(q.From):
+		return false
+	}
+	return true
+}
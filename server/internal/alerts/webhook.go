@@ -2,94 +2,191 @@ package alerts
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/server/internal/alerts/payload"
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+	"github.com/obsidianstack/obsidianstack/server/internal/diagnostics"
 )
 
-// deliver sends webhook notifications for a to all configured targets.
-// Errors are logged but do not affect the caller.
-func (e *Engine) deliver(a *Alert) {
-	for _, wh := range e.webhooks {
-		url := wh.URL()
-		if url == "" {
+// deliver sends webhook notifications for a, evaluated against snap, to the
+// targets of the receivers it is routed to, each in parallel so that one
+// target's retries do not hold up the others. It returns when every request
+// has been delivered or dead-lettered. Errors are logged but do not affect
+// the caller.
+func (e *Engine) deliver(a *Alert, snap *pb.PipelineSnapshot) {
+	if len(e.receivers) == 0 {
+		return
+	}
+	var targets []config.WebhookConfig
+	names := e.receiversFor(a)
+	for _, name := range names {
+		targets = append(targets, e.receivers[name]...)
+	}
+	if len(targets) == 0 {
+		slog.Warn("alerts: alert unrouted — no receiver with webhooks",
+			"rule", a.RuleName,
+			"source", a.SourceID,
+			"severity", a.Severity,
+			"receivers", names,
+		)
+		return
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for _, wh := range targets {
+		if wh.Type == "smtp" {
+			if wh.SMTP != nil {
+				e.mailers[newMailerKey(*wh.SMTP)].enqueue(a, snap, wh.SMTP.To)
+			}
 			continue
 		}
-
-		var err error
-		switch wh.Type {
-		case "slack":
-			err = e.sendSlack(url, a)
-		case "teams":
-			err = e.sendTeams(url, a)
-		case "pagerduty", "http":
-			err = e.sendHTTP(url, a)
-		default:
-			slog.Warn("alerts: unknown webhook type — skipping", "type", wh.Type)
+		url := wh.URL()
+		if url == "" {
 			continue
 		}
 
+		body, err := e.body(wh, a, snap)
 		if err != nil {
-			slog.Error("alerts: webhook delivery failed",
+			deliveries.WithLabelValues(wh.Type).Inc()
+			deliveryFailures.WithLabelValues(wh.Type).Inc()
+			slog.Error("alerts: webhook payload failed",
 				"type", wh.Type,
 				"rule", a.RuleName,
 				"err", err,
 			)
-		} else {
-			slog.Debug("alerts: webhook delivered",
-				"type", wh.Type,
-				"rule", a.RuleName,
-				"state", a.State,
-			)
+			continue
 		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.send(wh, url, body, a)
+		}()
 	}
 }
 
-func (e *Engine) sendSlack(url string, a *Alert) error {
-	body, _ := json.Marshal(map[string]string{
-		"text": fmt.Sprintf("*%s* %s", severityLabel(a.Severity), a.Message),
-	})
-	return e.post(url, body)
+// body returns the request body notifying wh of a, evaluated against snap.
+func (e *Engine) body(wh config.WebhookConfig, a *Alert, snap *pb.PipelineSnapshot) ([]byte, error) {
+	switch wh.Type {
+	case "slack", "teams", "http":
+		body, err := e.template(wh).Execute(e.payloadData(a, snap))
+		if err != nil {
+			return nil, fmt.Errorf("render payload: %w", err)
+		}
+		return body, nil
+	case "pagerduty":
+		return pagerDutyBody(wh.RoutingKey(), a, snap)
+	}
+	return nil, fmt.Errorf("unknown webhook type %q", wh.Type)
 }
 
-func (e *Engine) sendTeams(url string, a *Alert) error {
-	payload := map[string]interface{}{
-		"@type":      "MessageCard",
-		"@context":   "http://schema.org/extensions",
-		"themeColor": severityColor(a.Severity),
-		"summary":    a.RuleName,
-		"title":      fmt.Sprintf("ObsidianStack Alert: %s", a.RuleName),
-		"text":       a.Message,
-	}
-	body, _ := json.Marshal(payload)
-	return e.post(url, body)
+// template returns the template of wh, or the built-in one for its type.
+func (e *Engine) template(wh config.WebhookConfig) *payload.Template {
+	if t := e.templates[wh.Template]; t != nil {
+		return t
+	}
+	return payload.Default(wh.Type)
 }
 
-func (e *Engine) sendHTTP(url string, a *Alert) error {
-	body, _ := json.Marshal(map[string]interface{}{"alert": a})
-	return e.post(url, body)
+// payloadData returns what notification templates see for a, evaluated
+// against snap.
+func (e *Engine) payloadData(a *Alert, snap *pb.PipelineSnapshot) payload.Data {
+	d := payload.Data{
+		Alert: payload.Alert{
+			ID:             a.ID,
+			RuleName:       a.RuleName,
+			SourceID:       a.SourceID,
+			Severity:       a.Severity,
+			Message:        a.Message,
+			Value:          a.Value,
+			ActiveAt:       a.ActiveAt,
+			FiredAt:        a.FiredAt,
+			ResolvedAt:     a.ResolvedAt,
+			State:          a.State,
+			Labels:         a.labels,
+			Silenced:       a.Silenced,
+			SilencedBy:     a.SilencedBy,
+			AcknowledgedBy: a.AcknowledgedBy,
+			AcknowledgedAt: a.AcknowledgedAt,
+			Note:           a.Note,
+		},
+		Snapshot:     snap,
+		DashboardURL: e.dashboardURL,
+		PipelineURL:  payload.PipelineURL(e.dashboardURL, a.SourceID),
+	}
+	if snap != nil {
+		d.Diagnostics = diagnostics.Compute(snap)
+	}
+	return d
 }
 
-func (e *Engine) post(url string, body []byte) error {
+// post makes one request delivering body to wh at url, with the custom
+// headers of wh and, when it has a signing secret, a signature. A failure is
+// a *deliveryError.
+func (e *Engine) post(wh config.WebhookConfig, url string, body []byte) error {
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("build request: %w", err)
+		return &deliveryError{err: fmt.Errorf("build request: %w", err)}
+	}
+	req.Header = wh.Header()
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if secret := wh.SigningSecret(); secret != "" {
+		ts := strconv.FormatInt(e.now().Unix(), 10)
+		req.Header.Set(TimestampHeader, ts)
+		req.Header.Set(SignatureHeader, Sign(secret, ts, body))
 	}
-	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := e.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("http post: %w", err)
+		return &deliveryError{err: fmt.Errorf("http post: %w", err), retryable: true}
 	}
 	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) //nolint:errcheck // lets the connection be reused
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+		return &deliveryError{
+			err:        fmt.Errorf("webhook returned HTTP %d", resp.StatusCode),
+			retryable:  resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+			retryAfter: retryAfter(resp.Header.Get("Retry-After"), e.now()),
+		}
 	}
 	return nil
 }
 
+// deliveryError is a failed webhook request.
+type deliveryError struct {
+	err        error
+	retryable  bool          // a network error, HTTP 429 or 5xx
+	retryAfter time.Duration // asked for by the receiver; 0 if not
+}
+
+func (e *deliveryError) Error() string { return e.err.Error() }
+func (e *deliveryError) Unwrap() error { return e.err }
+
+// retryAfter parses a Retry-After header value, in seconds or an HTTP date.
+func retryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
 func severityLabel(s string) string {
 	switch s {
 	case "critical":
@@ -100,14 +197,3 @@ func severityLabel(s string) string {
 		return "[INFO]"
 	}
 }
-
-func severityColor(s string) string {
-	switch s {
-	case "critical":
-		return "FF4F6A"
-	case "warning":
-		return "FFAB40"
-	default:
-		return "00D4FF"
-	}
-}
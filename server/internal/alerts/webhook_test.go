@@ -0,0 +1,67 @@
+package alerts
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+func TestDeliver_RendersTemplates(t *testing.T) {
+	bodies := make(chan map[string][]byte, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- map[string][]byte{r.URL.Path: b}
+	}))
+	defer srv.Close()
+	t.Setenv("SLACK_URL", srv.URL+"/slack")
+	t.Setenv("HOOK_URL", srv.URL+"/hook")
+
+	e := New(config.AlertsConfig{
+		DashboardURL: "https://obsidian.example.com",
+		Rules:        []config.AlertRule{{Name: "drops", Condition: "drop_pct > 10", Severity: "critical"}},
+		Webhooks: []config.WebhookConfig{
+			{Type: "slack", URLEnv: "SLACK_URL"},
+			{Type: "http", URLEnv: "HOOK_URL", Template: `{"rule": {{json .Alert.RuleName}}, "cluster": {{json .Snapshot.Cluster}},
+  "hints": {{len .Diagnostics}}, "link": {{json .PipelineURL}}}`},
+		},
+	})
+	e.Evaluate(&pb.PipelineSnapshot{SourceId: "otel", Cluster: "prod-eu", DropPct: 20})
+
+	got := make(map[string][]byte)
+	for range 2 {
+		select {
+		case b := <-bodies:
+			for path, body := range b {
+				got[path] = body
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d of 2 webhook requests", len(got))
+		}
+	}
+
+	var hook struct {
+		Rule, Cluster, Link string
+		Hints               int
+	}
+	if err := json.Unmarshal(got["/hook"], &hook); err != nil {
+		t.Fatalf("http body %s: %v", got["/hook"], err)
+	}
+	if hook.Rule != "drops" || hook.Cluster != "prod-eu" || hook.Hints == 0 ||
+		hook.Link != "https://obsidian.example.com/pipelines?source=otel" {
+		t.Errorf("http body: got %+v", hook)
+	}
+
+	var slack struct {
+		Text   string
+		Blocks []json.RawMessage
+	}
+	if err := json.Unmarshal(got["/slack"], &slack); err != nil || slack.Text == "" || len(slack.Blocks) == 0 {
+		t.Errorf("slack body: got %s (%v), want the built-in Block Kit message", got["/slack"], err)
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/alerts"
+	"github.com/obsidianstack/obsidianstack/server/internal/auth"
+)
+
+// alertAction serves POST /api/v1/alerts/{id}/ack and
+// POST /api/v1/alerts/{id}/unack.
+func (h *Handler) alertAction(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/v1/alerts/")
+	i := strings.LastIndexByte(rest, '/')
+	if i <= 0 {
+		jsonErr(w, http.StatusNotFound, "not found")
+		return
+	}
+	id, action := rest[:i], rest[i+1:]
+	if action != "ack" && action != "unack" {
+		jsonErr(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		jsonErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var (
+		a   *alerts.Alert
+		err error
+	)
+	if action == "ack" {
+		var req AckRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			jsonErr(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+			return
+		}
+		if req.AcknowledgedBy == "" {
+			req.AcknowledgedBy = auth.CredentialFrom(r.Context())
+		}
+		a, err = h.engine.Ack(id, req.AcknowledgedBy, req.Note)
+	} else {
+		a, err = h.engine.Unack(id)
+	}
+
+	switch {
+	case errors.Is(err, alerts.ErrAlertNotFound):
+		jsonErr(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, alerts.ErrAlertResolved):
+		jsonErr(w, http.StatusConflict, err.Error())
+	default:
+		jsonResp(w, http.StatusOK, a)
+	}
+}
+
+// deliveries serves GET /api/v1/alerts/deliveries — the dead-letter list of
+// webhook deliveries that failed every retry, newest first.
+func (h *Handler) deliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		jsonErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	jsonResp(w, http.StatusOK, h.engine.Deliveries())
+}
+
+// resendDelivery serves POST /api/v1/alerts/deliveries/{id}/resend. It
+// returns the delivery, removed from the list, 409 if the alert has had a
+// newer notification since, or 502 with the error if the webhook failed
+// again.
+func (h *Handler) resendDelivery(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/alerts/deliveries/"), "/resend")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		jsonErr(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		jsonErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	d, err := h.engine.Resend(id)
+	switch {
+	case errors.Is(err, alerts.ErrDeliveryNotFound):
+		jsonErr(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, alerts.ErrDeliverySuperseded):
+		jsonErr(w, http.StatusConflict, err.Error())
+	case err != nil:
+		jsonErr(w, http.StatusBadGateway, "resend failed: "+err.Error())
+	default:
+		jsonResp(w, http.StatusOK, d)
+	}
+}
+
+// Paging defaults for GET /api/v1/alerts/history.
+const (
+	defaultAlertHistoryLimit = 100
+	maxAlertHistoryLimit     = 1000
+)
+
+// alertHistory serves GET /api/v1/alerts/history — resolved alerts, most
+// recently resolved first, filtered by rule, source, severity and from/to
+// and paged with limit and offset.
+func (h *Handler) alertHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		jsonErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	q, err := parseAlertHistoryQuery(r)
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	list, total, err := h.engine.History(r.Context(), q)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, "alert history query failed")
+		return
+	}
+	if list == nil {
+		list = []*alerts.Alert{}
+	}
+	jsonResp(w, http.StatusOK, AlertHistoryResponse{
+		Alerts: list,
+		Total:  total,
+		Limit:  q.Limit,
+		Offset: q.Offset,
+	})
+}
+
+func parseAlertHistoryQuery(r *http.Request) (alerts.HistoryQuery, error) {
+	v := r.URL.Query()
+	q := alerts.HistoryQuery{
+		Rule:     v.Get("rule"),
+		SourceID: v.Get("source"),
+		Severity: v.Get("severity"),
+		Limit:    defaultAlertHistoryLimit,
+	}
+	var err error
+	if s := v.Get("from"); s != "" {
+		if q.From, err = parseTime(s); err != nil {
+			return q, fmt.Errorf("from: %w", err)
+		}
+	}
+	if s := v.Get("to"); s != "" {
+		if q.To, err = parseTime(s); err != nil {
+			return q, fmt.Errorf("to: %w", err)
+		}
+	}
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > maxAlertHistoryLimit {
+			return q, fmt.Errorf("limit %q: want 1 to %d", s, maxAlertHistoryLimit)
+		}
+		q.Limit = n
+	}
+	if s := v.Get("offset"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("offset %q: want a non-negative integer", s)
+		}
+		q.Offset = n
+	}
+	return q, nil
+}
@@ -1,9 +1,13 @@
 package api_test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,6 +15,7 @@ import (
 	"github.com/obsidianstack/obsidianstack/server/internal/alerts"
 	"github.com/obsidianstack/obsidianstack/server/internal/api"
 	svrconfig "github.com/obsidianstack/obsidianstack/server/internal/config"
+	"github.com/obsidianstack/obsidianstack/server/internal/history"
 	"github.com/obsidianstack/obsidianstack/server/internal/store"
 )
 
@@ -53,6 +58,14 @@ func get(t *testing.T, h http.Handler, path string) *httptest.ResponseRecorder {
 	return rr
 }
 
+func send(t *testing.T, h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
 func decode(t *testing.T, rr *httptest.ResponseRecorder, v interface{}) {
 	t.Helper()
 	if err := json.NewDecoder(rr.Body).Decode(v); err != nil {
@@ -63,7 +76,7 @@ func decode(t *testing.T, rr *httptest.ResponseRecorder, v interface{}) {
 // --- /api/v1/health ---------------------------------------------------------
 
 func TestHealth_EmptyStore(t *testing.T) {
-	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := get(t, h, "/api/v1/health")
 
 	if rr.Code != http.StatusOK {
@@ -81,7 +94,7 @@ func TestHealth_EmptyStore(t *testing.T) {
 }
 
 func TestHealth_HealthyPipeline(t *testing.T) {
-	h := api.New(newStore(snap("otel", "healthy", 92.0)), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(snap("otel", "healthy", 92.0)), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := get(t, h, "/api/v1/health")
 
 	if rr.Code != http.StatusOK {
@@ -109,7 +122,7 @@ func TestHealth_MixedStates(t *testing.T) {
 		snap("a", "healthy", 90.0),
 		snap("b", "degraded", 70.0),
 		snap("c", "critical", 40.0),
-	), alerts.New(svrconfig.AlertsConfig{}))
+	), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := get(t, h, "/api/v1/health")
 	var resp map[string]interface{}
 	decode(t, rr, &resp)
@@ -129,8 +142,45 @@ func TestHealth_MixedStates(t *testing.T) {
 	}
 }
 
+func TestHealth_UsesAgentThresholds(t *testing.T) {
+	tracing := snap("jaeger", "healthy", 75.0)
+	tracing.ThresholdHealthy = 70
+	tracing.ThresholdDegraded = 40
+	h := api.New(newStore(tracing), alerts.New(svrconfig.AlertsConfig{}), nil)
+
+	var resp map[string]interface{}
+	decode(t, get(t, h, "/api/v1/health"), &resp)
+
+	// 75 is below the default 85 but above the agent's healthy threshold.
+	if resp["state"] != "healthy" {
+		t.Errorf("state: got %v, want healthy", resp["state"])
+	}
+}
+
+func TestPipelines_Thresholds(t *testing.T) {
+	tracing := snap("jaeger", "healthy", 75.0)
+	tracing.ThresholdHealthy = 70
+	tracing.ThresholdDegraded = 40
+	h := api.New(newStore(tracing, snap("legacy", "healthy", 90.0)), alerts.New(svrconfig.AlertsConfig{}), nil)
+
+	var resp []map[string]interface{}
+	decode(t, get(t, h, "/api/v1/pipelines"), &resp)
+	if len(resp) != 2 {
+		t.Fatalf("pipelines: got %d, want 2", len(resp))
+	}
+
+	want := map[string][2]float64{"jaeger": {70, 40}, "legacy": {85, 60}}
+	for _, p := range resp {
+		w := want[p["source_id"].(string)]
+		if p["threshold_healthy"] != w[0] || p["threshold_degraded"] != w[1] {
+			t.Errorf("%v thresholds: got %v/%v, want %v/%v", p["source_id"],
+				p["threshold_healthy"], p["threshold_degraded"], w[0], w[1])
+		}
+	}
+}
+
 func TestHealth_MethodNotAllowed(t *testing.T) {
-	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := httptest.NewRecorder()
 	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/health", nil))
 	if rr.Code != http.StatusMethodNotAllowed {
@@ -141,7 +191,7 @@ func TestHealth_MethodNotAllowed(t *testing.T) {
 // --- /api/v1/pipelines ------------------------------------------------------
 
 func TestListPipelines_Empty(t *testing.T) {
-	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := get(t, h, "/api/v1/pipelines")
 
 	if rr.Code != http.StatusOK {
@@ -159,7 +209,7 @@ func TestListPipelines_Multiple(t *testing.T) {
 		snap("otel", "healthy", 92.0),
 		snap("prom", "degraded", 70.0),
 		snap("loki", "critical", 40.0),
-	), alerts.New(svrconfig.AlertsConfig{}))
+	), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := get(t, h, "/api/v1/pipelines")
 
 	if rr.Code != http.StatusOK {
@@ -173,7 +223,7 @@ func TestListPipelines_Multiple(t *testing.T) {
 }
 
 func TestListPipelines_FieldsPresent(t *testing.T) {
-	h := api.New(newStore(snap("otel", "healthy", 92.5)), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(snap("otel", "healthy", 92.5)), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := get(t, h, "/api/v1/pipelines")
 	var resp []map[string]interface{}
 	decode(t, rr, &resp)
@@ -194,7 +244,7 @@ func TestListPipelines_FieldsPresent(t *testing.T) {
 }
 
 func TestListPipelines_MethodNotAllowed(t *testing.T) {
-	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := httptest.NewRecorder()
 	h.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/api/v1/pipelines", nil))
 	if rr.Code != http.StatusMethodNotAllowed {
@@ -205,7 +255,7 @@ func TestListPipelines_MethodNotAllowed(t *testing.T) {
 // --- /api/v1/pipelines/{id} -------------------------------------------------
 
 func TestGetPipeline_Found(t *testing.T) {
-	h := api.New(newStore(snap("otel-prod", "healthy", 88.0)), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(snap("otel-prod", "healthy", 88.0)), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := get(t, h, "/api/v1/pipelines/otel-prod")
 
 	if rr.Code != http.StatusOK {
@@ -222,7 +272,7 @@ func TestGetPipeline_Found(t *testing.T) {
 }
 
 func TestGetPipeline_NotFound(t *testing.T) {
-	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := get(t, h, "/api/v1/pipelines/does-not-exist")
 	if rr.Code != http.StatusNotFound {
 		t.Errorf("status: got %d, want 404", rr.Code)
@@ -230,7 +280,7 @@ func TestGetPipeline_NotFound(t *testing.T) {
 }
 
 func TestGetPipeline_MethodNotAllowed(t *testing.T) {
-	h := api.New(newStore(snap("src", "healthy", 90.0)), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(snap("src", "healthy", 90.0)), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := httptest.NewRecorder()
 	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/api/v1/pipelines/src", nil))
 	if rr.Code != http.StatusMethodNotAllowed {
@@ -241,7 +291,7 @@ func TestGetPipeline_MethodNotAllowed(t *testing.T) {
 // --- /api/v1/signals --------------------------------------------------------
 
 func TestSignals_NoData(t *testing.T) {
-	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := get(t, h, "/api/v1/signals")
 
 	if rr.Code != http.StatusOK {
@@ -267,7 +317,7 @@ func TestSignals_Aggregation(t *testing.T) {
 			{Type: "metrics", ReceivedPm: 2000, DroppedPm: 100},
 			{Type: "traces", ReceivedPm: 300, DroppedPm: 0},
 		}),
-	), alerts.New(svrconfig.AlertsConfig{}))
+	), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := get(t, h, "/api/v1/signals")
 
 	if rr.Code != http.StatusOK {
@@ -301,7 +351,7 @@ func TestSignals_Aggregation(t *testing.T) {
 }
 
 func TestSignals_MethodNotAllowed(t *testing.T) {
-	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := httptest.NewRecorder()
 	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/signals", nil))
 	if rr.Code != http.StatusMethodNotAllowed {
@@ -312,7 +362,7 @@ func TestSignals_MethodNotAllowed(t *testing.T) {
 // --- /api/v1/alerts ---------------------------------------------------------
 
 func TestAlerts_ReturnsEmptyArray(t *testing.T) {
-	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := get(t, h, "/api/v1/alerts")
 
 	if rr.Code != http.StatusOK {
@@ -328,10 +378,253 @@ func TestAlerts_ReturnsEmptyArray(t *testing.T) {
 	}
 }
 
+func TestAlerts_PendingState(t *testing.T) {
+	engine := alerts.New(svrconfig.AlertsConfig{Rules: []svrconfig.AlertRule{
+		{Name: "drops", Condition: "drop_pct > 10", For: time.Hour},
+	}})
+	s := snap("otel", "degraded", 60)
+	s.DropPct = 20
+	engine.Evaluate(s)
+
+	rr := get(t, api.New(newStore(s), engine, nil), "/api/v1/alerts")
+	var resp []map[string]interface{}
+	decode(t, rr, &resp)
+	if len(resp) != 1 {
+		t.Fatalf("alerts: got %d items, want 1", len(resp))
+	}
+	if resp[0]["state"] != "pending" {
+		t.Errorf("state: got %v, want pending", resp[0]["state"])
+	}
+	if _, ok := resp[0]["fired_at"]; ok {
+		t.Errorf("fired_at present on a pending alert: %v", resp[0]["fired_at"])
+	}
+	if resp[0]["active_at"] == nil {
+		t.Error("active_at missing")
+	}
+}
+
+func TestAlerts_AckUnack(t *testing.T) {
+	engine := alerts.New(svrconfig.AlertsConfig{Rules: []svrconfig.AlertRule{
+		{Name: "drops", Condition: "drop_pct > 10"},
+	}})
+	s := snap("otel", "degraded", 60)
+	s.DropPct = 20
+	engine.Evaluate(s)
+	h := api.New(newStore(s), engine, nil)
+
+	var list []alerts.Alert
+	decode(t, get(t, h, "/api/v1/alerts"), &list)
+	id := list[0].ID
+
+	rr := send(t, h, http.MethodPost, "/api/v1/alerts/"+id+"/ack", `{"acknowledged_by": "alice", "note": "on it"}`)
+	var acked alerts.Alert
+	decode(t, rr, &acked)
+	if rr.Code != http.StatusOK || acked.AcknowledgedBy != "alice" || acked.Note != "on it" || acked.AcknowledgedAt == nil {
+		t.Fatalf("ack: got %d %+v", rr.Code, acked)
+	}
+	decode(t, get(t, h, "/api/v1/alerts"), &list)
+	if list[0].AcknowledgedBy != "alice" {
+		t.Errorf("alerts after ack: got %+v", list[0])
+	}
+
+	rr = send(t, h, http.MethodPost, "/api/v1/alerts/"+id+"/unack", "")
+	var unacked alerts.Alert
+	decode(t, rr, &unacked)
+	if rr.Code != http.StatusOK || unacked.AcknowledgedAt != nil || unacked.AcknowledgedBy != "" {
+		t.Errorf("unack: got %d %+v", rr.Code, unacked)
+	}
+
+	for path, want := range map[string]int{
+		"/api/v1/alerts/nope/ack":       http.StatusNotFound,
+		"/api/v1/alerts/" + id + "/x":   http.StatusNotFound,
+		"/api/v1/alerts/" + id + "/ack": http.StatusOK, // empty body
+	} {
+		if rr := send(t, h, http.MethodPost, path, ""); rr.Code != want {
+			t.Errorf("POST %s: got %d, want %d", path, rr.Code, want)
+		}
+	}
+	if rr := get(t, h, "/api/v1/alerts/"+id+"/ack"); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET ack: got %d, want 405", rr.Code)
+	}
+}
+
+func TestAlertHistory_FilterAndPage(t *testing.T) {
+	engine := alerts.New(svrconfig.AlertsConfig{Rules: []svrconfig.AlertRule{
+		{Name: "drops", Condition: "drop_pct > 10", Severity: "critical"},
+		{Name: "degraded", Condition: "drop_pct > 5"},
+	}})
+	for _, id := range []string{"otel", "loki"} {
+		s := snap(id, "degraded", 60)
+		s.DropPct = 20
+		engine.Evaluate(s)
+		s.DropPct = 0
+		engine.Evaluate(s)
+	}
+	h := api.New(newStore(), engine, nil)
+
+	var resp api.AlertHistoryResponse
+	decode(t, get(t, h, "/api/v1/alerts/history"), &resp)
+	if resp.Total != 4 || len(resp.Alerts) != 4 || resp.Limit != 100 {
+		t.Fatalf("all: got total %d, %d alerts, limit %d", resp.Total, len(resp.Alerts), resp.Limit)
+	}
+
+	decode(t, get(t, h, "/api/v1/alerts/history?rule=drops&limit=1&offset=1"), &resp)
+	if resp.Total != 2 || len(resp.Alerts) != 1 || resp.Alerts[0].RuleName != "drops" || resp.Alerts[0].SourceID != "otel" {
+		t.Errorf("rule page 2: got total %d, %+v", resp.Total, resp.Alerts)
+	}
+
+	decode(t, get(t, h, "/api/v1/alerts/history?source=loki&severity=warning"), &resp)
+	if resp.Total != 1 || resp.Alerts[0].RuleName != "degraded" {
+		t.Errorf("source+severity: got %+v", resp.Alerts)
+	}
+
+	decode(t, get(t, h, "/api/v1/alerts/history?to=1"), &resp)
+	if resp.Total != 0 || resp.Alerts == nil {
+		t.Errorf("to=1: got %+v, want an empty list", resp)
+	}
+
+	for _, q := range []string{"limit=0", "limit=5000", "offset=-1", "from=yesterday"} {
+		if rr := get(t, h, "/api/v1/alerts/history?"+q); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", q, rr.Code)
+		}
+	}
+}
+
+func TestAlertDeliveries_ListAndResend(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer hook.Close()
+	t.Setenv("HOOK_URL", hook.URL)
+
+	engine := alerts.New(svrconfig.AlertsConfig{
+		Rules: []svrconfig.AlertRule{{Name: "drops", Condition: "drop_pct > 10"}},
+		Webhooks: []svrconfig.WebhookConfig{{
+			Type: "http", URLEnv: "HOOK_URL", Retry: svrconfig.RetryConfig{MaxAttempts: 1},
+		}},
+	})
+	s := snap("otel", "degraded", 60)
+	s.DropPct = 20
+	engine.Evaluate(s) // delivers in the background
+	h := api.New(newStore(s), engine, nil)
+
+	var list []alerts.Delivery
+	for deadline := time.Now().Add(5 * time.Second); len(list) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("no dead-lettered delivery")
+		}
+		time.Sleep(10 * time.Millisecond)
+		decode(t, get(t, h, "/api/v1/alerts/deliveries"), &list)
+	}
+	if d := list[0]; d.RuleName != "drops" || d.Attempts != 1 || d.Status != alerts.DeliveryFailed {
+		t.Errorf("delivery: got %+v", d)
+	}
+	path := "/api/v1/alerts/deliveries/" + list[0].ID + "/resend"
+
+	if rr := send(t, h, http.MethodPost, path, ""); rr.Code != http.StatusBadGateway {
+		t.Errorf("resend while failing: got %d, want 502", rr.Code)
+	}
+	failing.Store(false)
+	rr := send(t, h, http.MethodPost, path, "")
+	var d alerts.Delivery
+	decode(t, rr, &d)
+	if rr.Code != http.StatusOK || d.Status != alerts.DeliveryDelivered || d.Attempts != 3 {
+		t.Errorf("resend: got %d %+v", rr.Code, d)
+	}
+	decode(t, get(t, h, "/api/v1/alerts/deliveries"), &list)
+	if len(list) != 0 {
+		t.Errorf("deliveries after resend: got %+v, want none", list)
+	}
+
+	for path, want := range map[string]int{
+		path:                                 http.StatusNotFound, // delivered
+		"/api/v1/alerts/deliveries/d1/retry": http.StatusNotFound,
+	} {
+		if rr := send(t, h, http.MethodPost, path, ""); rr.Code != want {
+			t.Errorf("POST %s: got %d, want %d", path, rr.Code, want)
+		}
+	}
+	if rr := get(t, h, path); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET resend: got %d, want 405", rr.Code)
+	}
+}
+
+// --- /api/v1/silences -------------------------------------------------------
+
+func TestSilences_CreateListExpire(t *testing.T) {
+	engine := alerts.New(svrconfig.AlertsConfig{Rules: []svrconfig.AlertRule{
+		{Name: "drops", Condition: "drop_pct > 10"},
+	}})
+	s := snap("loki", "degraded", 60)
+	s.DropPct = 20
+	h := api.New(newStore(s), engine, nil)
+
+	rr := send(t, h, http.MethodPost, "/api/v1/silences",
+		`{"rule": "drops", "match": {"source_id": "loki"}, "duration": "2h", "comment": "loki upgrade"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create: got %d, want 201: %s", rr.Code, rr.Body)
+	}
+	var created alerts.Silence
+	decode(t, rr, &created)
+	if created.ID == "" || created.State != alerts.SilenceActive {
+		t.Fatalf("created: got %+v, want an active silence with an ID", created)
+	}
+
+	engine.Evaluate(s)
+	var active []alerts.Alert
+	decode(t, get(t, h, "/api/v1/alerts"), &active)
+	if len(active) != 1 || !active[0].Silenced || active[0].SilencedBy[0] != created.ID {
+		t.Fatalf("alerts: got %+v, want one alert silenced by %s", active, created.ID)
+	}
+
+	var list []alerts.Silence
+	decode(t, get(t, h, "/api/v1/silences"), &list)
+	if len(list) != 1 || list[0].ID != created.ID {
+		t.Fatalf("list: got %+v", list)
+	}
+
+	rr = send(t, h, http.MethodDelete, "/api/v1/silences/"+created.ID, "")
+	var expired alerts.Silence
+	decode(t, rr, &expired)
+	if rr.Code != http.StatusOK || expired.State != alerts.SilenceExpired {
+		t.Fatalf("expire: got %d %+v", rr.Code, expired)
+	}
+	decode(t, get(t, h, "/api/v1/alerts"), &active)
+	if active[0].Silenced {
+		t.Error("alert still silenced after the silence expired")
+	}
+
+	if rr := send(t, h, http.MethodDelete, "/api/v1/silences/nope", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("expire unknown: got %d, want 404", rr.Code)
+	}
+}
+
+func TestSilences_CreateInvalid(t *testing.T) {
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), nil)
+	for name, body := range map[string]string{
+		"no end":         `{"comment": "x"}`,
+		"no comment":     `{"duration": "1h"}`,
+		"end and dur":    `{"comment": "x", "duration": "1h", "ends_at": "2099-01-01T00:00:00Z"}`,
+		"past end":       `{"comment": "x", "ends_at": "2000-01-01T00:00:00Z"}`,
+		"bad label":      `{"comment": "x", "duration": "1h", "match": {"region": "eu"}}`,
+		"bad rule glob":  `{"comment": "x", "duration": "1h", "rule": "["}`,
+		"bad duration":   `{"comment": "x", "duration": "soon"}`,
+		"malformed JSON": `{`,
+	} {
+		if rr := send(t, h, http.MethodPost, "/api/v1/silences", body); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", name, rr.Code)
+		}
+	}
+}
+
 // --- /api/v1/certs ----------------------------------------------------------
 
 func TestCerts_ReturnsEmptyArray_NoCerts(t *testing.T) {
-	h := api.New(newStore(snap("otel", "healthy", 90.0)), alerts.New(svrconfig.AlertsConfig{})) // snap has no certs
+	h := api.New(newStore(snap("otel", "healthy", 90.0)), alerts.New(svrconfig.AlertsConfig{}), nil) // snap has no certs
 	rr := get(t, h, "/api/v1/certs")
 
 	if rr.Code != http.StatusOK {
@@ -351,7 +644,7 @@ func TestCerts_ReturnsCertData(t *testing.T) {
 			{Endpoint: "https://otel:4317", AuthType: "mtls", Status: "valid", DaysLeft: 45},
 		},
 	}
-	h := api.New(newStore(s), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(s), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := get(t, h, "/api/v1/certs")
 
 	var resp []map[string]interface{}
@@ -370,7 +663,7 @@ func TestCerts_ReturnsCertData(t *testing.T) {
 // --- /api/v1/snapshot -------------------------------------------------------
 
 func TestSnapshot_Empty(t *testing.T) {
-	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := get(t, h, "/api/v1/snapshot")
 
 	if rr.Code != http.StatusOK {
@@ -391,7 +684,7 @@ func TestSnapshot_AllLivePipelines(t *testing.T) {
 	h := api.New(newStore(
 		snap("otel", "healthy", 90.0),
 		snap("prom", "degraded", 70.0),
-	), alerts.New(svrconfig.AlertsConfig{}))
+	), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := get(t, h, "/api/v1/snapshot")
 
 	var resp map[string]interface{}
@@ -403,7 +696,7 @@ func TestSnapshot_AllLivePipelines(t *testing.T) {
 }
 
 func TestSnapshot_MethodNotAllowed(t *testing.T) {
-	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), nil)
 	rr := httptest.NewRecorder()
 	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPatch, "/api/v1/snapshot", nil))
 	if rr.Code != http.StatusMethodNotAllowed {
@@ -411,10 +704,154 @@ func TestSnapshot_MethodNotAllowed(t *testing.T) {
 	}
 }
 
+// --- GET /api/v1/pipelines/{id}/history -------------------------------------
+
+var histBase = time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)
+
+// newHistory returns a SQLite history store holding, for source "otel", one
+// snapshot every 30s from histBase for 4 minutes, with drop_pct = minute
+// index + 1 for the first snapshot of each minute and 10 × that for the
+// second, plus a logs signal on every snapshot.
+func newHistory(t *testing.T) history.Store {
+	t.Helper()
+	db, err := history.OpenSQLite(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for i := 0; i < 8; i++ {
+		v := float64(i/2 + 1)
+		if i%2 == 1 {
+			v *= 10
+		}
+		rec := history.Record{
+			Snapshot: &pb.PipelineSnapshot{
+				SourceId:      "otel",
+				DropPct:       v,
+				StrengthScore: 90,
+				Signals:       []*pb.SignalStats{{Type: "logs", ReceivedPm: 100 * v}},
+			},
+			Timestamp:  histBase.Add(time.Duration(i) * 30 * time.Second),
+			ReceivedAt: histBase.Add(time.Hour), // all replayed at once after an outage
+		}
+		if err := db.Write(context.Background(), rec); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	return db
+}
+
+func getHistory(t *testing.T, h http.Handler, query string) api.HistoryResponse {
+	t.Helper()
+	rr := get(t, h, "/api/v1/pipelines/otel/history?"+query)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200 (body: %s)", rr.Code, rr.Body.String())
+	}
+	var resp api.HistoryResponse
+	decode(t, rr, &resp)
+	return resp
+}
+
+func historyRange(step string) string {
+	return "from=" + histBase.Format(time.RFC3339) +
+		"&to=" + histBase.Add(4*time.Minute).Format(time.RFC3339) + "&step=" + step
+}
+
+func TestHistory_AvgPerStep(t *testing.T) {
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), newHistory(t))
+	resp := getHistory(t, h, historyRange("1m")+"&fields=drop_pct")
+
+	if resp.SourceID != "otel" || resp.StepSeconds != 60 || resp.Agg != "avg" {
+		t.Errorf("header: got %+v", resp)
+	}
+	if len(resp.Series) != 1 || resp.Series[0].Field != "drop_pct" {
+		t.Fatalf("series: got %+v, want one drop_pct series", resp.Series)
+	}
+	pts := resp.Series[0].Points
+	if len(pts) != 4 {
+		t.Fatalf("points: got %d, want 4", len(pts))
+	}
+	// Minute 0 holds drop_pct 1 and 10.
+	if pts[0].V != 5.5 {
+		t.Errorf("points[0].v: got %v, want 5.5", pts[0].V)
+	}
+	if pts[0].T != histBase.Format(time.RFC3339) {
+		t.Errorf("points[0].t: got %q, want %q", pts[0].T, histBase.Format(time.RFC3339))
+	}
+	if pts[3].V != 22 {
+		t.Errorf("points[3].v: got %v, want 22", pts[3].V)
+	}
+}
+
+func TestHistory_MinMax(t *testing.T) {
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), newHistory(t))
+	for agg, want := range map[string]float64{"min": 2, "max": 20} {
+		resp := getHistory(t, h, historyRange("60")+"&fields=drop_pct&agg="+agg)
+		if got := resp.Series[0].Points[1].V; got != want {
+			t.Errorf("agg=%s points[1].v: got %v, want %v", agg, got, want)
+		}
+	}
+}
+
+func TestHistory_SignalField(t *testing.T) {
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), newHistory(t))
+	resp := getHistory(t, h, historyRange("2m")+"&fields=logs.received_pm,traces.drop_pct&agg=max")
+	if len(resp.Series) != 2 {
+		t.Fatalf("series: got %d, want 2", len(resp.Series))
+	}
+	if pts := resp.Series[0].Points; len(pts) != 2 || pts[1].V != 4000 {
+		t.Errorf("logs.received_pm: got %+v, want 2 points ending at 4000", pts)
+	}
+	// No snapshot reports traces, so the series has no points.
+	if pts := resp.Series[1].Points; len(pts) != 0 {
+		t.Errorf("traces.drop_pct: got %d points, want 0", len(pts))
+	}
+}
+
+func TestHistory_DefaultFields(t *testing.T) {
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), newHistory(t))
+	resp := getHistory(t, h, historyRange("1m"))
+	fields := map[string]bool{}
+	for _, s := range resp.Series {
+		fields[s.Field] = true
+	}
+	for _, f := range []string{"drop_pct", "throughput_per_min", "strength_score", "uptime_pct", "logs.drop_pct"} {
+		if !fields[f] {
+			t.Errorf("default fields: missing %q", f)
+		}
+	}
+}
+
+func TestHistory_BadParams_400(t *testing.T) {
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), newHistory(t))
+	for _, q := range []string{
+		"from=yesterday",
+		"from=2026-03-02T00:00:00Z&to=2026-03-01T00:00:00Z",
+		"step=0s",
+		"from=0&to=1000000000&step=1s",
+		"fields=bogus",
+		"fields=spans.drop_pct",
+		"agg=median",
+	} {
+		rr := get(t, h, "/api/v1/pipelines/otel/history?"+q)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status got %d, want 400", q, rr.Code)
+		}
+	}
+}
+
+func TestHistory_Disabled_501(t *testing.T) {
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), nil)
+	rr := get(t, h, "/api/v1/pipelines/otel/history")
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("status: got %d, want 501", rr.Code)
+	}
+}
+
 // --- Content-Type -----------------------------------------------------------
 
 func TestContentTypeJSON(t *testing.T) {
-	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}))
+	h := api.New(newStore(), alerts.New(svrconfig.AlertsConfig{}), nil)
 	for _, path := range []string{
 		"/api/v1/health",
 		"/api/v1/pipelines",
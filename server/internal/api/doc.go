@@ -1,19 +1,47 @@
 // Package api implements the HTTP REST API for obsidianstack-server.
 //
-// New(store) returns an http.Handler that serves:
+// New(store, engine, history) returns an http.Handler that serves:
 //
 //	GET /api/v1/health          — overall score, state, per-state counts
 //	GET /api/v1/pipelines       — all live pipelines ([]PipelineResponse)
 //	GET /api/v1/pipelines/{id}  — single pipeline; 404 if unknown or stale
+//	GET /api/v1/pipelines/{id}/history — downsampled series from the history
+//	                              store; 501 when history is disabled
 //	GET /api/v1/signals         — metrics/logs/traces aggregated across pipelines
-//	GET /api/v1/alerts          — active alerts (empty until T021)
+//	GET /api/v1/alerts          — pending, firing and recently resolved alerts,
+//	                              silenced ones marked "silenced": true
+//	GET /api/v1/alerts/history  — resolved alerts (AlertHistoryResponse), most
+//	                              recently resolved first
+//	POST /api/v1/alerts/{id}/ack   — acknowledge a firing alert (optional
+//	                              AckRequest body); 404 unknown, 409 resolved
+//	POST /api/v1/alerts/{id}/unack — clear the acknowledgement
+//	GET /api/v1/alerts/deliveries — webhook deliveries that failed every
+//	                              retry ([]alerts.Delivery), newest first
+//	POST /api/v1/alerts/deliveries/{id}/resend — try a failed delivery again;
+//	                              404 unknown, 409 if superseded by a newer
+//	                              notification, 502 if it fails again
+//	GET /api/v1/silences        — pending, active and recently expired silences
+//	POST /api/v1/silences       — create a silence (SilenceRequest); 201
+//	DELETE /api/v1/silences/{id} — expire a silence now; 404 if unknown
 //	GET /api/v1/certs           — cert status per source endpoint
 //	GET /api/v1/snapshot        — full JSON dump: all live pipelines + generated_at
 //
 // All endpoints:
 //   - Respond with Content-Type: application/json
-//   - Return 405 for non-GET methods
+//   - Return 405 for methods other than those listed
 //   - Read live entries from the store (stale entries excluded from lists)
 //
+// The history endpoint takes from and to (RFC3339 or unix seconds; default
+// the last hour), step (duration or seconds; default about 240 points),
+// fields (comma-separated: drop_pct, throughput_per_min, strength_score,
+// uptime_pct, recovery_rate, latency_p{50,95,99}_ms, or
+// {metrics,logs,traces}.{received_pm,dropped_pm,drop_pct}) and agg
+// (avg|min|max per step, default avg). Invalid parameters return 400.
+//
+// The alert history endpoint filters by rule, source and severity (exact
+// match) and from/to (alerts active at some point in the range; same formats
+// as above), and pages with limit (1–1000, default 100) and offset. Without a
+// history store it covers alerts resolved since the server started.
+//
 // JSON types are defined in types.go. No external HTTP framework is used.
 package api
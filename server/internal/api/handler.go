@@ -6,28 +6,40 @@ import (
 	"strings"
 	"time"
 
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+
 	"github.com/obsidianstack/obsidianstack/server/internal/alerts"
+	"github.com/obsidianstack/obsidianstack/server/internal/diagnostics"
+	"github.com/obsidianstack/obsidianstack/server/internal/history"
 	"github.com/obsidianstack/obsidianstack/server/internal/store"
 )
 
 // Handler is the HTTP handler for all /api/v1/* endpoints.
 // It reads pipeline state from the snapshot store and returns JSON responses.
 type Handler struct {
-	store  *store.Store
-	engine *alerts.Engine
-	mux    *http.ServeMux
+	store   *store.Store
+	engine  *alerts.Engine
+	history history.Store // nil when history is disabled
+	mux     *http.ServeMux
 }
 
-// New creates a Handler wired to the given snapshot store and alerts engine,
-// and registers all routes.
-func New(st *store.Store, engine *alerts.Engine) http.Handler {
-	h := &Handler{store: st, engine: engine, mux: http.NewServeMux()}
+// New creates a Handler wired to the given snapshot store, alerts engine and
+// history store, and registers all routes. hist may be nil, in which case the
+// history endpoint returns 501.
+func New(st *store.Store, engine *alerts.Engine, hist history.Store) http.Handler {
+	h := &Handler{store: st, engine: engine, history: hist, mux: http.NewServeMux()}
 
 	h.mux.HandleFunc("/api/v1/health", h.health)
 	h.mux.HandleFunc("/api/v1/pipelines", h.listPipelines)
-	h.mux.HandleFunc("/api/v1/pipelines/", h.getPipeline) // subtree — extracts {id}
+	h.mux.HandleFunc("/api/v1/pipelines/", h.getPipeline) // subtree — extracts {id}[/history]
 	h.mux.HandleFunc("/api/v1/signals", h.signals)
 	h.mux.HandleFunc("/api/v1/alerts", h.alerts)
+	h.mux.HandleFunc("/api/v1/alerts/", h.alertAction) // subtree — {id}/ack, {id}/unack
+	h.mux.HandleFunc("/api/v1/alerts/history", h.alertHistory)
+	h.mux.HandleFunc("/api/v1/alerts/deliveries", h.deliveries)
+	h.mux.HandleFunc("/api/v1/alerts/deliveries/", h.resendDelivery) // subtree — {id}/resend
+	h.mux.HandleFunc("/api/v1/silences", h.silences)
+	h.mux.HandleFunc("/api/v1/silences/", h.silence) // subtree — extracts {id}
 	h.mux.HandleFunc("/api/v1/certs", h.certs)
 	h.mux.HandleFunc("/api/v1/snapshot", h.snapshot)
 
@@ -58,9 +70,12 @@ func (h *Handler) health(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var totalScore float64
+	var totalScore, totalHealthy, totalDegraded float64
 	for _, e := range entries {
 		totalScore += e.Snapshot.StrengthScore
+		healthy, degraded := thresholdsOf(e.Snapshot)
+		totalHealthy += healthy
+		totalDegraded += degraded
 		switch e.Snapshot.State {
 		case "healthy":
 			resp.HealthyCount++
@@ -73,8 +88,11 @@ func (h *Handler) health(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	resp.OverallScore = totalScore / float64(len(entries))
-	resp.State = stateFromScore(resp.OverallScore)
+	// The overall score is an average, so it is judged against the average of
+	// the thresholds each agent actually scored its pipeline with.
+	n := float64(len(entries))
+	resp.OverallScore = totalScore / n
+	resp.State = stateFromScore(resp.OverallScore, totalHealthy/n, totalDegraded/n)
 	jsonResp(w, http.StatusOK, resp)
 }
 
@@ -93,7 +111,8 @@ func (h *Handler) listPipelines(w http.ResponseWriter, r *http.Request) {
 	jsonResp(w, http.StatusOK, out)
 }
 
-// getPipeline returns GET /api/v1/pipelines/{id} — a single live pipeline.
+// getPipeline returns GET /api/v1/pipelines/{id} — a single live pipeline —
+// and dispatches GET /api/v1/pipelines/{id}/history.
 func (h *Handler) getPipeline(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		jsonErr(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -101,6 +120,10 @@ func (h *Handler) getPipeline(w http.ResponseWriter, r *http.Request) {
 	}
 
 	id := strings.TrimPrefix(r.URL.Path, "/api/v1/pipelines/")
+	if hid, ok := strings.CutSuffix(id, "/history"); ok && hid != "" {
+		h.pipelineHistory(w, r, hid)
+		return
+	}
 	if id == "" {
 		// Redirect bare /api/v1/pipelines/ to list handler.
 		h.listPipelines(w, r)
@@ -153,7 +176,8 @@ func (h *Handler) signals(w http.ResponseWriter, r *http.Request) {
 	jsonResp(w, http.StatusOK, resp)
 }
 
-// alerts returns GET /api/v1/alerts — active and recently resolved alerts.
+// alerts returns GET /api/v1/alerts — pending, firing and recently resolved
+// alerts, including silenced ones.
 func (h *Handler) alerts(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		jsonErr(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -232,13 +256,28 @@ func jsonErr(w http.ResponseWriter, code int, msg string) {
 	jsonResp(w, code, errorResponse{Error: msg})
 }
 
-// stateFromScore converts a 0–100 score to a health state string.
-// Mirrors the thresholds in agent/internal/compute.
-func stateFromScore(score float64) string {
+// Default state thresholds, mirroring agent/internal/compute. Used for
+// snapshots from agents that do not report the thresholds they scored with.
+const (
+	defaultThresholdHealthy  = 85.0
+	defaultThresholdDegraded = 60.0
+)
+
+// thresholdsOf returns the healthy/degraded thresholds the agent used for snap.
+func thresholdsOf(snap *pb.PipelineSnapshot) (healthy, degraded float64) {
+	if snap.ThresholdHealthy == 0 && snap.ThresholdDegraded == 0 {
+		return defaultThresholdHealthy, defaultThresholdDegraded
+	}
+	return snap.ThresholdHealthy, snap.ThresholdDegraded
+}
+
+// stateFromScore converts a 0–100 score to a health state string using the
+// given thresholds.
+func stateFromScore(score, healthy, degraded float64) string {
 	switch {
-	case score >= 85:
+	case score >= healthy:
 		return "healthy"
-	case score >= 60:
+	case score >= degraded:
 		return "degraded"
 	default:
 		return "critical"
@@ -257,26 +296,29 @@ func toPipelineResponse(e *store.Entry) PipelineResponse {
 			DropPct:    s.DropPct,
 		})
 	}
+	healthy, degraded := thresholdsOf(snap)
 	return PipelineResponse{
-		SourceID:         snap.SourceId,
-		SourceType:       snap.SourceType,
-		NodeType:         snap.NodeType,
-		Cluster:          snap.Cluster,
-		Namespace:        snap.Namespace,
-		State:            snap.State,
-		DropPct:          snap.DropPct,
-		RecoveryRate:     snap.RecoveryRate,
-		ThroughputPerMin: snap.ThroughputPerMin,
-		LatencyP50Ms:     snap.LatencyP50Ms,
-		LatencyP95Ms:     snap.LatencyP95Ms,
-		LatencyP99Ms:     snap.LatencyP99Ms,
-		StrengthScore:    snap.StrengthScore,
-		UptimePct:        snap.UptimePct,
-		ErrorMessage:     snap.ErrorMessage,
-		Signals:          sigs,
-		Diagnostics:      computeDiagnostics(snap),
-		Extra:            snap.Extra,
-		LastSeen:         e.UpdatedAt.UTC().Format(time.RFC3339),
+		SourceID:          snap.SourceId,
+		SourceType:        snap.SourceType,
+		NodeType:          snap.NodeType,
+		Cluster:           snap.Cluster,
+		Namespace:         snap.Namespace,
+		State:             snap.State,
+		DropPct:           snap.DropPct,
+		RecoveryRate:      snap.RecoveryRate,
+		ThroughputPerMin:  snap.ThroughputPerMin,
+		LatencyP50Ms:      snap.LatencyP50Ms,
+		LatencyP95Ms:      snap.LatencyP95Ms,
+		LatencyP99Ms:      snap.LatencyP99Ms,
+		StrengthScore:     snap.StrengthScore,
+		UptimePct:         snap.UptimePct,
+		ErrorMessage:      snap.ErrorMessage,
+		ThresholdHealthy:  healthy,
+		ThresholdDegraded: degraded,
+		Signals:           sigs,
+		Diagnostics:       diagnostics.Compute(snap),
+		Extra:             snap.Extra,
+		LastSeen:          e.UpdatedAt.UTC().Format(time.RFC3339),
 	}
 }
 
@@ -289,4 +331,3 @@ func toAggregate(recv, drop float64) SignalAggregate {
 	}
 	return agg
 }
-
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/server/internal/history"
+)
+
+// History query limits and defaults.
+const (
+	defaultHistoryRange = time.Hour
+	defaultHistoryAgg   = "avg"
+	// maxHistoryPoints caps the buckets per series; a step too small for the
+	// range is rejected, and the default step targets defaultHistoryPoints.
+	maxHistoryPoints     = 11000
+	defaultHistoryPoints = 240
+)
+
+// defaultHistoryFields are returned when the fields parameter is absent.
+var defaultHistoryFields = []string{
+	"drop_pct", "throughput_per_min", "strength_score", "uptime_pct",
+	"metrics.received_pm", "metrics.dropped_pm", "metrics.drop_pct",
+	"logs.received_pm", "logs.dropped_pm", "logs.drop_pct",
+	"traces.received_pm", "traces.dropped_pm", "traces.drop_pct",
+}
+
+// snapshotFields extracts the pipeline-level history fields.
+var snapshotFields = map[string]func(*pb.PipelineSnapshot) float64{
+	"drop_pct":           func(s *pb.PipelineSnapshot) float64 { return s.DropPct },
+	"recovery_rate":      func(s *pb.PipelineSnapshot) float64 { return s.RecoveryRate },
+	"throughput_per_min": func(s *pb.PipelineSnapshot) float64 { return s.ThroughputPerMin },
+	"latency_p50_ms":     func(s *pb.PipelineSnapshot) float64 { return s.LatencyP50Ms },
+	"latency_p95_ms":     func(s *pb.PipelineSnapshot) float64 { return s.LatencyP95Ms },
+	"latency_p99_ms":     func(s *pb.PipelineSnapshot) float64 { return s.LatencyP99Ms },
+	"strength_score":     func(s *pb.PipelineSnapshot) float64 { return s.StrengthScore },
+	"uptime_pct":         func(s *pb.PipelineSnapshot) float64 { return s.UptimePct },
+}
+
+// signalFields extracts the per-signal history fields, named "<type>.<stat>".
+var signalFields = map[string]func(*pb.SignalStats) float64{
+	"received_pm": func(s *pb.SignalStats) float64 { return s.ReceivedPm },
+	"dropped_pm":  func(s *pb.SignalStats) float64 { return s.DroppedPm },
+	"drop_pct":    func(s *pb.SignalStats) float64 { return s.DropPct },
+}
+
+// historyQuery is a parsed GET /api/v1/pipelines/{id}/history request.
+type historyQuery struct {
+	from, to time.Time
+	step     time.Duration
+	fields   []string
+	agg      string
+}
+
+// pipelineHistory returns GET /api/v1/pipelines/{id}/history — downsampled
+// series of the requested fields over [from, to).
+func (h *Handler) pipelineHistory(w http.ResponseWriter, r *http.Request, id string) {
+	if h.history == nil {
+		jsonErr(w, http.StatusNotImplemented, "history storage is not enabled")
+		return
+	}
+	q, err := parseHistoryQuery(r, time.Now())
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recs, err := h.history.Query(r.Context(), id, q.from, q.to)
+	if err != nil {
+		jsonErr(w, http.StatusInternalServerError, "history query failed")
+		return
+	}
+
+	jsonResp(w, http.StatusOK, HistoryResponse{
+		SourceID:    id,
+		From:        q.from.UTC().Format(time.RFC3339),
+		To:          q.to.UTC().Format(time.RFC3339),
+		StepSeconds: q.step.Seconds(),
+		Agg:         q.agg,
+		Series:      downsample(recs, q),
+	})
+}
+
+// parseHistoryQuery reads from, to, step, fields and agg from r's query
+// string. from and to accept RFC3339 or unix seconds; to defaults to now and
+// from to one hour before to. step defaults to a value giving about
+// defaultHistoryPoints buckets.
+func parseHistoryQuery(r *http.Request, now time.Time) (historyQuery, error) {
+	v := r.URL.Query()
+	q := historyQuery{to: now, agg: defaultHistoryAgg, fields: defaultHistoryFields}
+
+	var err error
+	if s := v.Get("to"); s != "" {
+		if q.to, err = parseTime(s); err != nil {
+			return q, fmt.Errorf("to: %w", err)
+		}
+	}
+	q.from = q.to.Add(-defaultHistoryRange)
+	if s := v.Get("from"); s != "" {
+		if q.from, err = parseTime(s); err != nil {
+			return q, fmt.Errorf("from: %w", err)
+		}
+	}
+	if !q.from.Before(q.to) {
+		return q, fmt.Errorf("from must be before to")
+	}
+
+	span := q.to.Sub(q.from)
+	if s := v.Get("step"); s != "" {
+		if q.step, err = parseStep(s); err != nil {
+			return q, fmt.Errorf("step: %w", err)
+		}
+		if span/q.step > maxHistoryPoints {
+			return q, fmt.Errorf("step %s too small for range: more than %d points", q.step, maxHistoryPoints)
+		}
+	} else {
+		q.step = (span / defaultHistoryPoints).Round(time.Second)
+		if q.step < time.Second {
+			q.step = time.Second
+		}
+	}
+
+	if s := v.Get("fields"); s != "" {
+		q.fields = nil
+		for _, f := range strings.Split(s, ",") {
+			f = strings.TrimSpace(f)
+			if !validHistoryField(f) {
+				return q, fmt.Errorf("unknown field %q", f)
+			}
+			q.fields = append(q.fields, f)
+		}
+	}
+
+	if s := v.Get("agg"); s != "" {
+		switch s {
+		case "avg", "min", "max":
+			q.agg = s
+		default:
+			return q, fmt.Errorf("agg %q unknown: want avg|min|max", s)
+		}
+	}
+	return q, nil
+}
+
+// parseTime accepts RFC3339 or integer unix seconds.
+func parseTime(s string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither RFC3339 nor unix seconds", s)
+	}
+	return t, nil
+}
+
+// parseStep accepts a Go duration ("5m") or integer seconds; the result is
+// at least one second.
+func parseStep(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		secs, perr := strconv.ParseInt(s, 10, 64)
+		if perr != nil {
+			return 0, fmt.Errorf("%q is neither a duration nor seconds", s)
+		}
+		d = time.Duration(secs) * time.Second
+	}
+	if d < time.Second {
+		return 0, fmt.Errorf("must be at least 1s")
+	}
+	return d, nil
+}
+
+func validHistoryField(f string) bool {
+	if _, ok := snapshotFields[f]; ok {
+		return true
+	}
+	sig, stat, ok := strings.Cut(f, ".")
+	if !ok {
+		return false
+	}
+	switch sig {
+	case "metrics", "logs", "traces":
+	default:
+		return false
+	}
+	_, ok = signalFields[stat]
+	return ok
+}
+
+// bucket accumulates the values of one field within one step.
+type bucket struct {
+	min, max, sum float64
+	n             int
+}
+
+func (b *bucket) add(v float64) {
+	if b.n == 0 || v < b.min {
+		b.min = v
+	}
+	if b.n == 0 || v > b.max {
+		b.max = v
+	}
+	b.sum += v
+	b.n++
+}
+
+func (b *bucket) value(agg string) float64 {
+	switch agg {
+	case "min":
+		return b.min
+	case "max":
+		return b.max
+	}
+	return b.sum / float64(b.n)
+}
+
+// downsample groups recs by snapshot time into step-wide buckets starting at
+// q.from and aggregates each requested field per bucket. Buckets without data
+// are omitted, so gaps in reporting show as gaps in the series. Signal fields
+// are only sampled from snapshots that report that signal.
+func downsample(recs []history.Record, q historyQuery) []HistorySeries {
+	nBuckets := int(math.Ceil(float64(q.to.Sub(q.from)) / float64(q.step)))
+	buckets := make([][]bucket, len(q.fields))
+	for i := range buckets {
+		buckets[i] = make([]bucket, nBuckets)
+	}
+
+	for _, rec := range recs {
+		idx := int(rec.Timestamp.Sub(q.from) / q.step)
+		if idx < 0 || idx >= nBuckets {
+			continue
+		}
+		for i, f := range q.fields {
+			if v, ok := fieldValue(rec.Snapshot, f); ok {
+				buckets[i][idx].add(v)
+			}
+		}
+	}
+
+	series := make([]HistorySeries, len(q.fields))
+	for i, f := range q.fields {
+		points := make([]HistoryPoint, 0)
+		for j := range buckets[i] {
+			b := &buckets[i][j]
+			if b.n == 0 {
+				continue
+			}
+			points = append(points, HistoryPoint{
+				T: q.from.Add(time.Duration(j) * q.step).UTC().Format(time.RFC3339),
+				V: b.value(q.agg),
+			})
+		}
+		series[i] = HistorySeries{Field: f, Points: points}
+	}
+	return series
+}
+
+// fieldValue returns field f of snap, and false when snap does not report it.
+func fieldValue(snap *pb.PipelineSnapshot, f string) (float64, bool) {
+	if get, ok := snapshotFields[f]; ok {
+		return get(snap), true
+	}
+	sig, stat, _ := strings.Cut(f, ".")
+	for _, s := range snap.Signals {
+		if s.Type == sig {
+			return signalFields[stat](s), true
+		}
+	}
+	return 0, false
+}
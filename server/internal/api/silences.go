@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/alerts"
+	"github.com/obsidianstack/obsidianstack/server/internal/auth"
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+// silences serves GET /api/v1/silences (list) and POST /api/v1/silences
+// (create).
+func (h *Handler) silences(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		jsonResp(w, http.StatusOK, h.engine.Silences())
+	case http.MethodPost:
+		h.createSilence(w, r)
+	default:
+		jsonErr(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// silence serves DELETE /api/v1/silences/{id}, which expires the silence.
+func (h *Handler) silence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		jsonErr(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/api/v1/silences/")
+	s, err := h.engine.ExpireSilence(id)
+	if errors.Is(err, alerts.ErrSilenceNotFound) {
+		jsonErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusOK, s)
+}
+
+func (h *Handler) createSilence(w http.ResponseWriter, r *http.Request) {
+	var req SilenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonErr(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	s, err := toSilence(req, time.Now())
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if s.CreatedBy == "" {
+		s.CreatedBy = auth.CredentialFrom(r.Context())
+	}
+	s, err = h.engine.AddSilence(s)
+	if err != nil {
+		jsonErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	jsonResp(w, http.StatusCreated, s)
+}
+
+// toSilence converts a request body to a Silence; the engine validates the
+// rest.
+func toSilence(req SilenceRequest, now time.Time) (alerts.Silence, error) {
+	s := alerts.Silence{
+		Rule:      req.Rule,
+		Match:     config.Selector(req.Match),
+		Comment:   req.Comment,
+		CreatedBy: req.CreatedBy,
+	}
+	var err error
+	if req.StartsAt != "" {
+		if s.StartsAt, err = parseTime(req.StartsAt); err != nil {
+			return s, fmt.Errorf("starts_at: %w", err)
+		}
+	}
+	switch {
+	case req.EndsAt != "" && req.Duration != "":
+		return s, errors.New("set either ends_at or duration, not both")
+	case req.EndsAt != "":
+		if s.EndsAt, err = parseTime(req.EndsAt); err != nil {
+			return s, fmt.Errorf("ends_at: %w", err)
+		}
+	case req.Duration != "":
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			return s, fmt.Errorf("duration: %q is not a positive duration", req.Duration)
+		}
+		start := s.StartsAt
+		if start.IsZero() {
+			start = now
+		}
+		s.EndsAt = start.Add(d)
+	}
+	return s, nil
+}
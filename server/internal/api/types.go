@@ -1,5 +1,10 @@
 package api
 
+import (
+	"github.com/obsidianstack/obsidianstack/server/internal/alerts"
+	"github.com/obsidianstack/obsidianstack/server/internal/diagnostics"
+)
+
 // HealthResponse is the payload for GET /api/v1/health.
 type HealthResponse struct {
 	OverallScore  float64 `json:"overall_score"`
@@ -30,8 +35,12 @@ type PipelineResponse struct {
 	StrengthScore    float64          `json:"strength_score"`
 	UptimePct        float64          `json:"uptime_pct"`
 	ErrorMessage     string           `json:"error_message,omitempty"`
+	// ThresholdHealthy / ThresholdDegraded are the score cut-offs the agent
+	// derived State with (per-source scoring overrides, or the 85/60 defaults).
+	ThresholdHealthy  float64 `json:"threshold_healthy"`
+	ThresholdDegraded float64 `json:"threshold_degraded"`
 	Signals          []SignalResponse   `json:"signals"`
-	Diagnostics      []DiagnosticHint  `json:"diagnostics"`
+	Diagnostics      []diagnostics.Hint  `json:"diagnostics"`
 	// Extra carries component-specific metrics. For otelcol: queue_size,
 	// queue_capacity, and per-minute rates for exporter_sent_*, receiver_refused_*,
 	// exporter_send_failed_*, processor_dropped_* (all with _pm suffix).
@@ -67,7 +76,56 @@ type SnapshotResponse struct {
 	GeneratedAt string             `json:"generated_at"` // RFC3339
 }
 
+// HistoryResponse is the payload for GET /api/v1/pipelines/{id}/history.
+type HistoryResponse struct {
+	SourceID    string          `json:"source_id"`
+	From        string          `json:"from"` // RFC3339
+	To          string          `json:"to"`   // RFC3339
+	StepSeconds float64         `json:"step_seconds"`
+	Agg         string          `json:"agg"` // avg | min | max
+	Series      []HistorySeries `json:"series"`
+}
+
+// HistorySeries is one field's downsampled values, oldest first.
+type HistorySeries struct {
+	Field  string         `json:"field"` // e.g. "drop_pct", "logs.received_pm"
+	Points []HistoryPoint `json:"points"`
+}
+
+// HistoryPoint is the aggregated value of one step; T is the step's start.
+type HistoryPoint struct {
+	T string  `json:"t"` // RFC3339
+	V float64 `json:"v"`
+}
+
 // errorResponse is a generic JSON error body.
 type errorResponse struct {
 	Error string `json:"error"`
 }
+
+// AlertHistoryResponse is the payload for GET /api/v1/alerts/history.
+type AlertHistoryResponse struct {
+	Alerts []*alerts.Alert `json:"alerts"` // most recently resolved first
+	Total  int             `json:"total"`  // matching alerts, ignoring paging
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+// AckRequest is the optional body of POST /api/v1/alerts/{id}/ack.
+type AckRequest struct {
+	AcknowledgedBy string `json:"acknowledged_by"` // defaults to the caller's credential name
+	Note           string `json:"note"`
+}
+
+// SilenceRequest is the body of POST /api/v1/silences. Times are RFC3339 or
+// unix seconds; starts_at defaults to now, and either ends_at or duration (a
+// Go duration such as "2h") is required.
+type SilenceRequest struct {
+	Rule      string            `json:"rule"`  // rule-name glob; empty matches every rule
+	Match     map[string]string `json:"match"` // pipeline label globs, "!" negates
+	StartsAt  string            `json:"starts_at"`
+	EndsAt    string            `json:"ends_at"`
+	Duration  string            `json:"duration"`
+	Comment   string            `json:"comment"`
+	CreatedBy string            `json:"created_by"` // defaults to the caller's credential name
+}
@@ -7,5 +7,19 @@
 // development with auth disabled). When the key is incorrect or absent,
 // the interceptor returns codes.Unauthenticated immediately.
 //
-// HTTP middleware for the REST API will be added in T012 (Phase 3).
+// MTLSInterceptor(identities) authorises agents by their verified client
+// certificate in mtls mode: the certificate's CN or a DNS/URI SAN must match
+// a configured identity, whose source ID patterns SourceAllowed then checks
+// for every snapshot the agent sends.
+//
+// ServerTLS(cfg, clientAuth) builds the tls.Config shared by the gRPC and
+// HTTP listeners; GRPCClientAuth and HTTPClientAuth pick the client
+// certificate policy for each.
+//
+// HTTPMiddleware(cfg, header) guards the REST API and WebSocket stream with
+// API keys and bearer tokens. Each credential carries a role: read may only
+// make GET/HEAD/OPTIONS requests, admin may make any. Failures return 401 or
+// 403 with the API's {"error": "..."} body. With no credentials configured
+// all requests pass through as admin. RoleFrom and CredentialFrom return the
+// caller's role and credential name from the request context.
 package auth
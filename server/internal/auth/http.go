@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+// roleKey is the context key for the role of an authenticated HTTP caller.
+type roleKey struct{}
+
+// credentialKey is the context key for the credential name of an
+// authenticated HTTP caller.
+type credentialKey struct{}
+
+// httpCredential is a configured credential with its secret resolved.
+type httpCredential struct {
+	name   string
+	bearer bool
+	secret []byte
+	role   string
+}
+
+// HTTPMiddleware returns middleware that authenticates REST API and
+// WebSocket requests against cfg.Credentials.
+//
+// Behaviour:
+//   - If no credentials are configured, all requests pass through with
+//     RoleAdmin (useful for local development with auth disabled). A
+//     configured credential whose secret is unset is logged and never
+//     matches; it does not disable auth. (config.Load already rejects it.)
+//   - apikey credentials are read from header (default "x-api-key"); bearer
+//     credentials from "Authorization: Bearer <token>". WebSocket upgrades,
+//     which browsers cannot send headers with, may pass either as the
+//     access_token query parameter.
+//   - A missing or unknown credential returns 401; a read credential on a
+//     request other than GET/HEAD/OPTIONS returns 403. Both use the API's
+//     {"error": "..."} body.
+func HTTPMiddleware(cfg config.HTTPAuthConfig, header string) func(http.Handler) http.Handler {
+	var creds []httpCredential
+	for _, c := range cfg.Credentials {
+		secret := c.Secret()
+		if secret == "" {
+			slog.Error("http credential has no secret set; it will not authenticate anyone",
+				"name", c.Name, "secret_env", c.SecretEnv)
+			continue
+		}
+		creds = append(creds, httpCredential{
+			name:   c.Name,
+			bearer: c.Type == "bearer",
+			secret: []byte(secret),
+			role:   c.Role,
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(cfg.Credentials) == 0 {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), roleKey{}, config.RoleAdmin)))
+			})
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, ok := matchCredential(r, header, creds)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="obsidianstack"`)
+				httpErr(w, http.StatusUnauthorized, "missing or invalid credentials")
+				return
+			}
+			if c.role != config.RoleAdmin && !safeMethod(r.Method) {
+				httpErr(w, http.StatusForbidden, "credential "+c.name+" is read-only")
+				return
+			}
+			ctx := context.WithValue(r.Context(), roleKey{}, c.role)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, credentialKey{}, c.name)))
+		})
+	}
+}
+
+// RoleFrom returns the role of the authenticated HTTP caller, or "" when the
+// request did not pass through HTTPMiddleware.
+func RoleFrom(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey{}).(string)
+	return role
+}
+
+// CredentialFrom returns the name of the credential the HTTP caller
+// authenticated with, or "" when auth is disabled or the request did not pass
+// through HTTPMiddleware.
+func CredentialFrom(ctx context.Context) string {
+	name, _ := ctx.Value(credentialKey{}).(string)
+	return name
+}
+
+// matchCredential returns the credential presented by r, if any.
+func matchCredential(r *http.Request, header string, creds []httpCredential) (httpCredential, bool) {
+	key := r.Header.Get(header)
+	var token string
+	if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		token = h[7:]
+	}
+	if key == "" && token == "" && isWebSocketUpgrade(r) {
+		key = r.URL.Query().Get("access_token")
+		token = key
+	}
+
+	for _, c := range creds {
+		presented := key
+		if c.bearer {
+			presented = token
+		}
+		if presented != "" && subtle.ConstantTimeCompare([]byte(presented), c.secret) == 1 {
+			return c, true
+		}
+	}
+	return httpCredential{}, false
+}
+
+// safeMethod reports whether method is allowed for the read role.
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// httpErr writes a JSON error body in the same shape as the REST API's.
+func httpErr(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct { //nolint:errcheck
+		Error string `json:"error"`
+	}{msg})
+}
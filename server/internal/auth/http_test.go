@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+// roleHandler writes the caller's role as the response body.
+var roleHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(RoleFrom(r.Context()))) //nolint:errcheck
+})
+
+// testHTTPAuth returns an HTTPAuthConfig with a read bearer token ("rtok")
+// and an admin API key ("akey").
+func testHTTPAuth(t *testing.T) config.HTTPAuthConfig {
+	t.Helper()
+	t.Setenv("TEST_READ_TOKEN", "rtok")
+	t.Setenv("TEST_ADMIN_KEY", "akey")
+	return config.HTTPAuthConfig{Credentials: []config.HTTPCredential{
+		{Name: "ui", Type: "bearer", SecretEnv: "TEST_READ_TOKEN", Role: config.RoleRead},
+		{Name: "ops", Type: "apikey", SecretEnv: "TEST_ADMIN_KEY", Role: config.RoleAdmin},
+	}}
+}
+
+func serveAuth(cfg config.HTTPAuthConfig, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	HTTPMiddleware(cfg, "x-api-key")(roleHandler).ServeHTTP(rec, r)
+	return rec
+}
+
+func TestHTTPMiddleware_NoCredentials_PassesAsAdmin(t *testing.T) {
+	rec := serveAuth(config.HTTPAuthConfig{}, httptest.NewRequest(http.MethodPost, "/api/v1/x", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", rec.Code)
+	}
+	if rec.Body.String() != config.RoleAdmin {
+		t.Errorf("role: got %q, want admin", rec.Body.String())
+	}
+}
+
+// TestHTTPMiddleware_UnsetSecret_FailsClosed checks that credentials whose
+// secrets are all unset do not turn auth off.
+func TestHTTPMiddleware_UnsetSecret_FailsClosed(t *testing.T) {
+	cfg := config.HTTPAuthConfig{Credentials: []config.HTTPCredential{
+		{Name: "ui", Type: "bearer", SecretEnv: "TEST_UNSET_TOKEN", Role: config.RoleAdmin},
+	}}
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/x", nil)
+	r.Header.Set("Authorization", "Bearer ")
+	rec := serveAuth(cfg, r)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status: got %d, want 401", rec.Code)
+	}
+}
+
+func TestHTTPMiddleware_Missing_Unauthorized(t *testing.T) {
+	rec := serveAuth(testHTTPAuth(t), httptest.NewRequest(http.MethodGet, "/api/v1/x", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status: got %d, want 401", rec.Code)
+	}
+	if rec.Header().Get("WWW-Authenticate") == "" {
+		t.Error("WWW-Authenticate header not set")
+	}
+	var body map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body["error"] == "" {
+		t.Errorf("body: got %v, want error field", body)
+	}
+}
+
+func TestHTTPMiddleware_WrongSecret_Unauthorized(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/x", nil)
+	r.Header.Set("Authorization", "Bearer nope")
+	if rec := serveAuth(testHTTPAuth(t), r); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status: got %d, want 401", rec.Code)
+	}
+}
+
+func TestHTTPMiddleware_APIKeyAsBearer_Unauthorized(t *testing.T) {
+	// Each secret is only accepted in the form its type declares.
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/x", nil)
+	r.Header.Set("Authorization", "Bearer akey")
+	if rec := serveAuth(testHTTPAuth(t), r); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status: got %d, want 401", rec.Code)
+	}
+}
+
+func TestHTTPMiddleware_ReadBearer_GetAllowed(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/x", nil)
+	r.Header.Set("Authorization", "Bearer rtok")
+	rec := serveAuth(testHTTPAuth(t), r)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", rec.Code)
+	}
+	if rec.Body.String() != config.RoleRead {
+		t.Errorf("role: got %q, want read", rec.Body.String())
+	}
+}
+
+func TestHTTPMiddleware_ReadBearer_PostForbidden(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/x", nil)
+	r.Header.Set("Authorization", "Bearer rtok")
+	if rec := serveAuth(testHTTPAuth(t), r); rec.Code != http.StatusForbidden {
+		t.Errorf("status: got %d, want 403", rec.Code)
+	}
+}
+
+func TestHTTPMiddleware_AdminKey_PostAllowed(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/x", nil)
+	r.Header.Set("x-api-key", "akey")
+	rec := serveAuth(testHTTPAuth(t), r)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", rec.Code)
+	}
+	if rec.Body.String() != config.RoleAdmin {
+		t.Errorf("role: got %q, want admin", rec.Body.String())
+	}
+}
+
+func TestHTTPMiddleware_CredentialName(t *testing.T) {
+	var got string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = CredentialFrom(r.Context())
+	})
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/x", nil)
+	r.Header.Set("x-api-key", "akey")
+	HTTPMiddleware(testHTTPAuth(t), "x-api-key")(h).ServeHTTP(httptest.NewRecorder(), r)
+	if got != "ops" {
+		t.Errorf("credential: got %q, want ops", got)
+	}
+}
+
+func TestHTTPMiddleware_WebSocketQueryToken(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/ws/stream?access_token=rtok", nil)
+	r.Header.Set("Upgrade", "websocket")
+	if rec := serveAuth(testHTTPAuth(t), r); rec.Code != http.StatusOK {
+		t.Errorf("status: got %d, want 200", rec.Code)
+	}
+}
+
+func TestHTTPMiddleware_QueryTokenIgnoredWithoutUpgrade(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/x?access_token=rtok", nil)
+	if rec := serveAuth(testHTTPAuth(t), r); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status: got %d, want 401", rec.Code)
+	}
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"path"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+// identityKey is the context key for the matched certificate identity.
+type identityKey struct{}
+
+// MTLSInterceptor returns a gRPC UnaryServerInterceptor that authorises
+// agents by their verified client certificate.
+//
+// Behaviour:
+//   - A call without a verified client certificate returns
+//     codes.Unauthenticated. (With mtls the TLS handshake already requires
+//     one; this guards against a misconfigured listener.)
+//   - If identities is empty, every verified agent may report any source.
+//   - Otherwise the certificate's common name and DNS/URI SANs are matched
+//     against the identity names. No match returns codes.PermissionDenied;
+//     a match is attached to the context for SourceAllowed.
+func MTLSInterceptor(identities []config.IdentityConfig) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		cert := peerCert(ctx)
+		if cert == nil {
+			return nil, status.Error(codes.Unauthenticated, "client certificate required")
+		}
+		if len(identities) == 0 {
+			return handler(ctx, req)
+		}
+
+		id, ok := matchIdentity(cert, identities)
+		if !ok {
+			return nil, status.Errorf(codes.PermissionDenied,
+				"certificate %q is not mapped to any sources", cert.Subject.CommonName)
+		}
+		return handler(context.WithValue(ctx, identityKey{}, id), req)
+	}
+}
+
+// SourceAllowed reports whether the caller may report sourceID. Calls
+// without a matched certificate identity — auth modes other than mtls, or
+// mtls without identity mappings — are allowed every source.
+func SourceAllowed(ctx context.Context, sourceID string) bool {
+	id, ok := ctx.Value(identityKey{}).(config.IdentityConfig)
+	if !ok {
+		return true
+	}
+	for _, pattern := range id.Sources {
+		if ok, _ := path.Match(pattern, sourceID); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// peerCert returns the caller's verified leaf certificate, or nil.
+func peerCert(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return tlsInfo.State.VerifiedChains[0][0]
+}
+
+// matchIdentity returns the first identity whose name is one of cert's names.
+func matchIdentity(cert *x509.Certificate, identities []config.IdentityConfig) (config.IdentityConfig, bool) {
+	names := certNames(cert)
+	for _, id := range identities {
+		for _, n := range names {
+			if n == id.Name {
+				return id, true
+			}
+		}
+	}
+	return config.IdentityConfig{}, false
+}
+
+// certNames returns the names a certificate identity can be matched by: the
+// subject CN followed by the DNS and URI SANs.
+func certNames(cert *x509.Certificate) []string {
+	var names []string
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	names = append(names, cert.DNSNames...)
+	for _, u := range cert.URIs {
+		names = append(names, u.String())
+	}
+	return names
+}
@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+// withPeerCert returns a context whose gRPC peer presented cert as its
+// verified client certificate.
+func withPeerCert(cert *x509.Certificate) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{cert}},
+		}},
+	})
+}
+
+// sourceHandler is a grpc.UnaryHandler that reports whether source "otel-prod"
+// is allowed for the caller.
+func sourceHandler(ctx context.Context, _ interface{}) (interface{}, error) {
+	return SourceAllowed(ctx, "otel-prod"), nil
+}
+
+var identities = []config.IdentityConfig{
+	{Name: "agent-a", Sources: []string{"otel-*"}},
+	{Name: "spiffe://obsidian/agent-b", Sources: []string{"loki"}},
+}
+
+func TestMTLSInterceptor_NoCertificate_Unauthenticated(t *testing.T) {
+	i := MTLSInterceptor(identities)
+	_, err := i(context.Background(), nil, &grpc.UnaryServerInfo{}, sourceHandler)
+	if code := status.Code(err); code != codes.Unauthenticated {
+		t.Errorf("code: got %v, want Unauthenticated", code)
+	}
+}
+
+func TestMTLSInterceptor_NoIdentities_AllowsAllSources(t *testing.T) {
+	i := MTLSInterceptor(nil)
+	ctx := withPeerCert(&x509.Certificate{Subject: pkix.Name{CommonName: "anyone"}})
+	res, err := i(ctx, nil, &grpc.UnaryServerInfo{}, sourceHandler)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res != true {
+		t.Error("SourceAllowed: got false, want true without identity mappings")
+	}
+}
+
+func TestMTLSInterceptor_CommonNameMapsSources(t *testing.T) {
+	i := MTLSInterceptor(identities)
+	ctx := withPeerCert(&x509.Certificate{Subject: pkix.Name{CommonName: "agent-a"}})
+	res, err := i(ctx, nil, &grpc.UnaryServerInfo{}, sourceHandler)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res != true {
+		t.Error("SourceAllowed(otel-prod): got false, want true for pattern otel-*")
+	}
+}
+
+func TestMTLSInterceptor_URISANMapsSources(t *testing.T) {
+	i := MTLSInterceptor(identities)
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "ignored"}}
+	cert.URIs = append(cert.URIs, mustParseURL(t, "spiffe://obsidian/agent-b"))
+	res, err := i(withPeerCert(cert), nil, &grpc.UnaryServerInfo{}, sourceHandler)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res != false {
+		t.Error("SourceAllowed(otel-prod): got true, want false for identity limited to loki")
+	}
+}
+
+func TestMTLSInterceptor_UnknownIdentity_PermissionDenied(t *testing.T) {
+	i := MTLSInterceptor(identities)
+	ctx := withPeerCert(&x509.Certificate{Subject: pkix.Name{CommonName: "stranger"}})
+	_, err := i(ctx, nil, &grpc.UnaryServerInfo{}, sourceHandler)
+	if code := status.Code(err); code != codes.PermissionDenied {
+		t.Errorf("code: got %v, want PermissionDenied", code)
+	}
+}
+
+func TestSourceAllowed_WithoutIdentity(t *testing.T) {
+	if !SourceAllowed(context.Background(), "anything") {
+		t.Error("SourceAllowed without identity: got false, want true")
+	}
+}
+
+// --- end-to-end over a real TLS listener ---
+
+// testPKI holds the PEM file paths of a throwaway CA and the certificates
+// it issued.
+type testPKI struct {
+	caFile, serverCert, serverKey, clientCert, clientKey string
+}
+
+// newTestPKI writes a CA, a server certificate for 127.0.0.1 and a client
+// certificate with CN clientCN into a temp dir.
+func newTestPKI(t *testing.T, clientCN string) testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey := newKey(t)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "obsidian-test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	p := testPKI{caFile: filepath.Join(dir, "ca.crt")}
+	writePEM(t, p.caFile, "CERTIFICATE", caDER)
+
+	issue := func(name string, serial int64, usage x509.ExtKeyUsage, tmpl *x509.Certificate) (string, string) {
+		key := newKey(t)
+		tmpl.SerialNumber = big.NewInt(serial)
+		tmpl.NotBefore = time.Now().Add(-time.Hour)
+		tmpl.NotAfter = time.Now().Add(time.Hour)
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+		writePEM(t, certFile, "CERTIFICATE", der)
+		writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+		return certFile, keyFile
+	}
+	p.serverCert, p.serverKey = issue("server", 2, x509.ExtKeyUsageServerAuth, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "obsidianstack-server"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+	})
+	p.clientCert, p.clientKey = issue("client", 3, x509.ExtKeyUsageClientAuth, &x509.Certificate{
+		Subject: pkix.Name{CommonName: clientCN},
+	})
+	return p
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// allowedServer answers SendSnapshot with whether the caller may report the
+// snapshot's source.
+type allowedServer struct {
+	pb.UnimplementedSnapshotServiceServer
+}
+
+func (allowedServer) SendSnapshot(ctx context.Context, snap *pb.PipelineSnapshot) (*pb.SendResponse, error) {
+	return &pb.SendResponse{Ok: SourceAllowed(ctx, snap.SourceId)}, nil
+}
+
+// startMTLSServer serves allowedServer over mTLS and returns its address.
+func startMTLSServer(t *testing.T, p testPKI, ids []config.IdentityConfig) string {
+	t.Helper()
+	tlsCfg, err := ServerTLS(config.TLSConfig{
+		CertFile: p.serverCert, KeyFile: p.serverKey, ClientCAFile: p.caFile,
+	}, GRPCClientAuth("mtls"))
+	if err != nil {
+		t.Fatalf("ServerTLS: %v", err)
+	}
+	srv := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsCfg)),
+		grpc.ChainUnaryInterceptor(MTLSInterceptor(ids)),
+	)
+	pb.RegisterSnapshotServiceServer(srv, allowedServer{})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.Serve(lis) //nolint:errcheck
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func dialTLS(t *testing.T, addr string, p testPKI, withClientCert bool) pb.SnapshotServiceClient {
+	t.Helper()
+	caPEM, err := os.ReadFile(p.caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caPEM)
+	tlsCfg := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	if withClientCert {
+		cert, err := tls.LoadX509KeyPair(p.clientCert, p.clientKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg)))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewSnapshotServiceClient(conn)
+}
+
+func TestMTLS_EndToEnd(t *testing.T) {
+	p := newTestPKI(t, "agent-a")
+	addr := startMTLSServer(t, p, identities)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := dialTLS(t, addr, p, true)
+	resp, err := client.SendSnapshot(ctx, &pb.PipelineSnapshot{SourceId: "otel-prod"})
+	if err != nil {
+		t.Fatalf("SendSnapshot with client cert: %v", err)
+	}
+	if !resp.Ok {
+		t.Error("otel-prod: not allowed for agent-a, want allowed")
+	}
+	resp, err = client.SendSnapshot(ctx, &pb.PipelineSnapshot{SourceId: "loki"})
+	if err != nil {
+		t.Fatalf("SendSnapshot: %v", err)
+	}
+	if resp.Ok {
+		t.Error("loki: allowed for agent-a, want not allowed")
+	}
+
+	if _, err := dialTLS(t, addr, p, false).SendSnapshot(ctx, &pb.PipelineSnapshot{SourceId: "otel-prod"}); err == nil {
+		t.Error("SendSnapshot without client cert: got nil error, want handshake failure")
+	}
+}
+
+func TestServerTLS_BadClientCA(t *testing.T) {
+	p := newTestPKI(t, "agent-a")
+	_, err := ServerTLS(config.TLSConfig{
+		CertFile: p.serverCert, KeyFile: p.serverKey, ClientCAFile: p.serverKey,
+	}, GRPCClientAuth("mtls"))
+	if err == nil {
+		t.Fatal("expected error for client CA file without certificates, got nil")
+	}
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+// ServerTLS builds the tls.Config for a listener from cfg. clientAuth sets
+// how client certificates are treated; when it asks for verification, they
+// are verified against cfg.ClientCAFile.
+func ServerTLS(cfg config.TLSConfig, clientAuth tls.ClientAuthType) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load server cert: %w", err)
+	}
+	tlsCfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		ClientAuth:   clientAuth,
+	}
+
+	if clientAuth >= tls.VerifyClientCertIfGiven {
+		caPEM, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certs in client ca file %q", cfg.ClientCAFile)
+		}
+		tlsCfg.ClientCAs = pool
+	}
+	return tlsCfg, nil
+}
+
+// GRPCClientAuth returns the client certificate policy for the gRPC
+// listener: required and verified in mtls mode, otherwise not requested.
+func GRPCClientAuth(mode string) tls.ClientAuthType {
+	if mode == "mtls" {
+		return tls.RequireAndVerifyClientCert
+	}
+	return tls.NoClientCert
+}
+
+// HTTPClientAuth returns the client certificate policy for the HTTP
+// listener: required if cfg says so, verified-if-given when a client CA is
+// configured, otherwise not requested.
+func HTTPClientAuth(cfg config.TLSConfig) tls.ClientAuthType {
+	switch {
+	case cfg.RequireHTTPClientCert:
+		return tls.RequireAndVerifyClientCert
+	case cfg.ClientCAFile != "":
+		return tls.VerifyClientCertIfGiven
+	}
+	return tls.NoClientCert
+}
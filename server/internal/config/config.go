@@ -2,16 +2,78 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
 	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/alerts/expr"
+	"github.com/obsidianstack/obsidianstack/server/internal/alerts/payload"
 )
 
-// AlertsConfig holds alerting rules and webhook delivery targets.
+// AlertsConfig holds alerting rules, notification receivers and the route
+// tree that picks them, and recurring maintenance windows.
 type AlertsConfig struct {
-	Rules    []AlertRule     `yaml:"rules"`
+	Rules []AlertRule `yaml:"rules"`
+
+	// DashboardURL is the base URL of the dashboard, used for links in
+	// notifications. Optional.
+	DashboardURL string `yaml:"dashboard_url"`
+
+	// Webhooks are the targets of the implicit receiver named "default".
+	// With no Route, every alert goes to it.
 	Webhooks []WebhookConfig `yaml:"webhooks"`
+
+	// Receivers are named groups of webhook targets.
+	Receivers []Receiver `yaml:"receivers"`
+
+	// Route is the root of the routing tree; its receiver is the default for
+	// alerts no child route matches.
+	Route *Route `yaml:"route"`
+
+	Maintenance []MaintenanceWindow `yaml:"maintenance"`
+}
+
+// DefaultReceiver names the receiver formed by AlertsConfig.Webhooks.
+const DefaultReceiver = "default"
+
+// Receiver is a named set of webhook targets that alerts are routed to.
+type Receiver struct {
+	Name     string          `yaml:"name"`
+	Webhooks []WebhookConfig `yaml:"webhooks"`
+}
+
+// Route is a node of the alert routing tree. An alert that matches a route
+// is passed down to the first of its child Routes that matches it, and on to
+// the next matching ones while the matching child has Continue set. It goes
+// to the route's own receiver only if no child matched.
+type Route struct {
+	// Receiver names the receiver for alerts this route handles; empty
+	// inherits the parent's.
+	Receiver string `yaml:"receiver"`
+
+	// Severity lists the severities the route matches; empty matches any.
+	Severity []string `yaml:"severity"`
+
+	// Rule is a path.Match pattern on the rule name; empty matches any.
+	Rule string `yaml:"rule"`
+
+	// Match selects the pipelines the route matches; empty selects all.
+	Match Selector `yaml:"match"`
+
+	// Continue keeps trying the following sibling routes after this one
+	// matched.
+	Continue bool `yaml:"continue"`
+
+	Routes []Route `yaml:"routes"`
 }
 
 // AlertRule defines one threshold-based alert condition.
@@ -19,8 +81,10 @@ type AlertRule struct {
 	// Name is the human-readable alert identifier, used as the deduplication key.
 	Name string `yaml:"name"`
 
-	// Condition is a simple expression: "drop_pct > 10", "strength_score < 60",
-	// "cert_days_left < 14", "state == critical".
+	// Condition is a boolean expression over snapshot fields, e.g.
+	// "drop_pct > 10", "state == critical || strength_score < 60" or
+	// `source_type == otelcol && extra.queue_size > 5000`. See package
+	// alerts/expr for the syntax; Load rejects conditions that do not parse.
 	Condition string `yaml:"condition"`
 
 	// Severity is one of: critical | warning | info.
@@ -29,6 +93,104 @@ type AlertRule struct {
 	// Cooldown suppresses re-fires for this duration after an alert fires.
 	// Defaults to 15 minutes if zero.
 	Cooldown time.Duration `yaml:"cooldown"`
+
+	// For is how long the condition must keep holding before the alert fires.
+	// Until then the alert is pending and no notification is sent. Zero fires
+	// on the first matching snapshot.
+	For time.Duration `yaml:"for"`
+
+	// KeepFiringFor keeps a firing alert firing for this long after its
+	// condition last held, so a briefly clearing condition does not resolve
+	// and re-fire it. Zero resolves on the first non-matching snapshot.
+	KeepFiringFor time.Duration `yaml:"keep_firing_for"`
+
+	// Match scopes the rule to the pipelines it selects; empty evaluates the
+	// rule against every pipeline.
+	Match Selector `yaml:"match"`
+
+	// Overrides change the severity or cooldown for the pipelines they
+	// select. The first matching entry wins; unset fields keep the rule's.
+	Overrides []RuleOverride `yaml:"overrides"`
+
+	// Receiver sends the rule's alerts to this receiver instead of routing
+	// them through the route tree.
+	Receiver string `yaml:"receiver"`
+}
+
+// RuleOverride adjusts an alert rule for a subset of pipelines.
+type RuleOverride struct {
+	Match    Selector      `yaml:"match"`
+	Severity string        `yaml:"severity"`
+	Cooldown time.Duration `yaml:"cooldown"`
+}
+
+// Selector picks pipelines by label. Keys are one of SelectorLabels; values
+// are path.Match patterns such as "prod-*", negated by a leading "!"
+// ("!staging-*"). A pipeline is selected when every entry matches, so an
+// empty Selector selects everything.
+type Selector map[string]string
+
+// SelectorLabels are the pipeline labels a Selector can match on.
+var SelectorLabels = []string{"source_id", "source_type", "cluster", "namespace", "node_type"}
+
+// Matches reports whether a pipeline with the given labels is selected.
+// Labels absent from the map match as the empty string.
+func (s Selector) Matches(labels map[string]string) bool {
+	for key, pattern := range s {
+		negate := strings.HasPrefix(pattern, "!")
+		ok, _ := path.Match(strings.TrimPrefix(pattern, "!"), labels[key])
+		if ok == negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks that every key is one of SelectorLabels and every pattern
+// is well-formed.
+func (s Selector) Validate() error {
+	for key, pattern := range s {
+		if !slices.Contains(SelectorLabels, key) {
+			return fmt.Errorf("unknown label %q: want one of %s", key, strings.Join(SelectorLabels, ", "))
+		}
+		if _, err := path.Match(strings.TrimPrefix(pattern, "!"), ""); err != nil {
+			return fmt.Errorf("%s: bad pattern %q: %w", key, pattern, err)
+		}
+	}
+	return nil
+}
+
+// MaintenanceWindow is a recurring period during which matching alerts are
+// silenced: they are still evaluated and listed, but not delivered.
+type MaintenanceWindow struct {
+	// Name identifies the window in logs and in an alert's silenced_by list.
+	Name string `yaml:"name"`
+
+	// Rule is a path.Match pattern on the rule name; empty matches every rule.
+	Rule string `yaml:"rule"`
+
+	// Match selects the pipelines the window applies to; empty selects all.
+	Match Selector `yaml:"match"`
+
+	// Days are the weekdays the window starts on ("mon" … "sun"); empty
+	// means every day.
+	Days []string `yaml:"days"`
+
+	// Start is the local start time as "HH:MM" in Timezone.
+	Start string `yaml:"start"`
+
+	// Duration is how long the window lasts; it may run past midnight but not
+	// beyond a week.
+	Duration time.Duration `yaml:"duration"`
+
+	// Timezone is an IANA zone name such as "Europe/Berlin". Defaults to UTC.
+	Timezone string `yaml:"timezone"`
+}
+
+// Weekdays maps the day names accepted in MaintenanceWindow.Days.
+var Weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
 }
 
 // WebhookConfig defines one webhook delivery target.
@@ -37,22 +199,171 @@ type WebhookConfig struct {
 	Type string `yaml:"type"`
 
 	// URLEnv is the name of the environment variable that holds the webhook URL.
+	// Optional for pagerduty, which defaults to PagerDutyEventsURL.
 	URLEnv string `yaml:"url_env"`
+
+	// RoutingKeyEnv is the name of the environment variable that holds the
+	// PagerDuty integration (routing) key. Required for pagerduty.
+	RoutingKeyEnv string `yaml:"routing_key_env"`
+
+	// SMTP configures the mail server and addresses. Required for smtp.
+	SMTP *SMTPConfig `yaml:"smtp"`
+
+	// Template is a text/template rendering the JSON request body of a
+	// slack, teams or http webhook, replacing the built-in one. See package
+	// alerts/payload for what it can use.
+	Template string `yaml:"template"`
+
+	// Headers are added to every request. HeadersEnv adds headers whose
+	// values are read from the named environment variables, for tokens.
+	Headers    map[string]string `yaml:"headers"`
+	HeadersEnv map[string]string `yaml:"headers_env"`
+
+	// SigningSecretEnv names the environment variable holding the secret an
+	// http webhook signs its requests with (HMAC-SHA256). Optional.
+	SigningSecretEnv string `yaml:"signing_secret_env"`
+
+	// Retry controls redelivery of failed requests.
+	Retry RetryConfig `yaml:"retry"`
+}
+
+// RetryConfig controls how a failed webhook request is retried: after a
+// network error, HTTP 429 or a 5xx response.
+type RetryConfig struct {
+	// MaxAttempts is the number of tries including the first. Default 4;
+	// 1 disables retries.
+	MaxAttempts int `yaml:"max_attempts"`
+
+	// Backoff is the wait before the first retry, doubled for each next one
+	// up to MaxBackoff. Defaults 1s and 30s.
+	Backoff    time.Duration `yaml:"backoff"`
+	MaxBackoff time.Duration `yaml:"max_backoff"`
 }
 
+// Defaults for RetryConfig.
+const (
+	DefaultRetryAttempts   = 4
+	DefaultRetryBackoff    = time.Second
+	DefaultRetryMaxBackoff = 30 * time.Second
+)
+
+// WithDefaults returns r with its unset fields set to the defaults.
+func (r RetryConfig) WithDefaults() RetryConfig {
+	if r.MaxAttempts == 0 {
+		r.MaxAttempts = DefaultRetryAttempts
+	}
+	if r.Backoff == 0 {
+		r.Backoff = DefaultRetryBackoff
+	}
+	if r.MaxBackoff == 0 {
+		r.MaxBackoff = max(DefaultRetryMaxBackoff, r.Backoff)
+	}
+	return r
+}
+
+// SMTPConfig configures alert email for an smtp webhook.
+type SMTPConfig struct {
+	Host string `yaml:"host"`
+
+	// Port defaults to 587, or 465 with TLS "tls".
+	Port int `yaml:"port"`
+
+	// TLS is "starttls" (default; the server must offer it), "tls" for
+	// implicit TLS, or "none".
+	TLS string `yaml:"tls"`
+
+	// UsernameEnv and PasswordEnv name the environment variables holding
+	// the SMTP AUTH PLAIN credentials. Without them no AUTH is attempted.
+	UsernameEnv string `yaml:"username_env"`
+	PasswordEnv string `yaml:"password_env"`
+
+	From string   `yaml:"from"`
+	To   []string `yaml:"to"`
+
+	// GroupWait is how long notifications for a recipient are collected
+	// into one email, starting at the first. Default 30s.
+	GroupWait time.Duration `yaml:"group_wait"`
+}
+
+// DefaultSMTPGroupWait is the default SMTPConfig.GroupWait.
+const DefaultSMTPGroupWait = 30 * time.Second
+
+// Addr returns host:port, with the default port for the TLS mode when Port
+// is unset.
+func (s SMTPConfig) Addr() string {
+	port := s.Port
+	if port == 0 {
+		port = 587
+		if s.TLS == "tls" {
+			port = 465
+		}
+	}
+	return net.JoinHostPort(s.Host, strconv.Itoa(port))
+}
+
+// Credentials returns the SMTP username and password resolved from the
+// environment.
+func (s SMTPConfig) Credentials() (username, password string) {
+	if s.UsernameEnv != "" {
+		username = os.Getenv(s.UsernameEnv)
+	}
+	if s.PasswordEnv != "" {
+		password = os.Getenv(s.PasswordEnv)
+	}
+	return username, password
+}
+
+// PagerDutyEventsURL is the PagerDuty Events API v2 endpoint.
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
 // URL returns the webhook URL resolved from the environment.
 func (w WebhookConfig) URL() string {
 	if w.URLEnv == "" {
+		if w.Type == "pagerduty" {
+			return PagerDutyEventsURL
+		}
 		return ""
 	}
 	return os.Getenv(w.URLEnv)
 }
 
+// Header returns the custom request headers, with the HeadersEnv values
+// resolved from the environment.
+func (w WebhookConfig) Header() http.Header {
+	h := make(http.Header, len(w.Headers)+len(w.HeadersEnv))
+	for k, v := range w.Headers {
+		h.Set(k, v)
+	}
+	for k, env := range w.HeadersEnv {
+		h.Set(k, os.Getenv(env))
+	}
+	return h
+}
+
+// SigningSecret returns the request signing secret resolved from the
+// environment, or "" when requests are not signed.
+func (w WebhookConfig) SigningSecret() string {
+	if w.SigningSecretEnv == "" {
+		return ""
+	}
+	return os.Getenv(w.SigningSecretEnv)
+}
+
+// RoutingKey returns the PagerDuty routing key resolved from the environment.
+func (w WebhookConfig) RoutingKey() string {
+	if w.RoutingKeyEnv == "" {
+		return ""
+	}
+	return os.Getenv(w.RoutingKeyEnv)
+}
+
 // Default values for the server configuration.
 const (
 	DefaultGRPCPort    = 50051
 	DefaultHTTPPort    = 8080
+	DefaultAdminPort   = 8081
 	DefaultSnapshotTTL = 5 * time.Minute
+	DefaultRetention   = 7 * 24 * time.Hour
 )
 
 // Config holds the server-side configuration parsed from the `server:` section
@@ -69,6 +380,14 @@ type ServerConfig struct {
 	// HTTPPort is the port the REST API and WebSocket hub listen on (default 8080).
 	HTTPPort int `yaml:"http_port"`
 
+	// AdminPort serves the server's own /metrics, /healthz and /readyz on a
+	// separate plain-HTTP listener without authentication (default 8081).
+	// 0 disables it.
+	AdminPort int `yaml:"admin_port"`
+
+	// TLS enables TLS on the gRPC and HTTP listeners. Required for mTLS auth.
+	TLS TLSConfig `yaml:"tls"`
+
 	// Auth configures how the server authenticates incoming gRPC and REST clients.
 	Auth AuthConfig `yaml:"auth"`
 
@@ -77,12 +396,38 @@ type ServerConfig struct {
 
 	// Alerts holds rule definitions and webhook delivery targets.
 	Alerts AlertsConfig `yaml:"alerts"`
+
+	// Storage configures the optional historical snapshot store.
+	Storage StorageConfig `yaml:"storage"`
 }
 
+// TLSConfig holds the server certificate and the CA used to verify client
+// certificates. Both listeners serve the same certificate.
+type TLSConfig struct {
+	// CertFile and KeyFile are the PEM server certificate and private key.
+	// Setting them enables TLS; leaving both empty serves plaintext.
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+
+	// ClientCAFile is the PEM bundle of CAs that issue agent (and, optionally,
+	// HTTP client) certificates. Required when auth.mode is mtls.
+	ClientCAFile string `yaml:"client_ca_file"`
+
+	// RequireHTTPClientCert makes the HTTP listener reject clients without a
+	// certificate signed by ClientCAFile. When false, a client certificate
+	// is verified if presented but not required, so browsers can still
+	// reach the UI and API.
+	RequireHTTPClientCert bool `yaml:"require_http_client_cert"`
+}
+
+// Enabled reports whether the listeners serve TLS.
+func (t TLSConfig) Enabled() bool { return t.CertFile != "" }
+
 // AuthConfig controls client authentication on the server side.
 type AuthConfig struct {
-	// Mode is one of: apikey | none.
-	// "mtls" is supported for future use but requires TLS listener setup.
+	// Mode is one of: apikey | mtls | none.
+	// "mtls" requires tls with a client_ca_file: agents must present a
+	// certificate signed by it.
 	Mode string `yaml:"mode"`
 
 	// KeyEnv is the name of the environment variable that holds the expected API key.
@@ -92,6 +437,71 @@ type AuthConfig struct {
 	// Header is the gRPC metadata key (and HTTP header name) to read the key from.
 	// Defaults to "x-api-key" if empty.
 	Header string `yaml:"header"`
+
+	// Identities maps agent certificate identities to the source IDs they may
+	// report. Used when Mode == "mtls"; when empty, any verified agent may
+	// report any source.
+	Identities []IdentityConfig `yaml:"identities"`
+
+	// HTTP configures authentication of the REST API and WebSocket stream,
+	// independently of Mode, which applies to agents on gRPC.
+	HTTP HTTPAuthConfig `yaml:"http"`
+}
+
+// HTTP roles. RoleRead may only make safe (GET/HEAD) requests; RoleAdmin may
+// make any request.
+const (
+	RoleRead  = "read"
+	RoleAdmin = "admin"
+)
+
+// HTTPAuthConfig controls access to /api/ and /ws/stream.
+type HTTPAuthConfig struct {
+	// Credentials lists the accepted API keys and bearer tokens. When empty
+	// the REST API and WebSocket stream are open to everyone. Every listed
+	// credential's secret must be set: config.Load fails otherwise.
+	Credentials []HTTPCredential `yaml:"credentials"`
+
+	// AllowedOrigins lists the Origin values (scheme://host[:port]) accepted
+	// on WebSocket upgrades, in addition to same-origin requests. "*" accepts
+	// any origin.
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+// HTTPCredential is one API key or bearer token and the role it grants.
+type HTTPCredential struct {
+	// Name identifies the credential in logs.
+	Name string `yaml:"name"`
+
+	// Type is apikey (sent in the auth header, default "x-api-key") or
+	// bearer (sent as "Authorization: Bearer <token>").
+	Type string `yaml:"type"`
+
+	// SecretEnv is the name of the environment variable holding the key or
+	// token.
+	SecretEnv string `yaml:"secret_env"`
+
+	// Role is read or admin.
+	Role string `yaml:"role"`
+}
+
+// Secret returns the credential's key or token resolved from the environment.
+func (c HTTPCredential) Secret() string {
+	if c.SecretEnv == "" {
+		return ""
+	}
+	return os.Getenv(c.SecretEnv)
+}
+
+// IdentityConfig grants one agent certificate identity a set of source IDs.
+type IdentityConfig struct {
+	// Name is matched against the certificate's subject common name and its
+	// DNS and URI subject alternative names.
+	Name string `yaml:"name"`
+
+	// Sources lists the source IDs this identity may report. Entries are
+	// path.Match patterns, e.g. "otel-*"; "*" allows every source.
+	Sources []string `yaml:"sources"`
 }
 
 // Key returns the expected API key resolved from the environment.
@@ -118,6 +528,28 @@ type SnapshotConfig struct {
 	TTL time.Duration `yaml:"ttl"`
 }
 
+// StorageConfig configures historical snapshot persistence. The latest
+// snapshot per source is always served from memory; Storage only adds history.
+type StorageConfig struct {
+	// Backend selects the storage implementation: sqlite, or none (default)
+	// to keep no history.
+	Backend string `yaml:"backend"`
+
+	// Path is the filesystem path for the SQLite database file.
+	Path string `yaml:"path"`
+
+	// Retention is how long historical snapshots are kept before deletion.
+	// Default: 168h (7 days).
+	Retention time.Duration `yaml:"retention"`
+
+	// AlertRetention is how long resolved alerts and ended silences are kept
+	// — the alert audit trail. Default: 0, kept forever.
+	AlertRetention time.Duration `yaml:"alert_retention"`
+}
+
+// Enabled reports whether a history backend is configured.
+func (s StorageConfig) Enabled() bool { return s.Backend != "" && s.Backend != "none" }
+
 // Load reads and parses the config file at path, returning the server configuration.
 // Missing fields are filled with sensible defaults before validation.
 func Load(path string) (*Config, error) {
@@ -142,11 +574,15 @@ func Load(path string) (*Config, error) {
 func defaults() *Config {
 	return &Config{
 		Server: ServerConfig{
-			GRPCPort: DefaultGRPCPort,
-			HTTPPort: DefaultHTTPPort,
+			GRPCPort:  DefaultGRPCPort,
+			HTTPPort:  DefaultHTTPPort,
+			AdminPort: DefaultAdminPort,
 			Snapshot: SnapshotConfig{
 				TTL: DefaultSnapshotTTL,
 			},
+			Storage: StorageConfig{
+				Retention: DefaultRetention,
+			},
 		},
 	}
 }
@@ -159,13 +595,375 @@ func validate(cfg *Config) error {
 	if cfg.Server.HTTPPort <= 0 || cfg.Server.HTTPPort > 65535 {
 		return fmt.Errorf("server.http_port %d is out of range [1, 65535]", cfg.Server.HTTPPort)
 	}
+	if cfg.Server.AdminPort < 0 || cfg.Server.AdminPort > 65535 {
+		return fmt.Errorf("server.admin_port %d is out of range [0, 65535]", cfg.Server.AdminPort)
+	}
 	switch cfg.Server.Auth.Mode {
 	case "apikey", "mtls", "none", "":
 	default:
 		return fmt.Errorf("server.auth.mode %q unknown: want apikey|mtls|none", cfg.Server.Auth.Mode)
 	}
+	if err := validateTLS(cfg.Server.TLS, cfg.Server.Auth.Mode); err != nil {
+		return fmt.Errorf("server.tls: %w", err)
+	}
+	for i, id := range cfg.Server.Auth.Identities {
+		if err := validateIdentity(id); err != nil {
+			return fmt.Errorf("server.auth.identities[%d]: %w", i, err)
+		}
+	}
+	for i, c := range cfg.Server.Auth.HTTP.Credentials {
+		if err := validateCredential(c); err != nil {
+			return fmt.Errorf("server.auth.http.credentials[%d]: %w", i, err)
+		}
+	}
+	if u := cfg.Server.Alerts.DashboardURL; u != "" {
+		if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("server.alerts.dashboard_url %q: want an absolute http(s) URL", u)
+		}
+	}
+	receivers, err := validateReceivers(cfg.Server.Alerts)
+	if err != nil {
+		return fmt.Errorf("server.alerts.%w", err)
+	}
+	for i, rule := range cfg.Server.Alerts.Rules {
+		if err := validateRule(rule); err != nil {
+			return fmt.Errorf("server.alerts.rules[%d]: %w", i, err)
+		}
+		if rule.Receiver != "" && !receivers[rule.Receiver] {
+			return fmt.Errorf("server.alerts.rules[%d]: %q: unknown receiver %q", i, rule.Name, rule.Receiver)
+		}
+	}
+	if r := cfg.Server.Alerts.Route; r != nil {
+		if len(r.Severity) > 0 || r.Rule != "" || len(r.Match) > 0 || r.Continue {
+			return fmt.Errorf("server.alerts.route: the root route matches every alert; set matchers on its routes")
+		}
+		if err := validateRoute(*r, receivers); err != nil {
+			return fmt.Errorf("server.alerts.route%w", err)
+		}
+	}
+	for i, w := range cfg.Server.Alerts.Maintenance {
+		if err := validateMaintenance(w); err != nil {
+			return fmt.Errorf("server.alerts.maintenance[%d]: %w", i, err)
+		}
+	}
+	if err := validateStorage(cfg.Server.Storage); err != nil {
+		return fmt.Errorf("server.storage: %w", err)
+	}
 	if cfg.Server.Snapshot.TTL < 0 {
 		return fmt.Errorf("server.snapshot.ttl must not be negative")
 	}
 	return nil
 }
+
+// validateTLS checks that the TLS files needed by mode are configured.
+func validateTLS(t TLSConfig, mode string) error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("cert_file and key_file must be set together")
+	}
+	if !t.Enabled() && (t.ClientCAFile != "" || t.RequireHTTPClientCert) {
+		return fmt.Errorf("client_ca_file and require_http_client_cert need cert_file and key_file")
+	}
+	if t.RequireHTTPClientCert && t.ClientCAFile == "" {
+		return fmt.Errorf("require_http_client_cert needs client_ca_file")
+	}
+	if mode == "mtls" && (!t.Enabled() || t.ClientCAFile == "") {
+		return fmt.Errorf("auth mode mtls needs cert_file, key_file and client_ca_file")
+	}
+	return nil
+}
+
+// validateRule checks one alert rule.
+func validateRule(r AlertRule) error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if _, err := expr.Parse(r.Condition); err != nil {
+		return fmt.Errorf("%q: condition %q: %w", r.Name, r.Condition, err)
+	}
+	if r.Cooldown < 0 || r.For < 0 || r.KeepFiringFor < 0 {
+		return fmt.Errorf("%q: cooldown, for and keep_firing_for must not be negative", r.Name)
+	}
+	if err := validateSeverity(r.Severity); err != nil {
+		return fmt.Errorf("%q: %w", r.Name, err)
+	}
+	if err := r.Match.Validate(); err != nil {
+		return fmt.Errorf("%q: match: %w", r.Name, err)
+	}
+	for i, o := range r.Overrides {
+		if err := o.Match.Validate(); err != nil {
+			return fmt.Errorf("%q: overrides[%d]: match: %w", r.Name, i, err)
+		}
+		if err := validateSeverity(o.Severity); err != nil {
+			return fmt.Errorf("%q: overrides[%d]: %w", r.Name, i, err)
+		}
+		if o.Cooldown < 0 {
+			return fmt.Errorf("%q: overrides[%d]: cooldown must not be negative", r.Name, i)
+		}
+	}
+	return nil
+}
+
+// validateSeverity checks an optional alert severity.
+func validateSeverity(s string) error {
+	switch s {
+	case "", "critical", "warning", "info":
+		return nil
+	}
+	return fmt.Errorf("unknown severity %q: want critical|warning|info", s)
+}
+
+// validateReceivers checks the webhooks and receivers and returns the set of
+// receiver names, including the default receiver when webhooks are set.
+func validateReceivers(a AlertsConfig) (map[string]bool, error) {
+	names := make(map[string]bool)
+	for i, w := range a.Webhooks {
+		if err := validateWebhook(w); err != nil {
+			return nil, fmt.Errorf("webhooks[%d]: %w", i, err)
+		}
+	}
+	if len(a.Webhooks) > 0 {
+		names[DefaultReceiver] = true
+	}
+	for i, r := range a.Receivers {
+		switch {
+		case r.Name == "":
+			return nil, fmt.Errorf("receivers[%d]: name is required", i)
+		case names[r.Name] && r.Name == DefaultReceiver:
+			return nil, fmt.Errorf("receivers[%d]: %q is the receiver formed by webhooks; pick another name", i, r.Name)
+		case names[r.Name]:
+			return nil, fmt.Errorf("receivers[%d]: duplicate name %q", i, r.Name)
+		}
+		for j, w := range r.Webhooks {
+			if err := validateWebhook(w); err != nil {
+				return nil, fmt.Errorf("receivers[%d]: %q: webhooks[%d]: %w", i, r.Name, j, err)
+			}
+		}
+		names[r.Name] = true
+	}
+	return names, nil
+}
+
+// validateWebhook checks one webhook target.
+func validateWebhook(w WebhookConfig) error {
+	if w.Template != "" {
+		if w.Type != "slack" && w.Type != "teams" && w.Type != "http" {
+			return fmt.Errorf("%s: template is only supported for slack, teams and http", w.Type)
+		}
+		if _, err := payload.Parse(w.Template); err != nil {
+			return err
+		}
+	}
+	if w.SigningSecretEnv != "" && w.Type != "http" {
+		return fmt.Errorf("%s: signing_secret_env is only supported for http", w.Type)
+	}
+	if w.Type == "smtp" && (len(w.Headers) > 0 || len(w.HeadersEnv) > 0 || w.Retry != (RetryConfig{})) {
+		return fmt.Errorf("smtp: headers, headers_env and retry apply to HTTP webhooks only")
+	}
+	for _, headers := range []map[string]string{w.Headers, w.HeadersEnv} {
+		for name := range headers {
+			if !validHeaderName(name) {
+				return fmt.Errorf("header %q: not a valid HTTP header name", name)
+			}
+		}
+	}
+	if err := validateRetry(w.Retry); err != nil {
+		return fmt.Errorf("retry: %w", err)
+	}
+
+	switch w.Type {
+	case "teams", "slack", "http":
+		return nil
+	case "smtp":
+		if w.SMTP == nil {
+			return fmt.Errorf("smtp: the smtp block is required")
+		}
+		if err := validateSMTP(*w.SMTP); err != nil {
+			return fmt.Errorf("smtp: %w", err)
+		}
+		return nil
+	case "pagerduty":
+		if w.RoutingKeyEnv == "" {
+			return fmt.Errorf("pagerduty: routing_key_env is required")
+		}
+		return nil
+	}
+	return fmt.Errorf("type %q unknown: want teams|slack|pagerduty|http|smtp", w.Type)
+}
+
+// validHeaderName reports whether name is an HTTP header field name (an
+// RFC 7230 token).
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if c >= 0x7f || c <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateRetry checks a webhook's retry settings.
+func validateRetry(r RetryConfig) error {
+	switch {
+	case r.MaxAttempts < 0 || r.MaxAttempts > 20:
+		return fmt.Errorf("max_attempts %d is out of range [1, 20]", r.MaxAttempts)
+	case r.Backoff < 0 || r.MaxBackoff < 0:
+		return fmt.Errorf("backoff and max_backoff must not be negative")
+	case r.MaxBackoff > 0 && r.Backoff > r.MaxBackoff:
+		return fmt.Errorf("backoff %v exceeds max_backoff %v", r.Backoff, r.MaxBackoff)
+	}
+	return nil
+}
+
+// validateSMTP checks the mail settings of an smtp webhook.
+func validateSMTP(s SMTPConfig) error {
+	if s.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+	if s.Port < 0 || s.Port > 65535 {
+		return fmt.Errorf("port %d is out of range [1, 65535]", s.Port)
+	}
+	switch s.TLS {
+	case "", "starttls", "tls", "none":
+	default:
+		return fmt.Errorf("tls %q unknown: want starttls|tls|none", s.TLS)
+	}
+	if s.PasswordEnv != "" && s.UsernameEnv == "" {
+		return fmt.Errorf("password_env requires username_env")
+	}
+	if _, err := mail.ParseAddress(s.From); err != nil {
+		return fmt.Errorf("from %q: %w", s.From, err)
+	}
+	if len(s.To) == 0 {
+		return fmt.Errorf("to: at least one recipient is required")
+	}
+	for _, to := range s.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("to %q: %w", to, err)
+		}
+	}
+	if s.GroupWait < 0 {
+		return fmt.Errorf("group_wait must not be negative")
+	}
+	return nil
+}
+
+// validateRoute checks r and its children recursively. Errors start with
+// the path below the route, e.g. ".routes[1].routes[0]: ...".
+func validateRoute(r Route, receivers map[string]bool) error {
+	if r.Receiver != "" && !receivers[r.Receiver] {
+		return fmt.Errorf(": unknown receiver %q", r.Receiver)
+	}
+	for _, sev := range r.Severity {
+		if sev == "" {
+			return fmt.Errorf(": empty severity")
+		}
+		if err := validateSeverity(sev); err != nil {
+			return fmt.Errorf(": %w", err)
+		}
+	}
+	if _, err := path.Match(r.Rule, ""); err != nil {
+		return fmt.Errorf(": rule: bad pattern %q: %w", r.Rule, err)
+	}
+	if err := r.Match.Validate(); err != nil {
+		return fmt.Errorf(": match: %w", err)
+	}
+	for i, child := range r.Routes {
+		if err := validateRoute(child, receivers); err != nil {
+			return fmt.Errorf(".routes[%d]%w", i, err)
+		}
+	}
+	return nil
+}
+
+// validateMaintenance checks one maintenance window.
+func validateMaintenance(w MaintenanceWindow) error {
+	if w.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if _, err := path.Match(w.Rule, ""); err != nil {
+		return fmt.Errorf("%q: rule: bad pattern %q: %w", w.Name, w.Rule, err)
+	}
+	if err := w.Match.Validate(); err != nil {
+		return fmt.Errorf("%q: match: %w", w.Name, err)
+	}
+	for _, d := range w.Days {
+		if _, ok := Weekdays[d]; !ok {
+			return fmt.Errorf("%q: day %q unknown: want mon|tue|wed|thu|fri|sat|sun", w.Name, d)
+		}
+	}
+	if _, err := time.Parse("15:04", w.Start); err != nil {
+		return fmt.Errorf("%q: start %q: want HH:MM", w.Name, w.Start)
+	}
+	if w.Duration <= 0 || w.Duration > 7*24*time.Hour {
+		return fmt.Errorf("%q: duration must be positive and at most 168h", w.Name)
+	}
+	if _, err := time.LoadLocation(w.Timezone); err != nil {
+		return fmt.Errorf("%q: timezone: %w", w.Name, err)
+	}
+	return nil
+}
+
+// validateStorage checks the history backend settings.
+func validateStorage(s StorageConfig) error {
+	switch s.Backend {
+	case "", "none":
+		return nil
+	case "sqlite":
+	default:
+		return fmt.Errorf("backend %q unknown: want sqlite|none", s.Backend)
+	}
+	if s.Path == "" {
+		return fmt.Errorf("path is required for backend %s", s.Backend)
+	}
+	if s.Retention <= 0 {
+		return fmt.Errorf("retention must be positive")
+	}
+	if s.AlertRetention < 0 {
+		return fmt.Errorf("alert_retention must not be negative")
+	}
+	return nil
+}
+
+// validateCredential checks one REST API credential.
+func validateCredential(c HTTPCredential) error {
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch c.Type {
+	case "apikey", "bearer":
+	default:
+		return fmt.Errorf("%q: unknown type %q: want apikey|bearer", c.Name, c.Type)
+	}
+	if c.SecretEnv == "" {
+		return fmt.Errorf("%q: secret_env is required", c.Name)
+	}
+	switch c.Role {
+	case RoleRead, RoleAdmin:
+	default:
+		return fmt.Errorf("%q: unknown role %q: want read|admin", c.Name, c.Role)
+	}
+	if c.Secret() == "" {
+		// Skipping the credential could leave none, which opens the API.
+		return fmt.Errorf("%q: environment variable %s is not set", c.Name, c.SecretEnv)
+	}
+	return nil
+}
+
+// validateIdentity checks one certificate identity mapping.
+func validateIdentity(id IdentityConfig) error {
+	if id.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(id.Sources) == 0 {
+		return fmt.Errorf("%q: sources must list at least one source ID or pattern", id.Name)
+	}
+	for _, pattern := range id.Sources {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%q: bad source pattern %q: %w", id.Name, pattern, err)
+		}
+	}
+	return nil
+}
@@ -32,11 +32,27 @@ func TestLoad_Defaults(t *testing.T) {
 	if cfg.Server.HTTPPort != DefaultHTTPPort {
 		t.Errorf("http_port: got %d, want %d", cfg.Server.HTTPPort, DefaultHTTPPort)
 	}
+	if cfg.Server.AdminPort != DefaultAdminPort {
+		t.Errorf("admin_port: got %d, want %d", cfg.Server.AdminPort, DefaultAdminPort)
+	}
 	if cfg.Server.Snapshot.TTL != DefaultSnapshotTTL {
 		t.Errorf("snapshot.ttl: got %v, want %v", cfg.Server.Snapshot.TTL, DefaultSnapshotTTL)
 	}
 }
 
+func TestLoad_AdminPort(t *testing.T) {
+	cfg, err := Load(writeConfig(t, "server:\n  admin_port: 0\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.AdminPort != 0 {
+		t.Errorf("admin_port: got %d, want 0 (disabled)", cfg.Server.AdminPort)
+	}
+	if _, err := Load(writeConfig(t, "server:\n  admin_port: -1\n")); err == nil {
+		t.Error("expected error for negative admin_port, got nil")
+	}
+}
+
 func TestLoad_FullServer(t *testing.T) {
 	p := writeConfig(t, `server:
   grpc_port: 9090
@@ -114,3 +130,576 @@ func TestLoad_MissingFile(t *testing.T) {
 		t.Fatal("expected error for missing file, got nil")
 	}
 }
+
+func TestLoad_TLSAndIdentities(t *testing.T) {
+	p := writeConfig(t, `server:
+  tls:
+    cert_file: /etc/tls/server.crt
+    key_file: /etc/tls/server.key
+    client_ca_file: /etc/tls/ca.crt
+  auth:
+    mode: mtls
+    identities:
+      - name: agent-prod.example.com
+        sources: ["otel-*", "prom-prod"]
+`)
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.Server.TLS.Enabled() {
+		t.Error("TLS.Enabled: got false, want true")
+	}
+	ids := cfg.Server.Auth.Identities
+	if len(ids) != 1 || ids[0].Name != "agent-prod.example.com" || len(ids[0].Sources) != 2 {
+		t.Errorf("identities: got %+v", ids)
+	}
+}
+
+func TestLoad_TLSInvalid(t *testing.T) {
+	tests := map[string]string{
+		"mtls without tls": `server:
+  auth:
+    mode: mtls
+`,
+		"mtls without client ca": `server:
+  tls:
+    cert_file: s.crt
+    key_file: s.key
+  auth:
+    mode: mtls
+`,
+		"cert without key": `server:
+  tls:
+    cert_file: s.crt
+`,
+		"require http cert without ca": `server:
+  tls:
+    cert_file: s.crt
+    key_file: s.key
+    require_http_client_cert: true
+`,
+		"identity without sources": `server:
+  auth:
+    identities:
+      - name: agent
+`,
+		"bad source pattern": `server:
+  auth:
+    identities:
+      - name: agent
+        sources: ["otel-["]
+`,
+	}
+	for name, yaml := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(writeConfig(t, yaml)); err == nil {
+				t.Fatal("expected validation error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoad_HTTPAuth(t *testing.T) {
+	t.Setenv("UI_TOKEN", "tok")
+	p := writeConfig(t, `server:
+  auth:
+    http:
+      credentials:
+        - name: dashboard
+          type: bearer
+          secret_env: UI_TOKEN
+          role: read
+      allowed_origins: ["https://ui.example"]
+`)
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	h := cfg.Server.Auth.HTTP
+	if len(h.Credentials) != 1 {
+		t.Fatalf("credentials: got %d, want 1", len(h.Credentials))
+	}
+	if c := h.Credentials[0]; c.Role != RoleRead || c.Secret() != "tok" {
+		t.Errorf("credential: got role %q secret %q, want read/tok", c.Role, c.Secret())
+	}
+	if len(h.AllowedOrigins) != 1 || h.AllowedOrigins[0] != "https://ui.example" {
+		t.Errorf("allowed_origins: got %v", h.AllowedOrigins)
+	}
+}
+
+func TestLoad_HTTPAuthInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown type": `server:
+  auth:
+    http:
+      credentials:
+        - {name: a, type: basic, secret_env: S, role: read}
+`,
+		"unknown role": `server:
+  auth:
+    http:
+      credentials:
+        - {name: a, type: apikey, secret_env: S, role: owner}
+`,
+		"missing secret_env": `server:
+  auth:
+    http:
+      credentials:
+        - {name: a, type: apikey, role: admin}
+`,
+		"unset secret": `server:
+  auth:
+    http:
+      credentials:
+        - {name: a, type: apikey, secret_env: OBSIDIAN_TEST_UNSET_SECRET, role: admin}
+`,
+	}
+	for name, yaml := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(writeConfig(t, yaml)); err == nil {
+				t.Fatal("expected validation error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoad_Storage(t *testing.T) {
+	p := writeConfig(t, `server:
+  storage:
+    backend: sqlite
+    path: /var/lib/obsidianstack/history.db
+`)
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	s := cfg.Server.Storage
+	if !s.Enabled() {
+		t.Error("Enabled: got false, want true")
+	}
+	if s.Retention != DefaultRetention {
+		t.Errorf("retention: got %v, want %v", s.Retention, DefaultRetention)
+	}
+	if s.AlertRetention != 0 {
+		t.Errorf("alert_retention: got %v, want 0 (kept forever)", s.AlertRetention)
+	}
+}
+
+func TestLoad_StorageInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown backend": `server:
+  storage:
+    backend: postgres
+    path: x
+`,
+		"sqlite without path": `server:
+  storage:
+    backend: sqlite
+`,
+		"negative retention": `server:
+  storage:
+    backend: sqlite
+    path: x
+    retention: -1h
+`,
+		"negative alert retention": `server:
+  storage:
+    backend: sqlite
+    path: x
+    alert_retention: -1h
+`,
+	}
+	for name, yaml := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(writeConfig(t, yaml)); err == nil {
+				t.Fatal("expected validation error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoad_AlertRules(t *testing.T) {
+	p := writeConfig(t, `server:
+  alerts:
+    rules:
+      - name: noisy-collector
+        condition: 'source_type == otelcol && (drop_pct > 5 || extra.queue_size > 5000)'
+        severity: critical
+        for: 2m
+        keep_firing_for: 5m
+      - name: staging-logs
+        condition: "cluster =~ 'staging-.*' && signal.logs.drop_pct >= 10"
+        match:
+          source_type: loki
+          namespace: "!kube-*"
+        overrides:
+          - match: {cluster: prod-*}
+            severity: critical
+            cooldown: 5m
+`)
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if n := len(cfg.Server.Alerts.Rules); n != 2 {
+		t.Fatalf("rules: got %d, want 2", n)
+	}
+	if r := cfg.Server.Alerts.Rules[0]; r.For != 2*time.Minute || r.KeepFiringFor != 5*time.Minute {
+		t.Errorf("for/keep_firing_for: got %v/%v, want 2m/5m", r.For, r.KeepFiringFor)
+	}
+	r := cfg.Server.Alerts.Rules[1]
+	if r.Match["source_type"] != "loki" || r.Match["namespace"] != "!kube-*" {
+		t.Errorf("match: got %v", r.Match)
+	}
+	if len(r.Overrides) != 1 || r.Overrides[0].Severity != "critical" || r.Overrides[0].Cooldown != 5*time.Minute {
+		t.Errorf("overrides: got %+v", r.Overrides)
+	}
+}
+
+func TestLoad_AlertRulesInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field": `server:
+  alerts:
+    rules:
+      - name: typo
+        condition: "drop_pcct > 10"
+`,
+		"syntax error": `server:
+  alerts:
+    rules:
+      - name: dangling
+        condition: "drop_pct > 10 &&"
+`,
+		"missing name": `server:
+  alerts:
+    rules:
+      - condition: "drop_pct > 10"
+`,
+		"unknown selector label": `server:
+  alerts:
+    rules:
+      - name: r
+        condition: "drop_pct > 10"
+        match: {region: eu}
+`,
+		"bad selector pattern": `server:
+  alerts:
+    rules:
+      - name: r
+        condition: "drop_pct > 10"
+        match: {cluster: "prod-["}
+`,
+		"unknown override severity": `server:
+  alerts:
+    rules:
+      - name: r
+        condition: "drop_pct > 10"
+        overrides:
+          - match: {cluster: prod}
+            severity: page
+`,
+		"negative for": `server:
+  alerts:
+    rules:
+      - name: r
+        condition: "drop_pct > 10"
+        for: -1m
+`,
+		"negative cooldown": `server:
+  alerts:
+    rules:
+      - name: r
+        condition: "drop_pct > 10"
+        cooldown: -1m
+`,
+	}
+	for name, yaml := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(writeConfig(t, yaml)); err == nil {
+				t.Fatal("expected validation error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoad_Maintenance(t *testing.T) {
+	p := writeConfig(t, `server:
+  alerts:
+    maintenance:
+      - name: loki-upgrade
+        rule: "*-drops"
+        match: {source_type: loki}
+        days: [sat, sun]
+        start: "23:30"
+        duration: 3h
+        timezone: Europe/Berlin
+`)
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	w := cfg.Server.Alerts.Maintenance[0]
+	if w.Name != "loki-upgrade" || w.Start != "23:30" || w.Duration != 3*time.Hour || len(w.Days) != 2 {
+		t.Errorf("maintenance: got %+v", w)
+	}
+}
+
+func TestLoad_MaintenanceInvalid(t *testing.T) {
+	tests := map[string]string{
+		"missing name":     "start: '02:00'\n        duration: 1h",
+		"bad start":        "name: m\n        start: 2am\n        duration: 1h",
+		"zero duration":    "name: m\n        start: '02:00'",
+		"too long":         "name: m\n        start: '02:00'\n        duration: 169h",
+		"unknown day":      "name: m\n        start: '02:00'\n        duration: 1h\n        days: [monday]",
+		"unknown timezone": "name: m\n        start: '02:00'\n        duration: 1h\n        timezone: Mars/Olympus",
+		"bad rule pattern": "name: m\n        start: '02:00'\n        duration: 1h\n        rule: '['",
+		"bad selector":     "name: m\n        start: '02:00'\n        duration: 1h\n        match: {region: eu}",
+	}
+	for name, w := range tests {
+		t.Run(name, func(t *testing.T) {
+			yaml := "server:\n  alerts:\n    maintenance:\n      - " + w + "\n"
+			if _, err := Load(writeConfig(t, yaml)); err == nil {
+				t.Fatal("expected validation error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoad_Routing(t *testing.T) {
+	p := writeConfig(t, `server:
+  alerts:
+    rules:
+      - name: cert_expiry
+        condition: "cert_days_left < 14"
+        receiver: platform
+    webhooks:
+      - type: slack
+        url_env: SLACK_URL
+    receivers:
+      - name: oncall
+        webhooks:
+          - type: pagerduty
+            routing_key_env: PD_ROUTING_KEY
+      - name: platform
+    route:
+      receiver: default
+      routes:
+        - severity: [critical]
+          receiver: oncall
+          continue: true
+        - rule: "drop*"
+          match: {cluster: "prod-*"}
+          routes:
+            - receiver: platform
+`)
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	a := cfg.Server.Alerts
+	if len(a.Receivers) != 2 || a.Receivers[0].Webhooks[0].Type != "pagerduty" {
+		t.Errorf("receivers: got %+v", a.Receivers)
+	}
+	if pd := a.Receivers[0].Webhooks[0]; pd.RoutingKeyEnv != "PD_ROUTING_KEY" || pd.URL() != PagerDutyEventsURL {
+		t.Errorf("pagerduty webhook: got %+v, URL %q", pd, pd.URL())
+	}
+	if r := a.Route; r == nil || len(r.Routes) != 2 || !r.Routes[0].Continue || r.Routes[1].Routes[0].Receiver != "platform" {
+		t.Errorf("route: got %+v", r)
+	}
+	if a.Rules[0].Receiver != "platform" {
+		t.Errorf("rule receiver: got %q", a.Rules[0].Receiver)
+	}
+}
+
+func TestLoad_RoutingInvalid(t *testing.T) {
+	tests := map[string]string{
+		"receiver without name":  "receivers: [{}]",
+		"duplicate receiver":     "receivers: [{name: a}, {name: a}]",
+		"shadows webhooks":       "webhooks: [{type: slack}]\n    receivers: [{name: default}]",
+		"unknown webhook type":   "receivers: [{name: a, webhooks: [{type: email}]}]",
+		"pagerduty without key":  "webhooks: [{type: pagerduty}]",
+		"unknown route receiver": "receivers: [{name: a}]\n    route: {routes: [{receiver: b}]}",
+		"matchers on root":       "receivers: [{name: a}]\n    route: {receiver: a, severity: [critical]}",
+		"bad severity":           "receivers: [{name: a}]\n    route: {routes: [{receiver: a, severity: [high]}]}",
+		"bad rule pattern":       "receivers: [{name: a}]\n    route: {routes: [{routes: [{rule: '['}]}]}",
+		"bad selector":           "receivers: [{name: a}]\n    route: {routes: [{match: {region: eu}}]}",
+		"unknown rule receiver":  "rules: [{name: r, condition: 'drop_pct > 1', receiver: b}]",
+	}
+	for name, alerts := range tests {
+		t.Run(name, func(t *testing.T) {
+			yaml := "server:\n  alerts:\n    " + alerts + "\n"
+			if _, err := Load(writeConfig(t, yaml)); err == nil {
+				t.Fatal("expected validation error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoad_SMTPWebhook(t *testing.T) {
+	p := writeConfig(t, `server:
+  alerts:
+    dashboard_url: https://obsidian.example.com
+    webhooks:
+      - type: smtp
+        smtp:
+          host: smtp.example.com
+          username_env: SMTP_USER
+          password_env: SMTP_PASSWORD
+          from: "ObsidianStack <alerts@example.com>"
+          to: [oncall@example.com]
+          group_wait: 1m
+`)
+	t.Setenv("SMTP_USER", "obsidian")
+	t.Setenv("SMTP_PASSWORD", "s3cret")
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	s := cfg.Server.Alerts.Webhooks[0].SMTP
+	if s == nil || s.Addr() != "smtp.example.com:587" || s.GroupWait != time.Minute || len(s.To) != 1 {
+		t.Fatalf("smtp: got %+v", s)
+	}
+	if user, pass := s.Credentials(); user != "obsidian" || pass != "s3cret" {
+		t.Errorf("Credentials: got %q, %q", user, pass)
+	}
+	if got := (SMTPConfig{Host: "smtp.example.com", TLS: "tls"}).Addr(); got != "smtp.example.com:465" {
+		t.Errorf("Addr with implicit TLS: got %q", got)
+	}
+}
+
+func TestLoad_SMTPWebhookInvalid(t *testing.T) {
+	valid := "host: smtp.example.com, from: alerts@example.com, to: [oncall@example.com]"
+	tests := map[string]string{
+		"no smtp block": "",
+		"missing host":  "smtp: {from: alerts@example.com, to: [oncall@example.com]}",
+		"bad port":      "smtp: {" + valid + ", port: 70000}",
+		"unknown tls":   "smtp: {" + valid + ", tls: ssl}",
+		"password only": "smtp: {" + valid + ", password_env: SMTP_PASSWORD}",
+		"bad from":      "smtp: {host: smtp.example.com, from: alerts, to: [oncall@example.com]}",
+		"no recipients": "smtp: {host: smtp.example.com, from: alerts@example.com}",
+		"bad recipient": "smtp: {host: smtp.example.com, from: alerts@example.com, to: [oncall]}",
+		"negative wait": "smtp: {" + valid + ", group_wait: -1s}",
+	}
+	for name, smtp := range tests {
+		t.Run(name, func(t *testing.T) {
+			yaml := "server:\n  alerts:\n    webhooks: [{type: smtp, " + smtp + "}]\n"
+			if _, err := Load(writeConfig(t, yaml)); err == nil {
+				t.Fatal("expected validation error, got nil")
+			}
+		})
+	}
+	yaml := "server:\n  alerts:\n    dashboard_url: obsidian.example.com\n"
+	if _, err := Load(writeConfig(t, yaml)); err == nil {
+		t.Error("relative dashboard_url: expected validation error, got nil")
+	}
+}
+
+func TestLoad_WebhookTemplate(t *testing.T) {
+	p := writeConfig(t, `server:
+  alerts:
+    webhooks:
+      - type: http
+        url_env: HOOK_URL
+        template: |
+          {"rule": {{json .Alert.RuleName}}, "drop_pct": {{.Snapshot.DropPct}}}
+`)
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if tpl := cfg.Server.Alerts.Webhooks[0].Template; tpl == "" {
+		t.Error("template not loaded")
+	}
+
+	for name, webhook := range map[string]string{
+		"syntax":        `{type: http, template: '{"rule": {{json .Alert.RuleName}'}`,
+		"unknown field": `{type: slack, template: '{"rule": {{json .Alert.Rule}}}'}`,
+		"not json":      `{type: teams, template: '{{.Alert.RuleName}}'}`,
+		"pagerduty":     `{type: pagerduty, routing_key_env: PD_KEY, template: '{}'}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			yaml := "server:\n  alerts:\n    webhooks: [" + webhook + "]\n"
+			if _, err := Load(writeConfig(t, yaml)); err == nil {
+				t.Fatal("expected validation error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoad_WebhookDelivery(t *testing.T) {
+	t.Setenv("HOOK_TOKEN", "Bearer t0k3n")
+	t.Setenv("HOOK_SECRET", "s3cret")
+	p := writeConfig(t, `server:
+  alerts:
+    webhooks:
+      - type: http
+        url_env: HOOK_URL
+        headers: {X-Team: sre}
+        headers_env: {Authorization: HOOK_TOKEN}
+        signing_secret_env: HOOK_SECRET
+        retry:
+          max_attempts: 6
+          backoff: 2s
+      - type: slack
+        url_env: SLACK_URL
+`)
+	cfg, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	wh := cfg.Server.Alerts.Webhooks[0]
+	if h := wh.Header(); h.Get("X-Team") != "sre" || h.Get("Authorization") != "Bearer t0k3n" {
+		t.Errorf("Header: got %v", h)
+	}
+	if wh.SigningSecret() != "s3cret" {
+		t.Errorf("SigningSecret: got %q", wh.SigningSecret())
+	}
+	want := RetryConfig{MaxAttempts: 6, Backoff: 2 * time.Second, MaxBackoff: DefaultRetryMaxBackoff}
+	if got := wh.Retry.WithDefaults(); got != want {
+		t.Errorf("retry: got %+v, want %+v", got, want)
+	}
+	want = RetryConfig{MaxAttempts: DefaultRetryAttempts, Backoff: DefaultRetryBackoff, MaxBackoff: DefaultRetryMaxBackoff}
+	if got := cfg.Server.Alerts.Webhooks[1].Retry.WithDefaults(); got != want {
+		t.Errorf("default retry: got %+v, want %+v", got, want)
+	}
+
+	for name, webhook := range map[string]string{
+		"signed slack": `{type: slack, url_env: U, signing_secret_env: S}`,
+		"header name":  `{type: http, url_env: U, headers: {"X Team": sre}}`,
+		"env header":   `{type: http, url_env: U, headers_env: {"": TOKEN}}`,
+		"attempts":     `{type: http, url_env: U, retry: {max_attempts: 50}}`,
+		"backoff":      `{type: http, url_env: U, retry: {backoff: 1m, max_backoff: 10s}}`,
+		"negative":     `{type: http, url_env: U, retry: {backoff: -1s}}`,
+		"smtp headers": `{type: smtp, headers: {X-Team: sre}, smtp: {host: mail, from: a@example.com, to: [b@example.com]}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			yaml := "server:\n  alerts:\n    webhooks: [" + webhook + "]\n"
+			if _, err := Load(writeConfig(t, yaml)); err == nil {
+				t.Fatal("expected validation error, got nil")
+			}
+		})
+	}
+}
+
+func TestSelector_Matches(t *testing.T) {
+	labels := map[string]string{"source_type": "otelcol", "cluster": "prod-eu"}
+	tests := []struct {
+		sel  Selector
+		want bool
+	}{
+		{nil, true},
+		{Selector{"source_type": "otelcol"}, true},
+		{Selector{"source_type": "otelcol", "cluster": "prod-*"}, true},
+		{Selector{"cluster": "staging-*"}, false},
+		{Selector{"cluster": "!staging-*"}, true},
+		{Selector{"cluster": "!prod-*"}, false},
+		{Selector{"namespace": ""}, true}, // absent label is empty
+		{Selector{"namespace": "!"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.sel.Matches(labels); got != tt.want {
+			t.Errorf("%v.Matches: got %v, want %v", tt.sel, got, tt.want)
+		}
+	}
+}
@@ -4,10 +4,31 @@
 // Config fields:
 //   - GRPCPort     — port for the gRPC receiver (default 50051)
 //   - HTTPPort     — port for the REST API and WebSocket hub (default 8080)
-//   - Auth.Mode    — "apikey" or "none"; "mtls" supported for future use
+//   - AdminPort    — port for the server's own /metrics, /healthz and /readyz
+//     (default 8081, 0 disables)
+//   - TLS          — cert_file/key_file enable TLS on both listeners;
+//     client_ca_file verifies client certificates (required for mtls);
+//     require_http_client_cert extends mandatory mTLS to the HTTP listener
+//   - Auth.Mode    — "apikey", "mtls" or "none"
 //   - Auth.KeyEnv  — environment variable holding the expected API key
 //   - Auth.Header  — gRPC metadata/HTTP header name (default "x-api-key")
+//   - Auth.Identities — mtls only: certificate identity (CN, DNS or URI SAN)
+//     → allowed source ID patterns
+//   - Auth.HTTP   — REST API / WebSocket credentials (apikey or bearer, role
+//     read|admin, secret from secret_env) and allowed WebSocket origins
 //   - Snapshot.TTL — how long a source snapshot remains live (default 5m)
+//   - Alerts       — rules (name, condition, severity, cooldown, for,
+//     keep_firing_for, match selector, per-selector overrides of severity and
+//     cooldown, receiver), webhook targets (type, url_env, routing_key_env
+//     for pagerduty, an smtp block for email, a payload template checked
+//     with package alerts/payload, custom headers, signing_secret_env for
+//     http, retry attempts and backoff; the "default" receiver), the
+//     dashboard URL for links, named receivers, the route tree (severity,
+//     rule glob, match selector, continue) and recurring maintenance
+//     windows (rule glob, match selector, days, HH:MM start, duration,
+//     timezone); every condition must parse (see package alerts/expr)
+//   - Storage      — history backend ("sqlite" or "none"), database path and
+//     retention (default 168h); the database also holds alert state
 //
 // Load(path) applies defaults before unmarshalling, then validates.
 package config
@@ -1,4 +1,4 @@
-package api
+package diagnostics
 
 import (
 	"fmt"
@@ -7,10 +7,10 @@ import (
 	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
 )
 
-// DiagnosticHint is one human-readable insight about a pipeline's health.
+// Hint is one human-readable insight about a pipeline's health.
 // The UI displays these as chips on the pipeline card; clicking one shows
 // Detail — written like an AI assistant explaining the problem in plain English.
-type DiagnosticHint struct {
+type Hint struct {
 	// Key is a stable machine-readable identifier (used for dedup/ordering).
 	Key string `json:"key"`
 	// Level is "ok" | "info" | "warning" | "critical"
@@ -23,10 +23,10 @@ type DiagnosticHint struct {
 	Value *float64 `json:"value,omitempty"`
 }
 
-// computeDiagnostics derives human-readable diagnostic hints from a snapshot.
+// Compute derives human-readable diagnostic hints from a snapshot.
 // Diagnostics are ordered: critical first, then warnings, then info.
-func computeDiagnostics(snap *pb.PipelineSnapshot) []DiagnosticHint {
-	var hints []DiagnosticHint
+func Compute(snap *pb.PipelineSnapshot) []Hint {
+	var hints []Hint
 
 	// ── Scrape failure ───────────────────────────────────────────────────────
 	if snap.ErrorMessage != "" {
@@ -39,7 +39,7 @@ func computeDiagnostics(snap *pb.PipelineSnapshot) []DiagnosticHint {
 				"for this pipeline are unavailable.",
 			msg,
 		)
-		hints = append(hints, DiagnosticHint{
+		hints = append(hints, Hint{
 			Key:    "scrape_failed",
 			Level:  "critical",
 			Title:  "Can't reach source",
@@ -50,7 +50,7 @@ func computeDiagnostics(snap *pb.PipelineSnapshot) []DiagnosticHint {
 
 	// ── Unknown state (first scrape / no baseline yet) ───────────────────────
 	if snap.State == "unknown" && snap.DropPct == 0 && snap.ThroughputPerMin == 0 {
-		hints = append(hints, DiagnosticHint{
+		hints = append(hints, Hint{
 			Key:   "warming_up",
 			Level: "info",
 			Title: "Warming up",
@@ -101,7 +101,7 @@ func computeDiagnostics(snap *pb.PipelineSnapshot) []DiagnosticHint {
 				pct,
 			)
 		}
-		hints = append(hints, DiagnosticHint{Key: "drop_rate", Level: level, Title: title, Detail: detail, Value: &v})
+		hints = append(hints, Hint{Key: "drop_rate", Level: level, Title: title, Detail: detail, Value: &v})
 	}
 
 	// ── Recovery rate (when there are drops) ─────────────────────────────────
@@ -113,7 +113,7 @@ func computeDiagnostics(snap *pb.PipelineSnapshot) []DiagnosticHint {
 				"A rate below 80%% means a significant portion of data is permanently lost.",
 			snap.RecoveryRate,
 		)
-		hints = append(hints, DiagnosticHint{
+		hints = append(hints, Hint{
 			Key:    "recovery_rate",
 			Level:  "info",
 			Title:  fmt.Sprintf("%.0f%% recovery", snap.RecoveryRate),
@@ -142,7 +142,7 @@ func computeDiagnostics(snap *pb.PipelineSnapshot) []DiagnosticHint {
 				"A brief dip is often a rolling restart; a sustained dip indicates instability.",
 			snap.UptimePct,
 		)
-		hints = append(hints, DiagnosticHint{
+		hints = append(hints, Hint{
 			Key:    "uptime",
 			Level:  level,
 			Title:  fmt.Sprintf("%.0f%% uptime", snap.UptimePct),
@@ -175,7 +175,7 @@ func computeDiagnostics(snap *pb.PipelineSnapshot) []DiagnosticHint {
 				"for example, metrics could be healthy while logs are backed up.",
 			sigName, sig.DropPct, sig.DroppedPm, sig.ReceivedPm,
 		)
-		hints = append(hints, DiagnosticHint{
+		hints = append(hints, Hint{
 			Key:    "signal_drop_" + sig.Type,
 			Level:  "warning",
 			Title:  fmt.Sprintf("%s drops", strings.Title(sig.Type)), //nolint:staticcheck
@@ -190,7 +190,7 @@ func computeDiagnostics(snap *pb.PipelineSnapshot) []DiagnosticHint {
 	// ── All clear ─────────────────────────────────────────────────────────────
 	if len(hints) == 0 {
 		score := snap.StrengthScore
-		hints = append(hints, DiagnosticHint{
+		hints = append(hints, Hint{
 			Key:   "healthy",
 			Level: "ok",
 			Title: "All clear",
@@ -210,9 +210,9 @@ func computeDiagnostics(snap *pb.PipelineSnapshot) []DiagnosticHint {
 
 // otelcolHints generates OTel-Collector-specific diagnostic hints using the
 // Extra map (queue gauges + per-minute counter rates populated by the agent).
-func otelcolHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
+func otelcolHints(snap *pb.PipelineSnapshot) []Hint {
 	ex := snap.Extra // may be nil for first scrape
-	var hints []DiagnosticHint
+	var hints []Hint
 
 	// ── Queue backpressure ────────────────────────────────────────────────────
 	qSize := ex["exporter_queue_size"]
@@ -222,7 +222,7 @@ func otelcolHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 		v := fillPct
 		switch {
 		case fillPct >= 90:
-			hints = append(hints, DiagnosticHint{
+			hints = append(hints, Hint{
 				Key:   "otel_queue_critical",
 				Level: "critical",
 				Title: fmt.Sprintf("Queue %.0f%% full", fillPct),
@@ -238,7 +238,7 @@ func otelcolHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 				Value: &v,
 			})
 		case fillPct >= 70:
-			hints = append(hints, DiagnosticHint{
+			hints = append(hints, Hint{
 				Key:   "otel_queue_warning",
 				Level: "warning",
 				Title: fmt.Sprintf("Queue %.0f%% full", fillPct),
@@ -252,7 +252,7 @@ func otelcolHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 				Value: &v,
 			})
 		case fillPct >= 30:
-			hints = append(hints, DiagnosticHint{
+			hints = append(hints, Hint{
 				Key:    "otel_queue_ok",
 				Level:  "info",
 				Title:  fmt.Sprintf("Queue %.0f%% used", fillPct),
@@ -269,7 +269,7 @@ func otelcolHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 	}
 	if totalRefusedPM > 0.5 {
 		v := totalRefusedPM
-		hints = append(hints, DiagnosticHint{
+		hints = append(hints, Hint{
 			Key:   "otel_receiver_refused",
 			Level: "warning",
 			Title: fmt.Sprintf("%.0f items/min refused", totalRefusedPM),
@@ -293,7 +293,7 @@ func otelcolHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 	}
 	if totalFailedPM > 0.5 {
 		v := totalFailedPM
-		hints = append(hints, DiagnosticHint{
+		hints = append(hints, Hint{
 			Key:   "otel_export_failures",
 			Level: "critical",
 			Title: fmt.Sprintf("%.0f exports/min failing", totalFailedPM),
@@ -312,7 +312,7 @@ func otelcolHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 
 	// ── Uptime context for otelcol ────────────────────────────────────────────
 	if snap.UptimePct < 100 {
-		hints = append(hints, DiagnosticHint{
+		hints = append(hints, Hint{
 			Key:   "otel_restart_tip",
 			Level: "info",
 			Title: "Check collector logs",
@@ -328,13 +328,13 @@ func otelcolHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 }
 
 // sourceTypeHints returns source-type-specific diagnostic hints.
-func sourceTypeHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
-	var hints []DiagnosticHint
+func sourceTypeHints(snap *pb.PipelineSnapshot) []Hint {
+	var hints []Hint
 
 	switch snap.SourceType {
 	case "prometheus":
 		if snap.DropPct > 0 {
-			hints = append(hints, DiagnosticHint{
+			hints = append(hints, Hint{
 				Key:   "prom_remotewrite_tip",
 				Level: "info",
 				Title: "Remote write check",
@@ -346,7 +346,7 @@ func sourceTypeHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 			})
 		}
 		if snap.UptimePct < 100 {
-			hints = append(hints, DiagnosticHint{
+			hints = append(hints, Hint{
 				Key:   "prom_restart_tip",
 				Level: "info",
 				Title: "Check Prometheus logs",
@@ -360,7 +360,7 @@ func sourceTypeHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 
 	case "loki":
 		if snap.DropPct > 0 {
-			hints = append(hints, DiagnosticHint{
+			hints = append(hints, Hint{
 				Key:   "loki_flush_tip",
 				Level: "info",
 				Title: "Check Loki ingesters",
@@ -377,6 +377,12 @@ func sourceTypeHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 
 	case "fluentbit":
 		hints = append(hints, fluentbitHints(snap)...)
+
+	case "jaeger":
+		hints = append(hints, jaegerHints(snap)...)
+
+	case "http":
+		hints = append(hints, httpProbeHints(snap)...)
 	}
 
 	return hints
@@ -384,15 +390,15 @@ func sourceTypeHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 
 // fluentbitHints generates Fluent Bit-specific diagnostic hints using the
 // Extra map (per-minute counter rates populated by the compute engine).
-func fluentbitHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
+func fluentbitHints(snap *pb.PipelineSnapshot) []Hint {
 	ex := snap.Extra
-	var hints []DiagnosticHint
+	var hints []Hint
 
 	// ── Permanent data loss (retried_failed = max retries exhausted) ──────────
 	lostPM := ex["output_retried_failed_pm"]
 	if lostPM > 0 {
 		v := lostPM
-		hints = append(hints, DiagnosticHint{
+		hints = append(hints, Hint{
 			Key:   "fb_data_loss",
 			Level: "critical",
 			Title: fmt.Sprintf("%.0f records/min lost", lostPM),
@@ -415,7 +421,7 @@ func fluentbitHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 	errorsPM := ex["output_errors_pm"]
 	if errorsPM > 0.5 {
 		v := errorsPM
-		hints = append(hints, DiagnosticHint{
+		hints = append(hints, Hint{
 			Key:   "fb_output_errors",
 			Level: "warning",
 			Title: fmt.Sprintf("%.0f output errors/min", errorsPM),
@@ -436,7 +442,7 @@ func fluentbitHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 	if retriesPM > 5 && lostPM == 0 {
 		// Only show if no data loss yet — if there IS loss, the critical hint covers it.
 		v := retriesPM
-		hints = append(hints, DiagnosticHint{
+		hints = append(hints, Hint{
 			Key:   "fb_retries",
 			Level: "info",
 			Title: fmt.Sprintf("%.0f retries/min", retriesPM),
@@ -455,7 +461,7 @@ func fluentbitHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 	filterDropPM := ex["filter_drop_records_pm"]
 	if filterDropPM > 0 {
 		v := filterDropPM
-		hints = append(hints, DiagnosticHint{
+		hints = append(hints, Hint{
 			Key:   "fb_filter_drops",
 			Level: "info",
 			Title: fmt.Sprintf("%.0f records/min filtered", filterDropPM),
@@ -472,3 +478,122 @@ func fluentbitHints(snap *pb.PipelineSnapshot) []DiagnosticHint {
 
 	return hints
 }
+
+// jaegerHints generates Jaeger-collector-specific diagnostic hints using the
+// Extra map (queue gauges + per-minute counter rates populated by the agent).
+func jaegerHints(snap *pb.PipelineSnapshot) []Hint {
+	ex := snap.Extra
+	var hints []Hint
+
+	// ── Queue backpressure ────────────────────────────────────────────────────
+	qSize := ex["queue_size"]
+	qCap := ex["queue_capacity"]
+	if qCap > 0 {
+		fillPct := qSize / qCap * 100
+		v := fillPct
+		switch {
+		case fillPct >= 90:
+			hints = append(hints, Hint{
+				Key:   "jaeger_queue_critical",
+				Level: "critical",
+				Title: fmt.Sprintf("Queue %.0f%% full", fillPct),
+				Detail: fmt.Sprintf(
+					"The Jaeger collector span queue is %.0f%% full (%.0f / %.0f spans). "+
+						"Storage writes are not keeping up with the ingest rate and the collector "+
+						"will start dropping spans as soon as the queue is full. "+
+						"Scale the storage backend, add collector replicas, or raise "+
+						"--collector.queue-size and --collector.num-workers.",
+					fillPct, qSize, qCap,
+				),
+				Value: &v,
+			})
+		case fillPct >= 70:
+			hints = append(hints, Hint{
+				Key:   "jaeger_queue_warning",
+				Level: "warning",
+				Title: fmt.Sprintf("Queue %.0f%% full", fillPct),
+				Detail: fmt.Sprintf(
+					"The Jaeger collector span queue is %.0f%% full (%.0f / %.0f spans). "+
+						"Backpressure is building — if storage does not catch up, spans will be dropped. "+
+						"Check the save latency and the health of your span storage.",
+					fillPct, qSize, qCap,
+				),
+				Value: &v,
+			})
+		case fillPct >= 30:
+			hints = append(hints, Hint{
+				Key:    "jaeger_queue_ok",
+				Level:  "info",
+				Title:  fmt.Sprintf("Queue %.0f%% used", fillPct),
+				Detail: fmt.Sprintf("The span queue is %.0f%% full (%.0f / %.0f). Healthy headroom.", fillPct, qSize, qCap),
+				Value:  &v,
+			})
+		}
+	}
+
+	// ── Storage write latency (mean, from histogram sum/count rates) ─────────
+	if countPM := ex["save_latency_count_pm"]; countPM > 0 {
+		meanMs := ex["save_latency_seconds_sum_pm"] / countPM * 1000
+		v := meanMs
+		level := "info"
+		if meanMs >= 500 {
+			level = "warning"
+		}
+		hints = append(hints, Hint{
+			Key:   "jaeger_save_latency",
+			Level: level,
+			Title: fmt.Sprintf("%.0f ms save latency", meanMs),
+			Detail: fmt.Sprintf(
+				"Writing spans to storage takes %.0f ms on average. "+
+					"Slow writes are the most common reason the collector queue fills up. "+
+					"If this keeps rising, check your Elasticsearch/Cassandra cluster load.",
+				meanMs,
+			),
+			Value: &v,
+		})
+	}
+
+	// ── Storage write errors ──────────────────────────────────────────────────
+	if errPM := ex["spans_saved_err_pm"]; errPM > 0.5 {
+		v := errPM
+		hints = append(hints, Hint{
+			Key:   "jaeger_save_errors",
+			Level: "critical",
+			Title: fmt.Sprintf("%.0f span saves/min failing", errPM),
+			Detail: fmt.Sprintf(
+				"%.0f spans per minute are failing to be written to storage. "+
+					"Check the collector logs for storage errors — common causes are "+
+					"authentication failures, full indices, or an unreachable backend.",
+				errPM,
+			),
+			Value: &v,
+		})
+	}
+
+	return hints
+}
+
+// httpProbeHints generates hints for synthetic http probes using the latency
+// percentiles computed by the agent over its rolling probe window.
+func httpProbeHints(snap *pb.PipelineSnapshot) []Hint {
+	var hints []Hint
+
+	if p95 := snap.LatencyP95Ms; p95 >= 1000 {
+		v := p95
+		hints = append(hints, Hint{
+			Key:   "http_probe_slow",
+			Level: "warning",
+			Title: fmt.Sprintf("P95 %.0f ms response", p95),
+			Detail: fmt.Sprintf(
+				"This endpoint answers the health check, but slowly: 95%% of recent probes "+
+					"took up to %.0f ms (median %.0f ms). For a SaaS endpoint this often means "+
+					"the provider is degraded or the network path from the agent is congested. "+
+					"Check the provider's status page and compare with probes from another region.",
+				p95, snap.LatencyP50Ms,
+			),
+			Value: &v,
+		})
+	}
+
+	return hints
+}
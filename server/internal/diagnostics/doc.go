@@ -0,0 +1,11 @@
+// Package diagnostics derives plain-English health hints from a pipeline
+// snapshot.
+//
+// Compute(snap) returns the Hints for one snapshot: a scrape failure alone
+// when the scrape failed, otherwise findings on data loss, recovery rate,
+// restarts and per-signal drops, followed by source-type specific ones
+// (otelcol, fluentbit, jaeger, ...), or an "all clear" hint.
+//
+// The REST API attaches them to every pipeline for the dashboard, and the
+// alert engine includes them in notifications.
+package diagnostics
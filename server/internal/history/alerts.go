@@ -0,0 +1,220 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/alerts"
+)
+
+// SQLite implements alerts.Store in the same database as the snapshots.
+var _ alerts.Store = (*SQLite)(nil)
+
+// LoadActive implements alerts.Store.
+func (s *SQLite) LoadActive(ctx context.Context) ([]alerts.StoredAlert, map[string]time.Time, error) {
+	rows, err := s.ro.QueryContext(ctx, `SELECT state FROM alert_active`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("history: load alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var active []alerts.StoredAlert
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, nil, fmt.Errorf("history: scan alert: %w", err)
+		}
+		var a alerts.StoredAlert
+		if err := json.Unmarshal([]byte(data), &a); err != nil {
+			return nil, nil, fmt.Errorf("history: decode alert: %w", err)
+		}
+		active = append(active, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("history: load alerts: %w", err)
+	}
+
+	rows, err = s.ro.QueryContext(ctx, `SELECT key, fired_ms FROM alert_fires`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("history: load alert fire times: %w", err)
+	}
+	defer rows.Close()
+
+	fires := make(map[string]time.Time)
+	for rows.Next() {
+		var key string
+		var ms int64
+		if err := rows.Scan(&key, &ms); err != nil {
+			return nil, nil, fmt.Errorf("history: scan alert fire time: %w", err)
+		}
+		fires[key] = time.UnixMilli(ms)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("history: load alert fire times: %w", err)
+	}
+	return active, fires, nil
+}
+
+// SaveActive implements alerts.Store.
+func (s *SQLite) SaveActive(ctx context.Context, a alerts.StoredAlert) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("history: encode alert: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO alert_active (key, state) VALUES (?, ?)
+		 ON CONFLICT (key) DO UPDATE SET state = excluded.state`,
+		a.Key, string(data))
+	if err != nil {
+		return fmt.Errorf("history: save alert: %w", err)
+	}
+	return nil
+}
+
+// DeleteActive implements alerts.Store.
+func (s *SQLite) DeleteActive(ctx context.Context, key string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM alert_active WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("history: delete alert: %w", err)
+	}
+	return nil
+}
+
+// SaveFire implements alerts.Store.
+func (s *SQLite) SaveFire(ctx context.Context, key string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO alert_fires (key, fired_ms) VALUES (?, ?)
+		 ON CONFLICT (key) DO UPDATE SET fired_ms = excluded.fired_ms`,
+		key, at.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("history: save alert fire time: %w", err)
+	}
+	return nil
+}
+
+// AppendHistory implements alerts.Store.
+func (s *SQLite) AppendHistory(ctx context.Context, a *alerts.Alert) error {
+	if a.ResolvedAt == nil {
+		return fmt.Errorf("history: alert %s is not resolved", a.ID)
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("history: encode alert: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO alert_history (rule_name, source_id, severity, active_ms, resolved_ms, alert)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		a.RuleName, a.SourceID, a.Severity, a.ActiveAt.UnixMilli(), a.ResolvedAt.UnixMilli(), string(data))
+	if err != nil {
+		return fmt.Errorf("history: insert alert: %w", err)
+	}
+	return nil
+}
+
+// QueryHistory implements alerts.Store.
+func (s *SQLite) QueryHistory(ctx context.Context, q alerts.HistoryQuery) ([]*alerts.Alert, int, error) {
+	var where []string
+	var args []any
+	for _, f := range []struct {
+		col, val string
+	}{
+		{"rule_name", q.Rule},
+		{"source_id", q.SourceID},
+		{"severity", q.Severity},
+	} {
+		if f.val != "" {
+			where = append(where, f.col+" = ?")
+			args = append(args, f.val)
+		}
+	}
+	if !q.To.IsZero() {
+		where = append(where, "active_ms < ?")
+		args = append(args, q.To.UnixMilli())
+	}
+	if !q.From.IsZero() {
+		where = append(where, "resolved_ms >= ?")
+		args = append(args, q.From.UnixMilli())
+	}
+	cond := ""
+	if len(where) > 0 {
+		cond = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.ro.QueryRowContext(ctx, `SELECT COUNT(*) FROM alert_history`+cond, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("history: count alerts: %w", err)
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1 // SQLite: no limit
+	}
+	rows, err := s.ro.QueryContext(ctx,
+		`SELECT alert FROM alert_history`+cond+` ORDER BY resolved_ms DESC, rowid DESC LIMIT ? OFFSET ?`,
+		append(args, limit, q.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("history: query alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*alerts.Alert
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, 0, fmt.Errorf("history: scan alert: %w", err)
+		}
+		a := &alerts.Alert{}
+		if err := json.Unmarshal([]byte(data), a); err != nil {
+			return nil, 0, fmt.Errorf("history: decode alert: %w", err)
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("history: query alerts: %w", err)
+	}
+	return out, total, nil
+}
+
+// LoadSilences implements alerts.Store.
+func (s *SQLite) LoadSilences(ctx context.Context) ([]alerts.Silence, error) {
+	rows, err := s.ro.QueryContext(ctx, `SELECT silence FROM alert_silences`)
+	if err != nil {
+		return nil, fmt.Errorf("history: load silences: %w", err)
+	}
+	defer rows.Close()
+
+	var out []alerts.Silence
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("history: scan silence: %w", err)
+		}
+		var sl alerts.Silence
+		if err := json.Unmarshal([]byte(data), &sl); err != nil {
+			return nil, fmt.Errorf("history: decode silence: %w", err)
+		}
+		out = append(out, sl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("history: load silences: %w", err)
+	}
+	return out, nil
+}
+
+// SaveSilence implements alerts.Store.
+func (s *SQLite) SaveSilence(ctx context.Context, sl alerts.Silence) error {
+	data, err := json.Marshal(sl)
+	if err != nil {
+		return fmt.Errorf("history: encode silence: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO alert_silences (id, ends_ms, silence) VALUES (?, ?, ?)
+		 ON CONFLICT (id) DO UPDATE SET ends_ms = excluded.ends_ms, silence = excluded.silence`,
+		sl.ID, sl.EndsAt.UnixMilli(), string(data))
+	if err != nil {
+		return fmt.Errorf("history: save silence: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/server/internal/alerts"
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+)
+
+func resolvedAlert(rule, source, severity string, active, resolved time.Time) *alerts.Alert {
+	return &alerts.Alert{
+		ID:         rule + ":" + source,
+		RuleName:   rule,
+		SourceID:   source,
+		Severity:   severity,
+		ActiveAt:   active,
+		FiredAt:    active,
+		ResolvedAt: &resolved,
+		State:      alerts.StateResolved,
+	}
+}
+
+func TestSQLite_AlertState_RoundTrip(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	a := alerts.StoredAlert{
+		Key:      "drops:otel",
+		Alert:    alerts.Alert{ID: "drops:otel:1", RuleName: "drops", SourceID: "otel", State: alerts.StateFiring, FiredAt: base},
+		Labels:   map[string]string{"source_id": "otel", "cluster": "prod"},
+		Notified: true,
+	}
+	if err := db.SaveActive(ctx, a); err != nil {
+		t.Fatalf("SaveActive: %v", err)
+	}
+	a.Alert.Note = "on it"
+	if err := db.SaveActive(ctx, a); err != nil { // replaces
+		t.Fatalf("SaveActive: %v", err)
+	}
+	if err := db.SaveActive(ctx, alerts.StoredAlert{Key: "gone:otel"}); err != nil {
+		t.Fatalf("SaveActive: %v", err)
+	}
+	if err := db.DeleteActive(ctx, "gone:otel"); err != nil {
+		t.Fatalf("DeleteActive: %v", err)
+	}
+	if err := db.SaveFire(ctx, "drops:otel", base); err != nil {
+		t.Fatalf("SaveFire: %v", err)
+	}
+
+	active, fires, err := db.LoadActive(ctx)
+	if err != nil {
+		t.Fatalf("LoadActive: %v", err)
+	}
+	if len(active) != 1 || active[0].Alert.Note != "on it" || !active[0].Notified || active[0].Labels["cluster"] != "prod" {
+		t.Errorf("active: got %+v", active)
+	}
+	if !active[0].Alert.FiredAt.Equal(base) {
+		t.Errorf("FiredAt: got %v, want %v", active[0].Alert.FiredAt, base)
+	}
+	if !fires["drops:otel"].Equal(base) || len(fires) != 1 {
+		t.Errorf("fires: got %v", fires)
+	}
+}
+
+func TestSQLite_QueryHistory(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	for _, a := range []*alerts.Alert{
+		resolvedAlert("drops", "otel", "critical", base, base.Add(10*time.Minute)),
+		resolvedAlert("drops", "loki", "warning", base.Add(time.Hour), base.Add(2*time.Hour)),
+		resolvedAlert("certs", "otel", "warning", base.Add(3*time.Hour), base.Add(4*time.Hour)),
+	} {
+		if err := db.AppendHistory(ctx, a); err != nil {
+			t.Fatalf("AppendHistory: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		q     alerts.HistoryQuery
+		want  []string // alert IDs, in order
+		total int
+	}{
+		{"all, newest first", alerts.HistoryQuery{}, []string{"certs:otel", "drops:loki", "drops:otel"}, 3},
+		{"rule", alerts.HistoryQuery{Rule: "drops"}, []string{"drops:loki", "drops:otel"}, 2},
+		{"source and severity", alerts.HistoryQuery{SourceID: "otel", Severity: "warning"}, []string{"certs:otel"}, 1},
+		{"active during range", alerts.HistoryQuery{From: base.Add(90 * time.Minute), To: base.Add(3 * time.Hour)}, []string{"drops:loki"}, 1},
+		{"paged", alerts.HistoryQuery{Limit: 1, Offset: 1}, []string{"drops:loki"}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := db.QueryHistory(ctx, tt.q)
+			if err != nil {
+				t.Fatalf("QueryHistory: %v", err)
+			}
+			var ids []string
+			for _, a := range got {
+				ids = append(ids, a.ID)
+			}
+			if total != tt.total || len(ids) != len(tt.want) {
+				t.Fatalf("got %v (total %d), want %v (total %d)", ids, total, tt.want, tt.total)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", ids, tt.want)
+					break
+				}
+			}
+		})
+	}
+
+	// Snapshot retention leaves the audit trail alone; alert retention
+	// drops resolved alerts.
+	if _, err := db.DeleteBefore(ctx, base.Add(3*time.Hour)); err != nil {
+		t.Fatalf("DeleteBefore: %v", err)
+	}
+	if _, total, _ := db.QueryHistory(ctx, alerts.HistoryQuery{}); total != 3 {
+		t.Errorf("after DeleteBefore: %d alerts, want 3", total)
+	}
+	if _, err := db.DeleteAlertsBefore(ctx, base.Add(3*time.Hour)); err != nil {
+		t.Fatalf("DeleteAlertsBefore: %v", err)
+	}
+	if _, total, _ := db.QueryHistory(ctx, alerts.HistoryQuery{}); total != 1 {
+		t.Errorf("after DeleteAlertsBefore: %d alerts, want 1", total)
+	}
+}
+
+// TestEngine_RestoresAcrossRestart runs an engine against a database, then
+// starts a second one on the same file as a restarted server would.
+func TestEngine_RestoresAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	ctx := context.Background()
+	cfg := config.AlertsConfig{Rules: []config.AlertRule{
+		{Name: "drops", Condition: "drop_pct > 10"},
+		{Name: "degraded", Condition: "drop_pct > 5"},
+		{Name: "removed", Condition: "drop_pct > 1"},
+	}}
+	bad := &pb.PipelineSnapshot{SourceId: "otel", DropPct: 20}
+
+	db, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	e := alerts.New(cfg)
+	if err := e.SetStore(ctx, db); err != nil {
+		t.Fatalf("SetStore: %v", err)
+	}
+	e.Evaluate(bad)
+	before := make(map[string]string)
+	for _, a := range e.Active() {
+		before[a.RuleName] = a.ID
+	}
+	if _, err := e.Ack(before["drops"], "alice", "on it"); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	e.Evaluate(&pb.PipelineSnapshot{SourceId: "otel", DropPct: 8}) // resolves drops
+	silence, err := e.AddSilence(alerts.Silence{Rule: "drops", EndsAt: time.Now().Add(time.Hour), Comment: "maintenance"})
+	if err != nil {
+		t.Fatalf("AddSilence: %v", err)
+	}
+	expired, _ := e.AddSilence(alerts.Silence{Rule: "degraded", EndsAt: time.Now().Add(time.Hour), Comment: "oops"})
+	if _, err := e.ExpireSilence(expired.ID); err != nil {
+		t.Fatalf("ExpireSilence: %v", err)
+	}
+	db.Close()
+
+	db, err = OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	cfg.Rules = cfg.Rules[:2] // "removed" is no longer configured
+	e = alerts.New(cfg)
+	if err := e.SetStore(ctx, db); err != nil {
+		t.Fatalf("SetStore after restart: %v", err)
+	}
+
+	// degraded is still firing with its ID: within the cooldown it does not
+	// re-fire. drops is listed as recently resolved, and the firing alert of
+	// the removed rule is gone.
+	e.Evaluate(bad)
+	got := make(map[string]*alerts.Alert)
+	for _, a := range e.Active() {
+		got[a.RuleName] = a
+	}
+	if a := got["degraded"]; a == nil || a.State != alerts.StateFiring || a.ID != before["degraded"] {
+		t.Errorf("degraded after restart: got %+v, want firing with ID %s", a, before["degraded"])
+	}
+	if a := got["drops"]; a == nil || a.State != alerts.StateResolved || a.AcknowledgedBy != "alice" {
+		t.Errorf("drops after restart: got %+v, want resolved, acknowledged by alice", a)
+	}
+	if a := got["removed"]; a != nil {
+		t.Errorf("alert of removed rule restored: %+v", a)
+	}
+
+	states := make(map[string]string)
+	for _, sl := range e.Silences() {
+		states[sl.ID] = sl.State
+	}
+	if len(states) != 2 || states[silence.ID] != alerts.SilenceActive || states[expired.ID] != alerts.SilenceExpired {
+		t.Errorf("silences after restart: got %v, want %s active and %s expired", states, silence.ID, expired.ID)
+	}
+
+	hist, total, err := e.History(ctx, alerts.HistoryQuery{Rule: "drops"})
+	if err != nil || total != 1 || hist[0].ID != before["drops"] {
+		t.Errorf("History: got %+v (total %d, err %v)", hist, total, err)
+	}
+}
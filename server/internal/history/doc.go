@@ -0,0 +1,19 @@
+// Package history persists every PipelineSnapshot the server receives so
+// past pipeline health can be queried after the in-memory store has moved
+// on (package store keeps only the latest snapshot per source).
+//
+// Store is the backend interface: Write appends a Record (snapshot plus the
+// time it was taken and received), Query returns one source's records in a
+// range of snapshot time, oldest first, and DeleteBefore drops expired rows.
+// SQLite, opened with OpenSQLite(path), is the only backend; rows hold the
+// proto-encoded snapshot indexed by (source_id, ts_ms).
+//
+// SQLite also implements alerts.Store: pending and firing alerts, last fire
+// times for cooldowns, silences, and resolved alerts are kept in tables of
+// the same database so the alert engine can resume after a restart.
+//
+// RunRetention(ctx, st, retention) is the background sweeper that deletes
+// records (and stale cooldown timers) older than retention. The alert audit
+// trail — resolved alerts and ended silences — is kept forever unless
+// RunAlertRetention is started with storage.alert_retention.
+package history
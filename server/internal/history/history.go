@@ -0,0 +1,91 @@
+package history
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+)
+
+// Record is a snapshot together with when it was taken and received.
+type Record struct {
+	Snapshot *pb.PipelineSnapshot
+
+	// Timestamp is when the agent took the snapshot. Records are indexed and
+	// queried by it, so snapshots an agent buffered through an outage land
+	// where they belong rather than at the time they were replayed.
+	Timestamp time.Time
+
+	// ReceivedAt is when the server received the snapshot.
+	ReceivedAt time.Time
+}
+
+// Store persists every received snapshot for historical queries.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Write appends one record.
+	Write(ctx context.Context, rec Record) error
+
+	// Query returns the records for sourceID taken in [from, to), oldest
+	// first.
+	Query(ctx context.Context, sourceID string, from, to time.Time) ([]Record, error)
+
+	// DeleteBefore removes records taken before cutoff and returns the
+	// number removed.
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+
+	// Close releases the underlying resources.
+	Close() error
+}
+
+// RunRetention deletes records older than retention from st until ctx is
+// cancelled. It sweeps once immediately, then at retention/24, clamped to
+// [1 minute, 1 hour], so expired rows never linger for long.
+func RunRetention(ctx context.Context, st Store, retention time.Duration) {
+	runSweeps(ctx, retention, "expired snapshots", st.DeleteBefore)
+}
+
+// RunAlertRetention deletes the alerts resolved and silences ended more than
+// retention ago from db until ctx is cancelled, sweeping like RunRetention.
+func RunAlertRetention(ctx context.Context, db *SQLite, retention time.Duration) {
+	runSweeps(ctx, retention, "expired alerts", db.DeleteAlertsBefore)
+}
+
+// runSweeps calls del with the retention cutoff once immediately, then at
+// retention/24 clamped to [1 minute, 1 hour], until ctx is cancelled. what
+// names the deleted rows in the log.
+func runSweeps(ctx context.Context, retention time.Duration, what string, del func(context.Context, time.Time) (int64, error)) {
+	interval := retention / 24
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	if interval > time.Hour {
+		interval = time.Hour
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	sweep(ctx, what, del, time.Now().Add(-retention))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			sweep(ctx, what, del, now.Add(-retention))
+		}
+	}
+}
+
+func sweep(ctx context.Context, what string, del func(context.Context, time.Time) (int64, error), cutoff time.Time) {
+	n, err := del(ctx, cutoff)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("history: retention sweep failed", "rows", what, "err", err)
+		}
+		return
+	}
+	if n > 0 {
+		slog.Debug("history: "+what+" deleted", "rows", n, "cutoff", cutoff)
+	}
+}
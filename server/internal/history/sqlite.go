@@ -0,0 +1,179 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS snapshots (
+	source_id   TEXT    NOT NULL,
+	ts_ms       INTEGER NOT NULL, -- snapshot time, unix milliseconds
+	received_ms INTEGER NOT NULL, -- server receive time, unix milliseconds
+	snapshot    BLOB    NOT NULL  -- proto-encoded PipelineSnapshot
+);
+CREATE INDEX IF NOT EXISTS snapshots_source_ts ON snapshots (source_id, ts_ms);
+CREATE INDEX IF NOT EXISTS snapshots_ts ON snapshots (ts_ms);
+
+CREATE TABLE IF NOT EXISTS alert_active (
+	key   TEXT PRIMARY KEY,      -- "ruleName:sourceID"
+	state TEXT NOT NULL          -- JSON-encoded alerts.StoredAlert
+);
+CREATE TABLE IF NOT EXISTS alert_fires (
+	key      TEXT PRIMARY KEY,
+	fired_ms INTEGER NOT NULL    -- last fire time, unix milliseconds
+);
+CREATE TABLE IF NOT EXISTS alert_history (
+	rule_name   TEXT    NOT NULL,
+	source_id   TEXT    NOT NULL,
+	severity    TEXT    NOT NULL,
+	active_ms   INTEGER NOT NULL,
+	resolved_ms INTEGER NOT NULL,
+	alert       TEXT    NOT NULL -- JSON-encoded alerts.Alert
+);
+CREATE INDEX IF NOT EXISTS alert_history_resolved ON alert_history (resolved_ms);
+CREATE TABLE IF NOT EXISTS alert_silences (
+	id      TEXT PRIMARY KEY,
+	ends_ms INTEGER NOT NULL,    -- end time, unix milliseconds
+	silence TEXT    NOT NULL     -- JSON-encoded alerts.Silence
+);
+`
+
+// maxReaders bounds the read pool: concurrent history and alert queries.
+const maxReaders = 4
+
+// SQLite is a Store backed by a single SQLite database file.
+type SQLite struct {
+	db *sql.DB // writes; one connection
+	ro *sql.DB // queries; a pool of read-only connections
+}
+
+// OpenSQLite opens (creating if needed) the SQLite database at path and
+// applies the schema. The database runs in WAL mode, and queries use their
+// own pool of connections, so a slow query does not block the receiver's
+// writes.
+func OpenSQLite(path string) (*SQLite, error) {
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() +
+		"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=synchronous(NORMAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("history: open %q: %w", path, err)
+	}
+	// SQLite allows one writer at a time; a single connection serialises
+	// writes in Go instead of surfacing SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("history: apply schema to %q: %w", path, err)
+	}
+
+	// Readers see the last committed transaction and never wait for the
+	// writer in WAL mode.
+	ro, err := sql.Open("sqlite", dsn+"&_pragma=query_only(1)")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("history: open %q: %w", path, err)
+	}
+	ro.SetMaxOpenConns(maxReaders)
+	return &SQLite{db: db, ro: ro}, nil
+}
+
+// Write implements Store.
+func (s *SQLite) Write(ctx context.Context, rec Record) error {
+	data, err := proto.Marshal(rec.Snapshot)
+	if err != nil {
+		return fmt.Errorf("history: encode snapshot: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO snapshots (source_id, ts_ms, received_ms, snapshot) VALUES (?, ?, ?, ?)`,
+		rec.Snapshot.SourceId, rec.Timestamp.UnixMilli(), rec.ReceivedAt.UnixMilli(), data)
+	if err != nil {
+		return fmt.Errorf("history: insert: %w", err)
+	}
+	return nil
+}
+
+// Query implements Store.
+func (s *SQLite) Query(ctx context.Context, sourceID string, from, to time.Time) ([]Record, error) {
+	rows, err := s.ro.QueryContext(ctx,
+		`SELECT ts_ms, received_ms, snapshot FROM snapshots
+		 WHERE source_id = ? AND ts_ms >= ? AND ts_ms < ?
+		 ORDER BY ts_ms`,
+		sourceID, from.UnixMilli(), to.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("history: query: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Record
+	for rows.Next() {
+		var tsMS, receivedMS int64
+		var data []byte
+		if err := rows.Scan(&tsMS, &receivedMS, &data); err != nil {
+			return nil, fmt.Errorf("history: scan: %w", err)
+		}
+		snap := &pb.PipelineSnapshot{}
+		if err := proto.Unmarshal(data, snap); err != nil {
+			return nil, fmt.Errorf("history: decode snapshot: %w", err)
+		}
+		out = append(out, Record{
+			Snapshot:   snap,
+			Timestamp:  time.UnixMilli(tsMS),
+			ReceivedAt: time.UnixMilli(receivedMS),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("history: query: %w", err)
+	}
+	return out, nil
+}
+
+// DeleteBefore implements Store. Cooldown timers last set before cutoff are
+// deleted too; the count is of all rows removed. The alert audit trail has
+// its own retention: see DeleteAlertsBefore.
+func (s *SQLite) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return s.deleteBefore(ctx, cutoff,
+		`DELETE FROM snapshots WHERE ts_ms < ?`,
+		`DELETE FROM alert_fires WHERE fired_ms < ?`,
+	)
+}
+
+// DeleteAlertsBefore removes the alerts resolved and the silences ended
+// before cutoff, and returns the number removed.
+func (s *SQLite) DeleteAlertsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return s.deleteBefore(ctx, cutoff,
+		`DELETE FROM alert_history WHERE resolved_ms < ?`,
+		`DELETE FROM alert_silences WHERE ends_ms < ?`,
+	)
+}
+
+// deleteBefore runs each query with cutoff in unix milliseconds and returns
+// the total number of rows removed.
+func (s *SQLite) deleteBefore(ctx context.Context, cutoff time.Time, queries ...string) (int64, error) {
+	var total int64
+	for _, q := range queries {
+		res, err := s.db.ExecContext(ctx, q, cutoff.UnixMilli())
+		if err != nil {
+			return total, fmt.Errorf("history: delete expired: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		total += n
+	}
+	return total, nil
+}
+
+// Close implements Store.
+func (s *SQLite) Close() error { return errors.Join(s.ro.Close(), s.db.Close()) }
+
+// Ping checks that the database is reachable.
+func (s *SQLite) Ping(ctx context.Context) error { return s.db.PingContext(ctx) }
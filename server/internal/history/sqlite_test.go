@@ -0,0 +1,172 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+)
+
+func openTestDB(t *testing.T) *SQLite {
+	t.Helper()
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func write(t *testing.T, db *SQLite, id string, dropPct float64, at time.Time) {
+	t.Helper()
+	rec := Record{
+		Snapshot:   &pb.PipelineSnapshot{SourceId: id, DropPct: dropPct},
+		Timestamp:  at,
+		ReceivedAt: at.Add(time.Hour), // replayed late
+	}
+	if err := db.Write(context.Background(), rec); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+}
+
+var base = time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+
+func TestSQLite_WriteQuery_RoundTrip(t *testing.T) {
+	db := openTestDB(t)
+	write(t, db, "otel", 2, base.Add(time.Minute))
+	write(t, db, "otel", 1, base)
+	write(t, db, "prom", 9, base)
+
+	recs, err := db.Query(context.Background(), "otel", base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("records: got %d, want 2", len(recs))
+	}
+	// Oldest first, regardless of insert order.
+	if recs[0].Snapshot.DropPct != 1 || recs[1].Snapshot.DropPct != 2 {
+		t.Errorf("order: got drop_pct %v, %v, want 1, 2",
+			recs[0].Snapshot.DropPct, recs[1].Snapshot.DropPct)
+	}
+	if !recs[0].Timestamp.Equal(base) || !recs[0].ReceivedAt.Equal(base.Add(time.Hour)) {
+		t.Errorf("times: got %v received %v, want %v received an hour later",
+			recs[0].Timestamp, recs[0].ReceivedAt, base)
+	}
+	if recs[0].Snapshot.SourceId != "otel" {
+		t.Errorf("SourceId: got %q, want otel", recs[0].Snapshot.SourceId)
+	}
+}
+
+func TestSQLite_Query_HalfOpenRange(t *testing.T) {
+	db := openTestDB(t)
+	write(t, db, "otel", 1, base)
+	write(t, db, "otel", 2, base.Add(time.Minute))
+
+	recs, err := db.Query(context.Background(), "otel", base, base.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(recs) != 1 || recs[0].Snapshot.DropPct != 1 {
+		t.Errorf("records: got %d, want only the one at from", len(recs))
+	}
+}
+
+func TestSQLite_DeleteBefore(t *testing.T) {
+	db := openTestDB(t)
+	write(t, db, "otel", 1, base.Add(-2*time.Hour))
+	write(t, db, "prom", 1, base.Add(-2*time.Hour))
+	write(t, db, "otel", 2, base)
+
+	n, err := db.DeleteBefore(context.Background(), base.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteBefore: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("deleted: got %d, want 2", n)
+	}
+	recs, _ := db.Query(context.Background(), "otel", base.Add(-24*time.Hour), base.Add(time.Hour))
+	if len(recs) != 1 || recs[0].Snapshot.DropPct != 2 {
+		t.Errorf("remaining: got %d records, want the recent one", len(recs))
+	}
+}
+
+func TestSQLite_Reopen_KeepsRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	db, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	write(t, db, "otel", 1, base)
+	db.Close()
+
+	db, err = OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	recs, err := db.Query(context.Background(), "otel", base, base.Add(time.Second))
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(recs) != 1 {
+		t.Errorf("records after reopen: got %d, want 1", len(recs))
+	}
+}
+
+// TestSQLite_QueryDoesNotBlockWrites holds a query open, as a slow history
+// scan would, while the receiver writes.
+func TestSQLite_QueryDoesNotBlockWrites(t *testing.T) {
+	db := openTestDB(t)
+	write(t, db, "otel", 1, base)
+	write(t, db, "otel", 2, base.Add(time.Second))
+
+	rows, err := db.ro.Query(`SELECT snapshot FROM snapshots`)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal("no rows")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	rec := Record{Snapshot: &pb.PipelineSnapshot{SourceId: "otel"}, Timestamp: base, ReceivedAt: base}
+	if err := db.Write(ctx, rec); err != nil {
+		t.Fatalf("Write during a query: %v", err)
+	}
+}
+
+func TestRunRetention_SweepsOnStart(t *testing.T) {
+	db := openTestDB(t)
+	now := time.Now()
+	write(t, db, "otel", 1, now.Add(-48*time.Hour))
+	write(t, db, "otel", 2, now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		RunRetention(ctx, db, 24*time.Hour)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		recs, err := db.Query(context.Background(), "otel", now.Add(-72*time.Hour), now.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		if len(recs) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expired record not swept: %d records remain", len(recs))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+}
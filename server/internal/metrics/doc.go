@@ -0,0 +1,33 @@
+// Package metrics exposes obsidianstack-server state in the Prometheus
+// exposition format.
+//
+// PipelineCollector renders every live snapshot in the store as gauges
+// labelled {source_id, source_type, cluster, namespace}:
+//
+//	obsidian_pipeline_state{state}                    1 for the current state
+//	obsidian_pipeline_strength_score
+//	obsidian_pipeline_drop_pct
+//	obsidian_pipeline_recovery_rate_pct
+//	obsidian_pipeline_throughput_per_minute
+//	obsidian_pipeline_export_latency_seconds{percentile} p50 | p95 | p99
+//	obsidian_pipeline_uptime_pct
+//	obsidian_pipeline_scrape_error
+//	obsidian_pipeline_last_seen_timestamp_seconds
+//	obsidian_pipeline_signal_{received,dropped}_per_minute{signal}
+//	obsidian_pipeline_signal_drop_pct{signal}
+//	obsidian_pipeline_cert_days_left{endpoint}
+//	obsidian_pipeline_cert_status{endpoint,status}    1 for the current status
+//	obsidian_pipeline_extra{key}                      one series per extra entry
+//	obsidian_pipeline_alerts_firing
+//
+// plus obsidian_alerts_firing{severity} across all pipelines. The store is
+// read on every scrape, so sources past the snapshot TTL drop out.
+//
+// Handler(collectors...) serves collectors from a dedicated registry; the
+// server mounts it at /metrics on the HTTP port.
+//
+// Internal returns the server's self-observability gauges — store size and
+// WebSocket client count — which the server registers, along with the
+// receiver and alerts collectors, on the admin port rather than the HTTP
+// port.
+package metrics
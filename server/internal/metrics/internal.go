@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/obsidianstack/obsidianstack/server/internal/store"
+	"github.com/obsidianstack/obsidianstack/server/internal/ws"
+)
+
+// Internal returns gauges describing the server itself rather than the
+// pipelines it monitors: the number of live snapshots in st and of WebSocket
+// clients connected to hub. They are read on every scrape.
+func Internal(st *store.Store, hub *ws.Hub) []prometheus.Collector {
+	return []prometheus.Collector{
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "obsidian_server_store_snapshots",
+			Help: "Pipeline snapshots held in the in-memory store.",
+		}, func() float64 { return float64(st.Count()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "obsidian_server_ws_clients",
+			Help: "WebSocket clients connected to the live stream.",
+		}, func() float64 { return float64(hub.Count()) }),
+	}
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/server/internal/store"
+	"github.com/obsidianstack/obsidianstack/server/internal/ws"
+)
+
+func TestInternal_Gauges(t *testing.T) {
+	st := store.New(5 * time.Minute)
+	cs := Internal(st, ws.New(st, time.Second))
+	storeSize, wsClients := cs[0], cs[1]
+
+	if got := testutil.ToFloat64(storeSize); got != 0 {
+		t.Errorf("store size before Put: got %v, want 0", got)
+	}
+	st.Put(&pb.PipelineSnapshot{SourceId: "a"})
+	st.Put(&pb.PipelineSnapshot{SourceId: "b"})
+	if got := testutil.ToFloat64(storeSize); got != 2 {
+		t.Errorf("store size: got %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(wsClients); got != 0 {
+		t.Errorf("ws clients: got %v, want 0", got)
+	}
+}
@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/server/internal/alerts"
+	"github.com/obsidianstack/obsidianstack/server/internal/store"
+)
+
+// sourceLabels identify the pipeline on every per-source gauge.
+var sourceLabels = []string{"source_id", "source_type", "cluster", "namespace"}
+
+func withSource(extra ...string) []string {
+	return append(append([]string{}, sourceLabels...), extra...)
+}
+
+// Enumerations rendered as one 0/1 series per value, so a state change never
+// leaves a stale series behind.
+var (
+	pipelineStates = []string{"healthy", "degraded", "critical", "unknown"}
+	certStatuses   = []string{"valid", "expiring", "expired", "unreachable"}
+	severities     = []string{"critical", "warning", "info"}
+)
+
+var (
+	descState = prometheus.NewDesc("obsidian_pipeline_state",
+		"Pipeline health state: 1 for the current state, 0 for the others.",
+		withSource("state"), nil)
+	descScore = prometheus.NewDesc("obsidian_pipeline_strength_score",
+		"Composite pipeline health score (0-100).", sourceLabels, nil)
+	descDropPct = prometheus.NewDesc("obsidian_pipeline_drop_pct",
+		"Percentage of data dropped (0-100).", sourceLabels, nil)
+	descRecovery = prometheus.NewDesc("obsidian_pipeline_recovery_rate_pct",
+		"Percentage of dropped data that recovered (0-100).", sourceLabels, nil)
+	descThroughput = prometheus.NewDesc("obsidian_pipeline_throughput_per_minute",
+		"Events, samples or lines processed per minute.", sourceLabels, nil)
+	descLatency = prometheus.NewDesc("obsidian_pipeline_export_latency_seconds",
+		"Export latency percentiles (p50, p95, p99).", withSource("percentile"), nil)
+	descUptime = prometheus.NewDesc("obsidian_pipeline_uptime_pct",
+		"Share of the agent's last 20 scrapes of the source that succeeded, as a percentage (0-100).", sourceLabels, nil)
+	descScrapeError = prometheus.NewDesc("obsidian_pipeline_scrape_error",
+		"1 if the agent's last scrape of the source failed, else 0.", sourceLabels, nil)
+	descLastSeen = prometheus.NewDesc("obsidian_pipeline_last_seen_timestamp_seconds",
+		"Unix time the server last received a snapshot for the source.", sourceLabels, nil)
+
+	descSigReceived = prometheus.NewDesc("obsidian_pipeline_signal_received_per_minute",
+		"Items received per minute, by signal type.", withSource("signal"), nil)
+	descSigDropped = prometheus.NewDesc("obsidian_pipeline_signal_dropped_per_minute",
+		"Items dropped per minute, by signal type.", withSource("signal"), nil)
+	descSigDropPct = prometheus.NewDesc("obsidian_pipeline_signal_drop_pct",
+		"Percentage of items dropped, by signal type (0-100).", withSource("signal"), nil)
+
+	descCertDays = prometheus.NewDesc("obsidian_pipeline_cert_days_left",
+		"Days until the endpoint's TLS certificate expires.", withSource("endpoint"), nil)
+	descCertStatus = prometheus.NewDesc("obsidian_pipeline_cert_status",
+		"Endpoint certificate status: 1 for the current status, 0 for the others.",
+		withSource("endpoint", "status"), nil)
+
+	descExtra = prometheus.NewDesc("obsidian_pipeline_extra",
+		"Source-type specific values reported in the snapshot's extra map.",
+		withSource("key"), nil)
+
+	descPipelineAlerts = prometheus.NewDesc("obsidian_pipeline_alerts_firing",
+		"Number of firing alerts for the pipeline.", sourceLabels, nil)
+	descAlerts = prometheus.NewDesc("obsidian_alerts_firing",
+		"Number of firing alerts across all pipelines, by severity.", []string{"severity"}, nil)
+)
+
+// PipelineCollector renders the live snapshots in a store.Store, and the
+// alerts firing for them, as Prometheus gauges. It reads the store on every
+// scrape, so sources dropped by store.List past the TTL disappear from the
+// output.
+type PipelineCollector struct {
+	store  *store.Store
+	engine *alerts.Engine
+}
+
+// NewPipelineCollector creates a collector over st and engine.
+func NewPipelineCollector(st *store.Store, engine *alerts.Engine) *PipelineCollector {
+	return &PipelineCollector{store: st, engine: engine}
+}
+
+// Describe implements prometheus.Collector.
+func (c *PipelineCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		descState, descScore, descDropPct, descRecovery, descThroughput,
+		descLatency, descUptime, descScrapeError, descLastSeen,
+		descSigReceived, descSigDropped, descSigDropPct,
+		descCertDays, descCertStatus, descExtra,
+		descPipelineAlerts, descAlerts,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *PipelineCollector) Collect(ch chan<- prometheus.Metric) {
+	firing := make(map[string]int)
+	bySeverity := make(map[string]int)
+	for _, a := range c.engine.Active() {
+		if a.State != alerts.StateFiring {
+			continue
+		}
+		firing[a.SourceID]++
+		bySeverity[a.Severity]++
+	}
+	for _, sev := range severities {
+		ch <- prometheus.MustNewConstMetric(descAlerts, prometheus.GaugeValue, float64(bySeverity[sev]), sev)
+	}
+
+	for _, e := range c.store.List() {
+		snap := e.Snapshot
+		src := []string{snap.SourceId, snap.SourceType, snap.Cluster, snap.Namespace}
+		gauge := func(d *prometheus.Desc, v float64, extra ...string) {
+			ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v, append(src, extra...)...)
+		}
+
+		state := snap.State
+		if state == "" {
+			state = "unknown"
+		}
+		for _, s := range pipelineStates {
+			gauge(descState, boolValue(s == state), s)
+		}
+		gauge(descScore, snap.StrengthScore)
+		gauge(descDropPct, snap.DropPct)
+		gauge(descRecovery, snap.RecoveryRate)
+		gauge(descThroughput, snap.ThroughputPerMin)
+		gauge(descLatency, snap.LatencyP50Ms/1000, "p50")
+		gauge(descLatency, snap.LatencyP95Ms/1000, "p95")
+		gauge(descLatency, snap.LatencyP99Ms/1000, "p99")
+		gauge(descUptime, snap.UptimePct)
+		gauge(descScrapeError, boolValue(snap.ErrorMessage != ""))
+		gauge(descLastSeen, float64(e.UpdatedAt.UnixMilli())/1000)
+		gauge(descPipelineAlerts, float64(firing[snap.SourceId]))
+
+		// Duplicate signal types or cert endpoints would collide on labels
+		// and fail the whole scrape; the first one wins.
+		seen := make(map[string]bool)
+		for _, s := range snap.Signals {
+			if seen["signal:"+s.Type] {
+				continue
+			}
+			seen["signal:"+s.Type] = true
+			gauge(descSigReceived, s.ReceivedPm, s.Type)
+			gauge(descSigDropped, s.DroppedPm, s.Type)
+			gauge(descSigDropPct, s.DropPct, s.Type)
+		}
+		for _, cert := range snap.Certs {
+			if seen["cert:"+cert.Endpoint] {
+				continue
+			}
+			seen["cert:"+cert.Endpoint] = true
+			collectCert(gauge, cert)
+		}
+		for k, v := range snap.Extra {
+			gauge(descExtra, v, k)
+		}
+	}
+}
+
+func collectCert(gauge func(*prometheus.Desc, float64, ...string), cert *pb.CertStatus) {
+	gauge(descCertDays, float64(cert.DaysLeft), cert.Endpoint)
+	for _, s := range certStatuses {
+		gauge(descCertStatus, boolValue(s == cert.Status), cert.Endpoint, s)
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
+	"github.com/obsidianstack/obsidianstack/server/internal/alerts"
+	"github.com/obsidianstack/obsidianstack/server/internal/config"
+	"github.com/obsidianstack/obsidianstack/server/internal/store"
+)
+
+func testSnapshot() *pb.PipelineSnapshot {
+	return &pb.PipelineSnapshot{
+		SourceId:      "otel",
+		SourceType:    "otelcol",
+		Cluster:       "prod",
+		Namespace:     "monitoring",
+		State:         "degraded",
+		DropPct:       12,
+		StrengthScore: 70,
+		LatencyP95Ms:  250,
+		Signals: []*pb.SignalStats{
+			{Type: "logs", ReceivedPm: 600, DroppedPm: 60, DropPct: 10},
+			{Type: "logs", ReceivedPm: 1, DroppedPm: 1, DropPct: 1}, // duplicate, ignored
+		},
+		Certs: []*pb.CertStatus{{Endpoint: "https://otel:8888", Status: "expiring", DaysLeft: 9}},
+		Extra: map[string]float64{"queue_size": 42},
+	}
+}
+
+// scrape serves the collector over HTTP and returns the exposition text.
+func scrape(t *testing.T, c *PipelineCollector) string {
+	t.Helper()
+	srv := httptest.NewServer(Handler(c))
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Fatalf("status: got %d, want 200", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestPipelineCollector_Gauges(t *testing.T) {
+	st := store.New(5 * time.Minute)
+	st.Put(testSnapshot())
+	out := scrape(t, NewPipelineCollector(st, alerts.New(config.AlertsConfig{})))
+
+	const src = `cluster="prod",namespace="monitoring",source_id="otel",source_type="otelcol"`
+	for _, want := range []string{
+		`obsidian_pipeline_strength_score{` + src + `} 70`,
+		`obsidian_pipeline_drop_pct{` + src + `} 12`,
+		`obsidian_pipeline_state{` + src + `,state="degraded"} 1`,
+		`obsidian_pipeline_state{` + src + `,state="healthy"} 0`,
+		`obsidian_pipeline_export_latency_seconds{cluster="prod",namespace="monitoring",percentile="p95",source_id="otel",source_type="otelcol"} 0.25`,
+		`obsidian_pipeline_signal_received_per_minute{cluster="prod",namespace="monitoring",signal="logs",source_id="otel",source_type="otelcol"} 600`,
+		`obsidian_pipeline_signal_drop_pct{cluster="prod",namespace="monitoring",signal="logs",source_id="otel",source_type="otelcol"} 10`,
+		`obsidian_pipeline_cert_days_left{cluster="prod",endpoint="https://otel:8888",namespace="monitoring",source_id="otel",source_type="otelcol"} 9`,
+		`obsidian_pipeline_cert_status{cluster="prod",endpoint="https://otel:8888",namespace="monitoring",source_id="otel",source_type="otelcol",status="expiring"} 1`,
+		`obsidian_pipeline_extra{cluster="prod",key="queue_size",namespace="monitoring",source_id="otel",source_type="otelcol"} 42`,
+		`obsidian_pipeline_alerts_firing{` + src + `} 0`,
+		`obsidian_alerts_firing{severity="critical"} 0`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func TestPipelineCollector_AlertCounts(t *testing.T) {
+	st := store.New(5 * time.Minute)
+	snap := testSnapshot()
+	st.Put(snap)
+	engine := alerts.New(config.AlertsConfig{Rules: []config.AlertRule{
+		{Name: "drops", Condition: "drop_pct > 10", Severity: "critical"},
+		{Name: "score", Condition: "strength_score < 80", Severity: "warning"},
+	}})
+	engine.Evaluate(snap)
+
+	out := scrape(t, NewPipelineCollector(st, engine))
+	for _, want := range []string{
+		`obsidian_alerts_firing{severity="critical"} 1`,
+		`obsidian_alerts_firing{severity="warning"} 1`,
+		`obsidian_alerts_firing{severity="info"} 0`,
+		`obsidian_pipeline_alerts_firing{cluster="prod",namespace="monitoring",source_id="otel",source_type="otelcol"} 2`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func TestPipelineCollector_StaleSourcesDropped(t *testing.T) {
+	st := store.New(5 * time.Minute)
+	st.Put(testSnapshot())
+	c := NewPipelineCollector(st, alerts.New(config.AlertsConfig{}))
+	if n := testutil.CollectAndCount(c, "obsidian_pipeline_strength_score"); n != 1 {
+		t.Fatalf("before eviction: got %d series, want 1", n)
+	}
+
+	st.Evict(time.Now().Add(10 * time.Minute))
+	if n := testutil.CollectAndCount(c, "obsidian_pipeline_strength_score"); n != 0 {
+		t.Errorf("after eviction: got %d series, want 0", n)
+	}
+}
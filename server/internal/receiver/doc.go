@@ -3,8 +3,17 @@
 //
 // Receiver.SendSnapshot validates that source_id is non-empty
 // (codes.InvalidArgument if missing), then calls store.Put to record the
-// snapshot. Authentication is enforced upstream by the gRPC server interceptor
-// (see package auth), so the receiver itself only performs structural validation.
+// snapshot. Receiver.SendSnapshots does the same for each snapshot of a batch
+// and acknowledges them individually — an invalid snapshot is reported in its
+// result without failing the others. Authentication is enforced upstream by
+// the gRPC server interceptor (see package auth), so the receiver itself only
+// performs structural validation.
 //
-// New(st) wires the receiver to the given snapshot store.
+// New(st, engine) wires the receiver to the given snapshot store and alert
+// engine. SetHistory(h) additionally appends every accepted snapshot to a
+// history.Store; write failures are logged and do not reject the snapshot.
+//
+// Request counts and latency per RPC, accepted/rejected snapshots and history
+// write failures are recorded in the Prometheus metrics returned by
+// Collectors (metrics.go).
 package receiver
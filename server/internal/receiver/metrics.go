@@ -0,0 +1,31 @@
+package receiver
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "obsidian_server_receiver_requests_total",
+		Help: "Snapshot RPCs handled, by method and gRPC status code.",
+	}, []string{"method", "code"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "obsidian_server_receiver_request_duration_seconds",
+		Help:    "Time spent handling snapshot RPCs, by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	snapshots = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "obsidian_server_receiver_snapshots_total",
+		Help: "Snapshots received, by result: accepted or rejected.",
+	}, []string{"result"})
+
+	historyWriteErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "obsidian_server_receiver_history_write_errors_total",
+		Help: "Accepted snapshots that could not be written to the history store.",
+	})
+)
+
+// Collectors returns the receiver's internal metrics for registration.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{requests, requestDuration, snapshots, historyWriteErrors}
+}
@@ -2,13 +2,18 @@ package receiver
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
 	"github.com/obsidianstack/obsidianstack/server/internal/alerts"
+	"github.com/obsidianstack/obsidianstack/server/internal/auth"
+	"github.com/obsidianstack/obsidianstack/server/internal/history"
 	"github.com/obsidianstack/obsidianstack/server/internal/store"
 )
 
@@ -16,8 +21,9 @@ import (
 // It validates each incoming PipelineSnapshot and stores it in the state store.
 type Receiver struct {
 	pb.UnimplementedSnapshotServiceServer
-	store  *store.Store
-	engine *alerts.Engine
+	store   *store.Store
+	engine  *alerts.Engine
+	history history.Store // nil when history is disabled
 }
 
 // New creates a Receiver that writes accepted snapshots to st and evaluates
@@ -26,16 +32,86 @@ func New(st *store.Store, engine *alerts.Engine) *Receiver {
 	return &Receiver{store: st, engine: engine}
 }
 
+// SetHistory makes the receiver also append every accepted snapshot to h.
+// Must be called before the receiver starts serving.
+func (r *Receiver) SetHistory(h history.Store) {
+	r.history = h
+}
+
 // SendSnapshot is the unary RPC handler called by obsidianstack-agent instances.
 // It validates the snapshot, stores it, and returns a confirmation.
-// Authentication is enforced by the gRPC server interceptor before this is called.
-func (r *Receiver) SendSnapshot(ctx context.Context, snap *pb.PipelineSnapshot) (*pb.SendResponse, error) {
+// Authentication is enforced by the gRPC server interceptor before this is called;
+// with mtls, a source the agent's certificate is not mapped to returns
+// codes.PermissionDenied.
+func (r *Receiver) SendSnapshot(ctx context.Context, snap *pb.PipelineSnapshot) (resp *pb.SendResponse, err error) {
+	defer observe("SendSnapshot", time.Now(), &err)
+	if aerr := r.accept(ctx, snap); aerr != nil {
+		if errors.Is(aerr, errSourceNotAllowed) {
+			return nil, status.Error(codes.PermissionDenied, aerr.Error())
+		}
+		return nil, status.Error(codes.InvalidArgument, aerr.Error())
+	}
+	return &pb.SendResponse{Ok: true}, nil
+}
+
+// SendSnapshots is the batched RPC handler. Every snapshot is validated and
+// stored independently, in order; results[i] reports the outcome for
+// snapshots[i], so one invalid snapshot does not reject the rest of the batch.
+func (r *Receiver) SendSnapshots(ctx context.Context, batch *pb.SnapshotBatch) (*pb.BatchResponse, error) {
+	defer observe("SendSnapshots", time.Now(), nil)
+	results := make([]*pb.SendResponse, len(batch.Snapshots))
+	for i, snap := range batch.Snapshots {
+		if err := r.accept(ctx, snap); err != nil {
+			results[i] = &pb.SendResponse{Ok: false, Message: err.Error()}
+			continue
+		}
+		results[i] = &pb.SendResponse{Ok: true}
+	}
+	slog.Debug("receiver: batch processed", "snapshots", len(batch.Snapshots))
+	return &pb.BatchResponse{Results: results}, nil
+}
+
+// observe records the count and latency of one RPC started at start. errp,
+// when not nil, points at the RPC's returned error, read when the deferred
+// call runs.
+func observe(method string, start time.Time, errp *error) {
+	var err error
+	if errp != nil {
+		err = *errp
+	}
+	requests.WithLabelValues(method, status.Code(err).String()).Inc()
+	requestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// errSourceNotAllowed rejects a snapshot for a source the caller's client
+// certificate is not mapped to.
+var errSourceNotAllowed = errors.New("source not allowed for this client certificate")
+
+// accept validates snap, checks the caller may report its source, stores it,
+// evaluates alert rules against it and records it in history.
+func (r *Receiver) accept(ctx context.Context, snap *pb.PipelineSnapshot) error {
 	if snap.SourceId == "" {
-		return nil, status.Error(codes.InvalidArgument, "source_id is required")
+		snapshots.WithLabelValues("rejected").Inc()
+		return errors.New("source_id is required")
 	}
+	if !auth.SourceAllowed(ctx, snap.SourceId) {
+		snapshots.WithLabelValues("rejected").Inc()
+		return fmt.Errorf("%w: %q", errSourceNotAllowed, snap.SourceId)
+	}
+	snapshots.WithLabelValues("accepted").Inc()
 
 	r.store.Put(snap)
 	r.engine.Evaluate(snap)
+	if r.history != nil {
+		// A history failure must not reject the snapshot: the live view in
+		// the in-memory store is already up to date.
+		now := time.Now()
+		rec := history.Record{Snapshot: snap, Timestamp: snapshotTime(snap, now), ReceivedAt: now}
+		if err := r.history.Write(ctx, rec); err != nil {
+			historyWriteErrors.Inc()
+			slog.Warn("receiver: history write failed", "source_id", snap.SourceId, "err", err)
+		}
+	}
 
 	slog.Debug("receiver: snapshot stored",
 		"source_id", snap.SourceId,
@@ -43,6 +119,18 @@ func (r *Receiver) SendSnapshot(ctx context.Context, snap *pb.PipelineSnapshot)
 		"state", snap.State,
 		"score", snap.StrengthScore,
 	)
+	return nil
+}
 
-	return &pb.SendResponse{Ok: true}, nil
+// snapshotTime returns when snap was taken: its own timestamp, which predates
+// now for snapshots replayed after an outage, or now when the agent did not
+// set one or its clock runs ahead of the server's.
+func snapshotTime(snap *pb.PipelineSnapshot, now time.Time) time.Time {
+	if snap.TimestampUnix <= 0 {
+		return now
+	}
+	if t := time.Unix(snap.TimestampUnix, 0); t.Before(now) {
+		return t
+	}
+	return now
 }
@@ -2,20 +2,29 @@ package receiver_test
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
 	"net"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	pb "github.com/obsidianstack/obsidianstack/gen/obsidian/v1"
 	"github.com/obsidianstack/obsidianstack/server/internal/alerts"
 	"github.com/obsidianstack/obsidianstack/server/internal/auth"
 	svrconfig "github.com/obsidianstack/obsidianstack/server/internal/config"
+	"github.com/obsidianstack/obsidianstack/server/internal/history"
 	"github.com/obsidianstack/obsidianstack/server/internal/receiver"
 	"github.com/obsidianstack/obsidianstack/server/internal/store"
 )
@@ -189,3 +198,231 @@ func TestSendSnapshot_WithAPIKeyInterceptor_MissingKey_Rejected(t *testing.T) {
 		t.Errorf("code: got %v, want Unauthenticated", code)
 	}
 }
+
+func TestSendSnapshots_AcksEachSnapshot(t *testing.T) {
+	client, st := startServer(t, allowAll)
+
+	resp, err := client.SendSnapshots(context.Background(), &pb.SnapshotBatch{
+		Snapshots: []*pb.PipelineSnapshot{
+			{SourceId: "otel", State: "healthy"},
+			{}, // missing source_id
+			{SourceId: "loki", State: "degraded"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SendSnapshots: %v", err)
+	}
+	if len(resp.Results) != 3 {
+		t.Fatalf("results: got %d, want 3", len(resp.Results))
+	}
+	for i, want := range []bool{true, false, true} {
+		if resp.Results[i].Ok != want {
+			t.Errorf("results[%d].Ok: got %v, want %v", i, resp.Results[i].Ok, want)
+		}
+	}
+	if resp.Results[1].Message == "" {
+		t.Error("results[1].Message: want a reason for the rejection")
+	}
+	if n := st.Count(); n != 2 {
+		t.Errorf("store.Count: got %d, want 2", n)
+	}
+}
+
+func TestSendSnapshots_WithAPIKeyInterceptor_MissingKey_Rejected(t *testing.T) {
+	client, _ := startServer(t, auth.APIKeyInterceptor("apikey", "x-api-key", "secret123"))
+
+	_, err := client.SendSnapshots(context.Background(), &pb.SnapshotBatch{
+		Snapshots: []*pb.PipelineSnapshot{{SourceId: "src"}},
+	})
+	if code := status.Code(err); code != codes.Unauthenticated {
+		t.Errorf("code: got %v, want Unauthenticated", code)
+	}
+}
+
+// asAgent is an interceptor that makes every call look like it came over
+// mTLS from an agent whose certificate CN is cn, then applies
+// auth.MTLSInterceptor with identities.
+func asAgent(cn string, identities []svrconfig.IdentityConfig) grpc.UnaryServerInterceptor {
+	mtls := auth.MTLSInterceptor(identities)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+		ctx = peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{cert}},
+		}}})
+		return mtls(ctx, req, info, handler)
+	}
+}
+
+func TestSendSnapshot_MTLSIdentity_SourceNotAllowed(t *testing.T) {
+	client, st := startServer(t, asAgent("agent-a", []svrconfig.IdentityConfig{
+		{Name: "agent-a", Sources: []string{"otel-*"}},
+	}))
+
+	if _, err := client.SendSnapshot(context.Background(), &pb.PipelineSnapshot{SourceId: "otel-prod"}); err != nil {
+		t.Fatalf("SendSnapshot for mapped source: %v", err)
+	}
+	_, err := client.SendSnapshot(context.Background(), &pb.PipelineSnapshot{SourceId: "loki"})
+	if code := status.Code(err); code != codes.PermissionDenied {
+		t.Errorf("code: got %v, want PermissionDenied", code)
+	}
+	if _, ok := st.Get("loki"); ok {
+		t.Error("store.Get(loki): snapshot for unmapped source was stored")
+	}
+}
+
+func TestSendSnapshots_MTLSIdentity_RejectsPerItem(t *testing.T) {
+	client, st := startServer(t, asAgent("agent-a", []svrconfig.IdentityConfig{
+		{Name: "agent-a", Sources: []string{"otel-*"}},
+	}))
+
+	resp, err := client.SendSnapshots(context.Background(), &pb.SnapshotBatch{
+		Snapshots: []*pb.PipelineSnapshot{{SourceId: "otel-prod"}, {SourceId: "loki"}},
+	})
+	if err != nil {
+		t.Fatalf("SendSnapshots: %v", err)
+	}
+	if !resp.Results[0].Ok || resp.Results[1].Ok {
+		t.Errorf("results: got %v/%v, want true/false", resp.Results[0].Ok, resp.Results[1].Ok)
+	}
+	if n := st.Count(); n != 1 {
+		t.Errorf("store.Count: got %d, want 1", n)
+	}
+}
+
+// fakeHistory is an in-memory history.Store that records writes and can be
+// made to fail.
+type fakeHistory struct {
+	mu   sync.Mutex
+	recs []history.Record
+	err  error
+}
+
+func (f *fakeHistory) Write(_ context.Context, rec history.Record) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.recs = append(f.recs, rec)
+	return nil
+}
+
+func (f *fakeHistory) Query(context.Context, string, time.Time, time.Time) ([]history.Record, error) {
+	return nil, nil
+}
+
+func (f *fakeHistory) DeleteBefore(context.Context, time.Time) (int64, error) { return 0, nil }
+
+func (f *fakeHistory) Close() error { return nil }
+
+func TestSendSnapshot_WritesHistory(t *testing.T) {
+	st := store.New(5 * time.Minute)
+	rec := receiver.New(st, alerts.New(svrconfig.AlertsConfig{}))
+	h := &fakeHistory{}
+	rec.SetHistory(h)
+
+	taken := time.Now().Add(-time.Hour).Truncate(time.Second) // replayed after an outage
+	batch := &pb.SnapshotBatch{Snapshots: []*pb.PipelineSnapshot{
+		{SourceId: "a", TimestampUnix: taken.Unix()}, {}, {SourceId: "b"},
+	}}
+	if _, err := rec.SendSnapshots(context.Background(), batch); err != nil {
+		t.Fatalf("SendSnapshots: %v", err)
+	}
+	if len(h.recs) != 2 {
+		t.Fatalf("history records: got %d, want 2 (invalid snapshot excluded)", len(h.recs))
+	}
+	if h.recs[0].Snapshot.SourceId != "a" || h.recs[1].Snapshot.SourceId != "b" {
+		t.Errorf("history order: got %q, %q", h.recs[0].Snapshot.SourceId, h.recs[1].Snapshot.SourceId)
+	}
+	if a := h.recs[0]; !a.Timestamp.Equal(taken) || a.ReceivedAt.Before(taken.Add(time.Hour)) {
+		t.Errorf("replayed snapshot: timestamp %v received %v, want %v and now", a.Timestamp, a.ReceivedAt, taken)
+	}
+	if b := h.recs[1]; b.ReceivedAt.IsZero() || !b.Timestamp.Equal(b.ReceivedAt) {
+		t.Errorf("snapshot without a timestamp: timestamp %v received %v, want both now", b.Timestamp, b.ReceivedAt)
+	}
+}
+
+func TestSendSnapshot_HistoryFailure_StillAccepted(t *testing.T) {
+	st := store.New(5 * time.Minute)
+	rec := receiver.New(st, alerts.New(svrconfig.AlertsConfig{}))
+	rec.SetHistory(&fakeHistory{err: errors.New("disk full")})
+
+	resp, err := rec.SendSnapshot(context.Background(), &pb.PipelineSnapshot{SourceId: "a"})
+	if err != nil {
+		t.Fatalf("SendSnapshot: %v", err)
+	}
+	if !resp.Ok {
+		t.Error("Ok: got false, want true")
+	}
+	if _, ok := st.Get("a"); !ok {
+		t.Error("snapshot not stored in memory")
+	}
+}
+
+// metricValue returns the value of the receiver counter name whose labels
+// include want, or 0 when no such series exists yet.
+func metricValue(t *testing.T, name string, want map[string]string) float64 {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(receiver.Collectors()...)
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	for _, mf := range mfs {
+		if mf.GetName() != name {
+			continue
+		}
+	series:
+		for _, m := range mf.GetMetric() {
+			labels := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			for k, v := range want {
+				if labels[k] != v {
+					continue series
+				}
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestReceiver_Metrics(t *testing.T) {
+	const (
+		requestsTotal = "obsidian_server_receiver_requests_total"
+		snapsTotal    = "obsidian_server_receiver_snapshots_total"
+		historyErrors = "obsidian_server_receiver_history_write_errors_total"
+	)
+	invalid := map[string]string{"method": "SendSnapshot", "code": "InvalidArgument"}
+	accepted := map[string]string{"result": "accepted"}
+	rejected := map[string]string{"result": "rejected"}
+
+	beforeInvalid := metricValue(t, requestsTotal, invalid)
+	beforeAccepted := metricValue(t, snapsTotal, accepted)
+	beforeRejected := metricValue(t, snapsTotal, rejected)
+	beforeHistory := metricValue(t, historyErrors, nil)
+
+	rec := receiver.New(store.New(5*time.Minute), alerts.New(svrconfig.AlertsConfig{}))
+	rec.SetHistory(&fakeHistory{err: errors.New("disk full")})
+	ctx := context.Background()
+	_, _ = rec.SendSnapshot(ctx, &pb.PipelineSnapshot{SourceId: "a"})
+	_, _ = rec.SendSnapshot(ctx, &pb.PipelineSnapshot{})
+	batch := &pb.SnapshotBatch{Snapshots: []*pb.PipelineSnapshot{{SourceId: "b"}, {}}}
+	_, _ = rec.SendSnapshots(ctx, batch)
+
+	if got := metricValue(t, requestsTotal, invalid) - beforeInvalid; got != 1 {
+		t.Errorf("InvalidArgument SendSnapshot requests: got %v, want 1", got)
+	}
+	if got := metricValue(t, snapsTotal, accepted) - beforeAccepted; got != 2 {
+		t.Errorf("accepted snapshots: got %v, want 2", got)
+	}
+	if got := metricValue(t, snapsTotal, rejected) - beforeRejected; got != 2 {
+		t.Errorf("rejected snapshots: got %v, want 2", got)
+	}
+	if got := metricValue(t, historyErrors, nil) - beforeHistory; got != 2 {
+		t.Errorf("history write errors: got %v, want 2", got)
+	}
+}
@@ -16,6 +16,11 @@
 //	  "data":  { /* same schema as GET /api/v1/snapshot */ }
 //	}
 //
-// The upgrader accepts all origins. Apply CORS restrictions at the reverse
-// proxy level. WebSocket endpoint is mounted at /ws/stream by the server.
+// Hub.Publish sends other events straight away in the same envelope; the
+// server publishes "alert.ack" and "alert.unack" with the alert as data.
+//
+// The upgrader accepts same-origin browsers and clients that send no Origin
+// header; Hub.AllowOrigins adds further origins ("*" for any). The WebSocket
+// endpoint is mounted at /ws/stream by the server, behind the HTTP auth
+// middleware.
 package ws
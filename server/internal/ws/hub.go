@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,17 +31,11 @@ const (
 	sendBufSize = 16
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 4096,
-	// Allow all origins — callers should apply CORS at the reverse-proxy level.
-	CheckOrigin: func(r *http.Request) bool { return true },
-}
-
-// Message is the JSON envelope sent to clients on every broadcast tick.
+// Message is the JSON envelope sent to clients: an api.SnapshotResponse on
+// every broadcast tick, or the payload of an event sent with Publish.
 type Message struct {
-	Event string           `json:"event"`
-	Data  api.SnapshotResponse `json:"data"`
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
 }
 
 // Hub manages WebSocket client connections and broadcasts the current pipeline
@@ -47,6 +43,11 @@ type Message struct {
 type Hub struct {
 	store    *store.Store
 	interval time.Duration
+	upgrader websocket.Upgrader
+
+	// origins are the cross-origin Origin values accepted on upgrade; "*"
+	// accepts any. Same-origin requests are always accepted.
+	origins []string
 
 	mu      sync.RWMutex
 	clients map[*client]struct{}
@@ -60,11 +61,24 @@ type client struct {
 
 // New creates a Hub that reads from st and broadcasts every interval.
 func New(st *store.Store, interval time.Duration) *Hub {
-	return &Hub{
+	h := &Hub{
 		store:    st,
 		interval: interval,
 		clients:  make(map[*client]struct{}),
 	}
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 4096,
+		CheckOrigin:     h.checkOrigin,
+	}
+	return h
+}
+
+// AllowOrigins sets the cross-origin Origin values (scheme://host[:port])
+// accepted on upgrade, replacing any set before; "*" accepts any origin.
+// Must be called before the hub starts serving.
+func (h *Hub) AllowOrigins(origins ...string) {
+	h.origins = origins
 }
 
 // Run starts the broadcast ticker loop. It sends the current snapshot to all
@@ -89,7 +103,7 @@ func (h *Hub) Run(ctx context.Context) {
 // It sends the current snapshot immediately on connect, then continues to
 // receive broadcasts from the ticker loop. Blocks until the connection closes.
 func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		// upgrader has already written the error response.
 		return
@@ -114,6 +128,16 @@ func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	c.readPump() // blocks until connection closes
 }
 
+// Publish sends event with data to all connected clients immediately,
+// without waiting for the next tick.
+func (h *Hub) Publish(event string, data interface{}) {
+	msg, err := json.Marshal(Message{Event: event, Data: data})
+	if err != nil {
+		return
+	}
+	h.sendAll(msg)
+}
+
 // Count returns the number of currently connected clients.
 func (h *Hub) Count() int {
 	h.mu.RLock()
@@ -143,7 +167,10 @@ func (h *Hub) broadcast() {
 	if err != nil {
 		return
 	}
+	h.sendAll(data)
+}
 
+func (h *Hub) sendAll(data []byte) {
 	h.mu.RLock()
 	targets := make([]*client, 0, len(h.clients))
 	for c := range h.clients {
@@ -161,6 +188,22 @@ func (h *Hub) broadcast() {
 	}
 }
 
+// checkOrigin accepts requests without an Origin header (non-browser
+// clients), same-origin requests and the origins set by AllowOrigins.
+func (h *Hub) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, o := range h.origins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
 func (h *Hub) buildMessage() ([]byte, error) {
 	msg := Message{
 		Event: "snapshot",
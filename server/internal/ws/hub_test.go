@@ -267,3 +267,84 @@ func TestHub_NonWebSocketRequest_Returns400(t *testing.T) {
 		t.Errorf("status: got %d, want 400", resp.StatusCode)
 	}
 }
+
+// dialOrigin dials wsURL with the given Origin header and returns the HTTP
+// status of the handshake response.
+func dialOrigin(t *testing.T, wsURL, origin string) int {
+	t.Helper()
+	conn, resp, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Origin": {origin}})
+	if err == nil {
+		conn.Close()
+	}
+	if resp == nil {
+		t.Fatalf("dial with origin %q: %v", origin, err)
+	}
+	return resp.StatusCode
+}
+
+func TestHub_Origin_SameOriginAccepted(t *testing.T) {
+	url, _, _ := startHub(t, newStore())
+	origin := "http" + strings.TrimPrefix(url, "ws")
+	if code := dialOrigin(t, url, origin); code != http.StatusSwitchingProtocols {
+		t.Errorf("same origin: got status %d, want 101", code)
+	}
+}
+
+func TestHub_Origin_CrossOriginRejectedByDefault(t *testing.T) {
+	url, _, _ := startHub(t, newStore())
+	if code := dialOrigin(t, url, "https://evil.example"); code != http.StatusForbidden {
+		t.Errorf("cross origin: got status %d, want 403", code)
+	}
+}
+
+// startHubWithOrigins is startHub for a hub that allows origins.
+func startHubWithOrigins(t *testing.T, origins ...string) string {
+	t.Helper()
+	hub := wsHub.New(newStore(), testInterval)
+	hub.AllowOrigins(origins...)
+	srv := httptest.NewServer(hub)
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestHub_Origin_AllowOrigins(t *testing.T) {
+	url := startHubWithOrigins(t, "https://ui.example")
+	if code := dialOrigin(t, url, "https://ui.example"); code != http.StatusSwitchingProtocols {
+		t.Errorf("allowed origin: got status %d, want 101", code)
+	}
+	if code := dialOrigin(t, url, "https://other.example"); code != http.StatusForbidden {
+		t.Errorf("other origin: got status %d, want 403", code)
+	}
+}
+
+func TestHub_Origin_Wildcard(t *testing.T) {
+	url := startHubWithOrigins(t, "*")
+	if code := dialOrigin(t, url, "https://other.example"); code != http.StatusSwitchingProtocols {
+		t.Errorf("wildcard: got status %d, want 101", code)
+	}
+}
+
+func TestHub_Publish(t *testing.T) {
+	wsURL, hub, _ := startHub(t, newStore())
+	conn := dial(t, wsURL)
+	readMessage(t, conn) // consume initial message
+	time.Sleep(10 * time.Millisecond)
+
+	hub.Publish("alert.ack", map[string]string{"id": "drops:otel:1"})
+
+	// Snapshot ticks may arrive first.
+	for i := 0; i < 10; i++ {
+		var m struct {
+			Event string            `json:"event"`
+			Data  map[string]string `json:"data"`
+		}
+		json.Unmarshal(readMessage(t, conn), &m) //nolint:errcheck
+		if m.Event == "alert.ack" {
+			if m.Data["id"] != "drops:otel:1" {
+				t.Errorf("data: got %v", m.Data)
+			}
+			return
+		}
+	}
+	t.Fatal("published event not received")
+}
@@ -5,6 +5,7 @@ import { useWebSocket } from './hooks/useWebSocket'
 import { useStore } from './store/useStore'
 import { usePipelines } from './hooks/usePipelines'
 import AddSourceModal from './components/AddSourceModal'
+import TokenPrompt from './components/TokenPrompt'
 import Dashboard from './pages/Dashboard'
 import Pipelines from './pages/Pipelines'
 import Signals from './pages/Signals'
@@ -280,6 +281,7 @@ function Header({ onAddSource }: { onAddSource: () => void }) {
 function AppShell() {
   useWebSocket()
   const [showAddSource, setShowAddSource] = useState(false)
+  const authRequired = useStore((s) => s.authRequired)
 
   return (
     <div className="flex flex-col min-h-screen" style={{ background: '#080c10', color: '#e8f1ff' }}>
@@ -297,6 +299,7 @@ function AppShell() {
         </main>
       </div>
       {showAddSource && <AddSourceModal onClose={() => setShowAddSource(false)} />}
+      {authRequired && <TokenPrompt />}
     </div>
   )
 }
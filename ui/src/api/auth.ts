@@ -0,0 +1,32 @@
+// Access token for servers with server.auth.http credentials configured.
+// It is kept in localStorage so a reload does not ask again, and may be
+// handed over in the dashboard link as ?token=..., which is then removed from
+// the address bar.
+
+const STORAGE_KEY = 'obsidianstack.token'
+
+export function loadToken(): string | null {
+  const params = new URLSearchParams(location.search)
+  const fromURL = params.get('token')
+  if (fromURL) {
+    saveToken(fromURL)
+    params.delete('token')
+    const qs = params.toString()
+    history.replaceState(null, '', `${location.pathname}${qs ? `?${qs}` : ''}${location.hash}`)
+    return fromURL
+  }
+  try {
+    return localStorage.getItem(STORAGE_KEY)
+  } catch {
+    return null // storage disabled
+  }
+}
+
+export function saveToken(token: string | null) {
+  try {
+    if (token) localStorage.setItem(STORAGE_KEY, token)
+    else localStorage.removeItem(STORAGE_KEY)
+  } catch {
+    // storage disabled — the token lasts until the page is reloaded
+  }
+}
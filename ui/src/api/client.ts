@@ -2,15 +2,22 @@ import type {
   AlertEntry,
   CertEntry,
   HealthResponse,
+  HistoryParams,
+  HistoryResponse,
   PipelineResponse,
   SignalsResponse,
   SnapshotResponse,
 } from './types'
+import { useStore } from '../store/useStore'
 
 const BASE = '/api/v1'
 
 async function get<T>(path: string): Promise<T> {
-  const res = await fetch(`${BASE}${path}`)
+  const token = useStore.getState().token
+  const res = await fetch(`${BASE}${path}`, {
+    headers: token ? { Authorization: `Bearer ${token}` } : {},
+  })
+  if (res.status === 401) useStore.getState().requireAuth()
   if (!res.ok) {
     throw new Error(`GET ${path} failed: ${res.status} ${res.statusText}`)
   }
@@ -21,6 +28,15 @@ export const api = {
   health: () => get<HealthResponse>('/health'),
   pipelines: () => get<PipelineResponse[]>('/pipelines'),
   pipeline: (id: string) => get<PipelineResponse>(`/pipelines/${encodeURIComponent(id)}`),
+  history: (id: string, p: HistoryParams = {}) => {
+    const q = new URLSearchParams()
+    if (p.from) q.set('from', p.from)
+    if (p.to) q.set('to', p.to)
+    if (p.step) q.set('step', p.step)
+    if (p.fields?.length) q.set('fields', p.fields.join(','))
+    if (p.agg) q.set('agg', p.agg)
+    return get<HistoryResponse>(`/pipelines/${encodeURIComponent(id)}/history?${q}`)
+  },
   signals: () => get<SignalsResponse>('/signals'),
   alerts: () => get<AlertEntry[]>('/alerts'),
   certs: () => get<CertEntry[]>('/certs'),
@@ -80,9 +80,15 @@ export interface AlertEntry {
   severity: 'critical' | 'warning' | 'info'
   message: string
   value: number
-  fired_at: string
+  active_at: string // when the condition started holding
+  fired_at?: string // absent while pending
   resolved_at?: string
-  state: 'firing' | 'resolved'
+  state: 'pending' | 'firing' | 'resolved'
+  silenced: boolean // a silence or maintenance window matches; no notifications
+  silenced_by?: string[] // silence IDs, or "maintenance:<name>"
+  acknowledged_by?: string
+  acknowledged_at?: string
+  note?: string
 }
 
 export interface SnapshotResponse {
@@ -90,8 +96,44 @@ export interface SnapshotResponse {
   generated_at: string
 }
 
+// GET /api/v1/pipelines/{id}/history — downsampled series, oldest first.
+export interface HistoryPoint {
+  t: string // step start, RFC3339
+  v: number
+}
+
+export interface HistorySeries {
+  field: string // e.g. "drop_pct", "logs.received_pm"
+  points: HistoryPoint[]
+}
+
+export interface HistoryResponse {
+  source_id: string
+  from: string
+  to: string
+  step_seconds: number
+  agg: 'avg' | 'min' | 'max'
+  series: HistorySeries[]
+}
+
+export interface HistoryParams {
+  from?: string // RFC3339 or unix seconds
+  to?: string
+  step?: string // duration ("5m") or seconds
+  fields?: string[]
+  agg?: 'avg' | 'min' | 'max'
+}
+
 // WebSocket message envelope — matches server/internal/ws Message type.
-export interface WsMessage {
+export interface WsSnapshotMessage {
   event: 'snapshot'
   data: SnapshotResponse
 }
+
+// Pushed when an alert is acknowledged or unacknowledged.
+export interface WsAlertMessage {
+  event: 'alert.ack' | 'alert.unack'
+  data: AlertEntry
+}
+
+export type WsMessage = WsSnapshotMessage | WsAlertMessage
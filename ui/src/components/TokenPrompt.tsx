@@ -0,0 +1,55 @@
+import { useState } from 'react'
+import { useQueryClient } from '@tanstack/react-query'
+import { useStore } from '../store/useStore'
+
+// Asks for an API token once the server has rejected a request with 401.
+// It is sent as "Authorization: Bearer", so it must be a bearer credential
+// from server.auth.http; a read one is enough for the dashboard.
+export default function TokenPrompt() {
+  const setToken = useStore((s) => s.setToken)
+  const queryClient = useQueryClient()
+  const [value, setValue] = useState('')
+
+  function submit(e: React.FormEvent) {
+    e.preventDefault()
+    if (!value.trim()) return
+    setToken(value.trim())
+    queryClient.invalidateQueries()
+  }
+
+  return (
+    <div
+      className="fixed inset-0 z-[100] flex items-center justify-center p-4"
+      style={{ background: 'rgba(8,12,16,0.85)', backdropFilter: 'blur(4px)' }}
+    >
+      <form
+        onSubmit={submit}
+        className="w-full max-w-sm rounded-xl overflow-hidden shadow-2xl px-5 py-4 space-y-3"
+        style={{ background: '#0d1117', border: '1px solid #1e2d3d' }}
+      >
+        <div>
+          <h2 className="font-syne text-[15px] font-bold text-obs-text">Sign in</h2>
+          <p className="text-[11px] text-obs-muted mt-0.5">
+            This server requires an API token. It is stored in this browser.
+          </p>
+        </div>
+        <input
+          type="password"
+          autoFocus
+          value={value}
+          placeholder="Bearer token"
+          onChange={(e) => setValue(e.target.value)}
+          className="w-full rounded px-3 py-2 text-[12px] text-obs-text outline-none"
+          style={{ background: '#111820', border: '1px solid #1e2d3d', fontFamily: '"JetBrains Mono", monospace' }}
+        />
+        <button
+          type="submit"
+          className="w-full px-3 py-1.5 rounded text-[11px] font-semibold transition-colors hover:brightness-110"
+          style={{ background: '#00d4ff', color: '#080c10' }}
+        >
+          Continue
+        </button>
+      </form>
+    </div>
+  )
+}
@@ -1,13 +1,22 @@
 import { useEffect, useRef } from 'react'
+import { useQueryClient } from '@tanstack/react-query'
 import type { WsMessage } from '../api/types'
 import { useStore } from '../store/useStore'
 
 const WS_URL = `${location.protocol === 'https:' ? 'wss' : 'ws'}://${location.host}/ws/stream`
+
+// Browsers cannot set headers on a WebSocket upgrade, so the token goes in
+// the query string, which the server accepts on upgrades only.
+function streamURL(token: string | null) {
+  return token ? `${WS_URL}?access_token=${encodeURIComponent(token)}` : WS_URL
+}
 const RECONNECT_DELAY_MS = 3_000
 
 export function useWebSocket() {
   const setLiveSnapshot = useStore((s) => s.setLiveSnapshot)
   const setWsConnected = useStore((s) => s.setWsConnected)
+  const token = useStore((s) => s.token)
+  const queryClient = useQueryClient()
   const wsRef = useRef<WebSocket | null>(null)
   const timerRef = useRef<ReturnType<typeof setTimeout> | null>(null)
 
@@ -16,7 +25,7 @@ export function useWebSocket() {
 
     function connect() {
       if (cancelled) return
-      const ws = new WebSocket(WS_URL)
+      const ws = new WebSocket(streamURL(token))
       wsRef.current = ws
 
       ws.onopen = () => {
@@ -28,6 +37,9 @@ export function useWebSocket() {
         try {
           const msg = JSON.parse(ev.data as string) as WsMessage
           if (msg.event === 'snapshot') setLiveSnapshot(msg)
+          else if (msg.event === 'alert.ack' || msg.event === 'alert.unack') {
+            queryClient.invalidateQueries({ queryKey: ['alerts'] })
+          }
         } catch {
           // malformed message — ignore
         }
@@ -52,5 +64,5 @@ export function useWebSocket() {
       wsRef.current?.close()
       setWsConnected(false)
     }
-  }, [setLiveSnapshot, setWsConnected])
+  }, [setLiveSnapshot, setWsConnected, queryClient, token])
 }
@@ -328,13 +328,17 @@ function AlertRow({ alert: a }: { alert: AlertEntry }) {
         <p className="font-semibold truncate" style={{ color: a.state === 'firing' ? color : '#6b8ba8' }}>
           {a.rule_name}
         </p>
-        <p className="text-obs-muted truncate">{a.source_id}</p>
+        <p className="text-obs-muted truncate">
+          {a.source_id}
+          {a.silenced && <span title={a.silenced_by?.join(', ')}> · silenced</span>}
+          {a.acknowledged_by && <span title={a.note}> · ack {a.acknowledged_by}</span>}
+        </p>
       </div>
       <span
         className="text-[9px] font-bold px-1.5 py-0.5 rounded flex-shrink-0"
         style={{ background: `${color}15`, color, border: `1px solid ${color}30` }}
       >
-        {a.state === 'resolved' ? 'OK' : a.severity.toUpperCase()}
+        {a.state === 'resolved' ? 'OK' : a.state === 'pending' ? 'PENDING' : a.severity.toUpperCase()}
       </span>
     </div>
   )
@@ -1,4 +1,5 @@
 import { useState } from 'react'
+import { useSearchParams } from 'react-router-dom'
 import { useStore } from '../store/useStore'
 import { usePipelines } from '../hooks/usePipelines'
 import type { PipelineResponse, SignalResponse } from '../api/types'
@@ -273,7 +274,9 @@ export default function Pipelines() {
 
   const [sortKey, setSortKey] = useState<SortKey>('strength_score')
   const [sortAsc, setSortAsc] = useState(false)
-  const [filter, setFilter] = useState('')
+  // ?source=<id> pre-fills the filter: alert notifications link here.
+  const [params] = useSearchParams()
+  const [filter, setFilter] = useState(params.get('source') ?? '')
   const [drawerPipeline, setDrawerPipeline] = useState<PipelineResponse | null>(null)
 
   function toggleSort(key: SortKey) {
@@ -1,5 +1,6 @@
 import { create } from 'zustand'
-import type { SnapshotResponse, WsMessage } from '../api/types'
+import type { SnapshotResponse, WsSnapshotMessage } from '../api/types'
+import { loadToken, saveToken } from '../api/auth'
 
 interface AppState {
   /** Latest snapshot received via WebSocket. Null until first message arrives. */
@@ -8,8 +9,16 @@ interface AppState {
   /** True while the WebSocket connection is open. */
   wsConnected: boolean
 
-  setLiveSnapshot: (msg: WsMessage) => void
+  /** API token sent with every request; null when none is set. */
+  token: string | null
+
+  /** True once the server has rejected the request credentials (HTTP 401). */
+  authRequired: boolean
+
+  setLiveSnapshot: (msg: WsSnapshotMessage) => void
   setWsConnected: (connected: boolean) => void
+  setToken: (token: string) => void
+  requireAuth: () => void
 }
 
 export const useStore = create<AppState>((set) => ({
@@ -17,4 +26,14 @@ export const useStore = create<AppState>((set) => ({
   wsConnected: false,
   setLiveSnapshot: (msg) => set({ liveSnapshot: msg.data }),
   setWsConnected: (wsConnected) => set({ wsConnected }),
+  token: loadToken(),
+  authRequired: false,
+  setToken: (token) => {
+    saveToken(token)
+    set({ token, authRequired: false })
+  },
+  requireAuth: () => {
+    saveToken(null)
+    set({ token: null, authRequired: true })
+  },
 }))